@@ -0,0 +1,122 @@
+package filesystem
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+/*
+Backend represents the low-level disk operations that every helper in this
+package is built on top of. By supplying your own implementation to
+GetFileSystem, you can redirect all file system activity to an alternate
+storage location (such as memory) instead of the local disk.
+*/
+type Backend interface {
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.FileInfo, error)
+	Rename(oldName string, newName string) error
+	Remove(name string) error
+	RemoveAll(name string) error
+	Mkdir(name string, perm os.FileMode) error
+	MkdirAll(name string, perm os.FileMode) error
+	Chmod(name string, mode os.FileMode) error
+	Chown(name string, uid int, gid int) error
+	Chtimes(name string, accessTime time.Time, modificationTime time.Time) error
+	Symlink(oldName string, newName string) error
+	Readlink(name string) (string, error)
+}
+
+/*
+File represents an open file handle returned by a Backend. The method set
+mirrors the parts of '*os.File' which are used by this package.
+*/
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Writer
+	io.WriterAt
+	io.Seeker
+	io.Closer
+	Name() string
+	Stat() (os.FileInfo, error)
+	Sync() error
+	Truncate(size int64) error
+	WriteString(stringToWrite string) (int, error)
+	Readdirnames(count int) ([]string, error)
+}
+
+type osBackendType struct{}
+
+/*
+GetOsBackend allows you to obtain a backend which performs all operations
+directly against the local file system. This is the backend used by all
+package level functions.
+*/
+func GetOsBackend() Backend {
+	return osBackendType{}
+}
+
+func (shared osBackendType) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	file, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		// Return an untyped nil so callers comparing against nil behave as expected.
+		return nil, err
+	}
+	return file, nil
+}
+
+func (shared osBackendType) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (shared osBackendType) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (shared osBackendType) ReadDir(name string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(name)
+}
+
+func (shared osBackendType) Rename(oldName string, newName string) error {
+	return os.Rename(oldName, newName)
+}
+
+func (shared osBackendType) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (shared osBackendType) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+func (shared osBackendType) Mkdir(name string, perm os.FileMode) error {
+	return os.Mkdir(name, perm)
+}
+
+func (shared osBackendType) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+func (shared osBackendType) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+func (shared osBackendType) Chown(name string, uid int, gid int) error {
+	return os.Chown(name, uid, gid)
+}
+
+func (shared osBackendType) Chtimes(name string, accessTime time.Time, modificationTime time.Time) error {
+	return os.Chtimes(name, accessTime, modificationTime)
+}
+
+func (shared osBackendType) Symlink(oldName string, newName string) error {
+	return os.Symlink(oldName, newName)
+}
+
+func (shared osBackendType) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
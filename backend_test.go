@@ -0,0 +1,68 @@
+package filesystem
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+type recordingBackendType struct {
+	Backend
+	openedFiles  []string
+	removedFiles []string
+}
+
+func (shared *recordingBackendType) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	shared.openedFiles = append(shared.openedFiles, name)
+	return shared.Backend.OpenFile(name, flag, perm)
+}
+
+func (shared *recordingBackendType) Remove(name string) error {
+	shared.removedFiles = append(shared.removedFiles, name)
+	return shared.Backend.Remove(name)
+}
+
+func TestFileSystemUsesBackend(test *testing.T) {
+	backend := &recordingBackendType{Backend: GetOsBackend()}
+	fileSystem := GetFileSystem(backend)
+	sourceFile := "/tmp/backend_source.txt"
+	err := fileSystem.WriteBytesToFile(sourceFile, []byte("sample_string"), 0666)
+	assert.NoErrorf(test, err, "An error was not expected when creating a sample file!")
+	fileContents, err := fileSystem.GetFileContentsAsBytes(sourceFile)
+	assert.NoErrorf(test, err, "An error was not expected when reading a sample file!")
+	assert.Equalf(test, "sample_string", string(fileContents), "The file contents were not as expected!")
+	err = fileSystem.DeleteFile(sourceFile)
+	assert.NoErrorf(test, err, "An error was not expected when deleting a sample file!")
+	assert.Equalf(test, []string{sourceFile, sourceFile}, backend.openedFiles, "All file access was expected to go through the backend!")
+	assert.Equalf(test, []string{sourceFile}, backend.removedFiles, "All deletions were expected to go through the backend!")
+}
+
+func TestFileInstanceUsesBackend(test *testing.T) {
+	backend := &recordingBackendType{Backend: GetOsBackend()}
+	fileSystem := GetFileSystem(backend)
+	fileName := "/tmp/backend_instance.txt"
+	file := fileSystem.GetFileInstance()
+	err := file.Open(fileName, 0)
+	assert.NoErrorf(test, err, "An error was not expected when opening a file.")
+	err = file.WriteLine("First written line.")
+	assert.NoErrorf(test, err, "An error was not expected when writing lines to a file.")
+	file.Close()
+	assert.Equalf(test, []string{fileName}, backend.openedFiles, "The file instance was expected to open files through its backend!")
+	err = fileSystem.DeleteFile(fileName)
+	assert.NoErrorf(test, err, "An error was not expected when deleting a sample file!")
+}
+
+func TestGlobThroughBackend(test *testing.T) {
+	fileSystem := GetFileSystem(GetOsBackend())
+	err := fileSystem.CreateDirectory("/tmp/glob_test", 0)
+	assert.NoErrorf(test, err, "An error was not expected when creating a directory.")
+	for _, fileName := range []string{"/tmp/glob_test/a.txt", "/tmp/glob_test/b.txt", "/tmp/glob_test/c.log"} {
+		err = fileSystem.WriteBytesToFile(fileName, []byte("sample_string"), 0666)
+		assert.NoErrorf(test, err, "An error was not expected when creating a sample file!")
+	}
+	obtainedValue, err := fileSystem.glob("/tmp/glob_test/*.txt")
+	assert.NoErrorf(test, err, "An error was not expected when matching a pattern!")
+	assert.Equalf(test, []string{"/tmp/glob_test/a.txt", "/tmp/glob_test/b.txt"}, obtainedValue, "The matched files were not as expected!")
+	err = fileSystem.DeleteDirectory("/tmp/glob_test")
+	assert.NoErrorf(test, err, "An error was not expected when deleting a directory.")
+}
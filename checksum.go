@@ -0,0 +1,152 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"regexp"
+	"strings"
+)
+
+/*
+checksumType holds a digest along with the name of the algorithm which
+produced it.
+*/
+type checksumType struct {
+	algorithm string
+	digest    []byte
+}
+
+/*
+bsdChecksumLineMatcher matches a line of a checksum file in the format
+written by the BSD tools, such as "SHA256 (file.bin) = <hex>".
+*/
+var bsdChecksumLineMatcher = regexp.MustCompile(`^([A-Za-z0-9]+) \((.*)\) = ([0-9A-Fa-f]+)$`)
+
+/*
+getChecksumHash allows you to obtain a new hash for the algorithm name
+provided, or nil if the algorithm is not supported.
+*/
+func getChecksumHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "md5":
+		return md5.New()
+	case "sha256":
+		return sha256.New()
+	case "sha512":
+		return sha512.New()
+	}
+	return nil
+}
+
+/*
+getChecksumAlgorithmForDigest allows you to guess the algorithm which
+produced a digest from its length, returning an empty string if no
+supported algorithm matches.
+*/
+func getChecksumAlgorithmForDigest(digest []byte) string {
+	switch len(digest) {
+	case md5.Size:
+		return "md5"
+	case sha256.Size:
+		return "sha256"
+	case sha512.Size:
+		return "sha512"
+	}
+	return ""
+}
+
+/*
+parseChecksum allows you to parse an expected checksum. In addition, the
+following information should be noted:
+
+- A checksum may be given as hexadecimal prefixed with the algorithm name,
+such as "sha256:<hex>", or as plain hexadecimal in which case the algorithm
+is determined from its length.
+
+- Subresource Integrity values, such as "sha512-<base64>", are also
+accepted. When several are given separated by spaces, the first one using
+a supported algorithm is used.
+*/
+func parseChecksum(checksum string) (checksumType, error) {
+	checksum = strings.TrimSpace(checksum)
+	invalidError := fmt.Errorf("%w '%s'", ErrInvalidChecksum, checksum)
+	if separatorIndex := strings.Index(checksum, ":"); separatorIndex >= 0 {
+		algorithm := strings.ToLower(checksum[:separatorIndex])
+		digest, err := hex.DecodeString(checksum[separatorIndex+1:])
+		if err != nil || getChecksumHash(algorithm) == nil || getChecksumHash(algorithm).Size() != len(digest) {
+			return checksumType{}, invalidError
+		}
+		return checksumType{algorithm: algorithm, digest: digest}, nil
+	}
+	if digest, err := hex.DecodeString(checksum); err == nil {
+		algorithm := getChecksumAlgorithmForDigest(digest)
+		if algorithm == "" {
+			return checksumType{}, invalidError
+		}
+		return checksumType{algorithm: algorithm, digest: digest}, nil
+	}
+	for _, integrityValue := range strings.Fields(checksum) {
+		separatorIndex := strings.Index(integrityValue, "-")
+		if separatorIndex < 0 {
+			continue
+		}
+		algorithm := strings.ToLower(integrityValue[:separatorIndex])
+		encodedDigest := integrityValue[separatorIndex+1:]
+		if optionIndex := strings.Index(encodedDigest, "?"); optionIndex >= 0 {
+			encodedDigest = encodedDigest[:optionIndex]
+		}
+		digest, err := base64.StdEncoding.DecodeString(encodedDigest)
+		if err == nil && getChecksumHash(algorithm) != nil && getChecksumHash(algorithm).Size() == len(digest) {
+			return checksumType{algorithm: algorithm, digest: digest}, nil
+		}
+	}
+	return checksumType{}, invalidError
+}
+
+/*
+parseChecksumFile allows you to find the checksum of a file from the
+contents of a checksum file. In addition, the following information should
+be noted:
+
+- Lines written by 'sha256sum' and similar tools, BSD style lines, and a
+file containing nothing but a digest are all understood.
+
+- When the checksum file lists more than one file, the line for the file
+name provided is used.
+*/
+func parseChecksumFile(contents []byte, fileName string) (checksumType, error) {
+	var entries [][2]string
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if match := bsdChecksumLineMatcher.FindStringSubmatch(line); match != nil {
+			entries = append(entries, [2]string{strings.ToLower(match[1]) + ":" + match[3], match[2]})
+			continue
+		}
+		fields := strings.Fields(line)
+		entryFileName := ""
+		if len(fields) > 1 {
+			entryFileName = strings.TrimPrefix(strings.TrimPrefix(fields[1], "*"), "./")
+		}
+		entries = append(entries, [2]string{fields[0], entryFileName})
+	}
+	if len(entries) == 1 {
+		return parseChecksum(entries[0][0])
+	}
+	for _, entry := range entries {
+		if entry[1] == fileName {
+			return parseChecksum(entry[0])
+		}
+	}
+	return checksumType{}, fmt.Errorf("%w: no checksum for '%s' found in checksum file", ErrInvalidChecksum, fileName)
+}
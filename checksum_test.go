@@ -0,0 +1,47 @@
+package filesystem
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseChecksum(test *testing.T) {
+	sha256Digest := sha256.Sum256([]byte("content"))
+	sha512Digest := sha512.Sum512([]byte("content"))
+	sha256Hex := hex.EncodeToString(sha256Digest[:])
+	for _, checksum := range []string{sha256Hex, "sha256:" + sha256Hex, "SHA256:" + sha256Hex, "sha256-" + base64.StdEncoding.EncodeToString(sha256Digest[:])} {
+		parsedChecksum, err := parseChecksum(checksum)
+		assert.NoErrorf(test, err, "An error was not expected when parsing '%s'.", checksum)
+		assert.Equalf(test, "sha256", parsedChecksum.algorithm, "The algorithm of '%s' was not as expected.", checksum)
+		assert.Equalf(test, sha256Digest[:], parsedChecksum.digest, "The digest of '%s' was not as expected.", checksum)
+	}
+	parsedChecksum, err := parseChecksum("sha1-unknown sha512-" + base64.StdEncoding.EncodeToString(sha512Digest[:]) + "?option")
+	assert.NoErrorf(test, err, "An error was not expected when parsing several integrity values.")
+	assert.Equalf(test, "sha512", parsedChecksum.algorithm, "The first supported integrity value was expected to be used.")
+	for _, checksum := range []string{"", "abcd", "sha256:abcd", "sha1:" + sha256Hex, "md5-" + base64.StdEncoding.EncodeToString(sha256Digest[:])} {
+		_, err := parseChecksum(checksum)
+		assert.Truef(test, errors.Is(err, ErrInvalidChecksum), "The checksum '%s' was expected to be rejected.", checksum)
+	}
+}
+
+func TestParseChecksumFile(test *testing.T) {
+	firstDigest := sha256.Sum256([]byte("first"))
+	secondDigest := sha256.Sum256([]byte("second"))
+	firstHex := hex.EncodeToString(firstDigest[:])
+	secondHex := hex.EncodeToString(secondDigest[:])
+	checksumFile := "# Release checksums\n" + firstHex + "  first.tar.gz\n" + secondHex + " *second.tar.gz\n"
+	parsedChecksum, err := parseChecksumFile([]byte(checksumFile), "second.tar.gz")
+	assert.NoErrorf(test, err, "An error was not expected when parsing a checksum file.")
+	assert.Equalf(test, secondDigest[:], parsedChecksum.digest, "The checksum of the named file was expected to be used.")
+	_, err = parseChecksumFile([]byte(checksumFile), "third.tar.gz")
+	assert.Truef(test, errors.Is(err, ErrInvalidChecksum), "A file missing from the checksum file was expected to be rejected.")
+	parsedChecksum, _ = parseChecksumFile([]byte("SHA256 (first.tar.gz) = "+firstHex+"\n"), "other.tar.gz")
+	assert.Equalf(test, firstDigest[:], parsedChecksum.digest, "A single BSD style line was expected to be used.")
+	parsedChecksum, _ = parseChecksumFile([]byte(firstHex+"\n"), "first.tar.gz")
+	assert.Equalf(test, firstDigest[:], parsedChecksum.digest, "A checksum file holding only a digest was expected to be used.")
+}
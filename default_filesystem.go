@@ -0,0 +1,453 @@
+package filesystem
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+)
+
+/*
+defaultFileSystem is the file system instance used by all package level
+functions. It operates directly against the local file system.
+*/
+var defaultFileSystem = GetFileSystem(GetOsBackend())
+
+/*
+GetFileInstance allows you to obtain a file instance to work on.
+*/
+func GetFileInstance() fileInstanceType {
+	return defaultFileSystem.GetFileInstance()
+}
+
+/*
+IsFileContainsText allows you to check if a file contains a matching string
+or not. The file is streamed and reading stops at the first match.
+*/
+func IsFileContainsText(filename string, regexMatcher string) (bool, error) {
+	return defaultFileSystem.IsFileContainsText(filename, regexMatcher)
+}
+
+/*
+FindReplaceInFile allows you to find and replace text from within a file.
+The file is atomically replaced once every line has been processed.
+*/
+func FindReplaceInFile(filename string, regexMatcher string, replacementValue string) error {
+	return defaultFileSystem.FindReplaceInFile(filename, regexMatcher, replacementValue)
+}
+
+/*
+GetLastLineFromFile allows you to obtain the last line from a file without
+reading the whole file.
+*/
+func GetLastLineFromFile(fileName string) (string, error) {
+	return defaultFileSystem.GetLastLineFromFile(fileName)
+}
+
+/*
+GetFileContents allows you to get the entire contents of a file. In
+addition, the following information should be noted:
+
+- An error is returned if the file does not exist, rather than an empty
+file being created for you.
+*/
+func GetFileContents(fileName string) ([]byte, error) {
+	return defaultFileSystem.GetFileContents(fileName)
+}
+
+/*
+RemoveFirstLineFromFile allows you to remove the first line from a file.
+*/
+func RemoveFirstLineFromFile(fileName string) error {
+	return defaultFileSystem.RemoveFirstLineFromFile(fileName)
+}
+
+/*
+DownloadFile allows you to download a file from the internet to your local
+file system.
+*/
+func DownloadFile(url string, filepath string, header http.Header) error {
+	return defaultFileSystem.DownloadFile(url, filepath, header)
+}
+
+/*
+CopyFile allows you to copy a file from one source location to a target
+destination location. In the event the operation could not be completed,
+an error is returned to the user.
+*/
+func CopyFile(sourceFile string, destinationFile string) error {
+	return defaultFileSystem.CopyFile(sourceFile, destinationFile)
+}
+
+/*
+CopyFileWithOptions allows you to copy a file from one source location to a
+target destination location using the options provided.
+*/
+func CopyFileWithOptions(sourceFile string, destinationFile string, options CopyOptions) error {
+	return defaultFileSystem.CopyFileWithOptions(sourceFile, destinationFile, options)
+}
+
+/*
+WriteBytesToFile allows you to write a series of bytes to a given file.
+If you pass in a permissions value of '0', the default value of 744 will
+be used instead.
+*/
+func WriteBytesToFile(fileName string, bytesToWrite []byte, permissions int) error {
+	return defaultFileSystem.WriteBytesToFile(fileName, bytesToWrite, permissions)
+}
+
+/*
+GetFileContentsAsBytes allows you to get the contents of a file as a byte
+array.
+*/
+func GetFileContentsAsBytes(fileName string) ([]byte, error) {
+	return defaultFileSystem.GetFileContentsAsBytes(fileName)
+}
+
+/*
+AppendLineToFile allows you to append a line to the end of a file. If you
+pass in a permissions value of '0', the default value of 744 will be used
+instead.
+*/
+func AppendLineToFile(fileName string, lineToWrite string, permissions int) error {
+	return defaultFileSystem.AppendLineToFile(fileName, lineToWrite, permissions)
+}
+
+/*
+GetListOfFiles allows you to obtain a list of files that match a given regular
+expression.
+*/
+func GetListOfFiles(directoryPath string, regexMatcher string) ([]string, error) {
+	return defaultFileSystem.GetListOfFiles(directoryPath, regexMatcher)
+}
+
+/*
+GetListOfDirectories allows you to obtain a list of files that match a given
+regular expression.
+*/
+func GetListOfDirectories(directoryPath string, regexMatcher string) ([]string, error) {
+	return defaultFileSystem.GetListOfDirectories(directoryPath, regexMatcher)
+}
+
+/*
+IsDirectory allows you to check if a disk entry is a directory or not.
+*/
+func IsDirectory(directoryPath string) bool {
+	return defaultFileSystem.IsDirectory(directoryPath)
+}
+
+/*
+GetListOfDirectoryContents allows you to obtain a list of files and directories
+that match a given regular expression.
+*/
+func GetListOfDirectoryContents(directoryPath string, regexMatchers []string, isFilesIncluded bool, isDirectoriesIncluded bool) ([]string, error) {
+	return defaultFileSystem.GetListOfDirectoryContents(directoryPath, regexMatchers, isFilesIncluded, isDirectoriesIncluded)
+}
+
+/*
+FindMatchingContent allows you to find matching content from a given directory
+path. Both shallow and recursive searches are supported and results are
+returned as a fully qualified path.
+*/
+func FindMatchingContent(directoryPath string, regexMatchers []string, isFilesIncluded bool, isDirectoriesIncluded bool, isRecursive bool) ([]string, error) {
+	return defaultFileSystem.FindMatchingContent(directoryPath, regexMatchers, isFilesIncluded, isDirectoriesIncluded, isRecursive)
+}
+
+/*
+IsDirectoryEmpty allows you to detect if a directory is empty or not.
+*/
+func IsDirectoryEmpty(directoryName string) (bool, error) {
+	return defaultFileSystem.IsDirectoryEmpty(directoryName)
+}
+
+/*
+GetFileSize allows you to obtain the size of a specified file in bytes.
+*/
+func GetFileSize(fileName string) (int64, error) {
+	return defaultFileSystem.GetFileSize(fileName)
+}
+
+/*
+RenameFile allows you to rename a file on your local file system. In the
+event that a file with the same name already exists, it will be overwritten.
+*/
+func RenameFile(sourceFileName string, targetFileName string) error {
+	return defaultFileSystem.RenameFile(sourceFileName, targetFileName)
+}
+
+/*
+IsDirectoryExists allows you to check if a directory exists or not on the file
+system.
+*/
+func IsDirectoryExists(directoryPath string) bool {
+	return defaultFileSystem.IsDirectoryExists(directoryPath)
+}
+
+/*
+IsFileExists allows you to check if a file exists or not on the file system.
+*/
+func IsFileExists(filePath string) bool {
+	return defaultFileSystem.IsFileExists(filePath)
+}
+
+/*
+DeleteFile allows you to delete a file on the file system.
+*/
+func DeleteFile(fileName string) error {
+	return defaultFileSystem.DeleteFile(fileName)
+}
+
+/*
+DeleteFilesMatchingPattern allows you to delete files matching a specific
+pattern. Pattern syntax is the same as the 'Match' command.
+*/
+func DeleteFilesMatchingPattern(fileName string) error {
+	return defaultFileSystem.DeleteFilesMatchingPattern(fileName)
+}
+
+/*
+DeleteDirectory allows you to recursively remove a directory from the file
+system.
+*/
+func DeleteDirectory(pathName string) error {
+	return defaultFileSystem.DeleteDirectory(pathName)
+}
+
+/*
+MoveFile allows you to move a file from one location to another. This method
+is an alias which simply performs a rename command, which is capable of doing
+the same action.
+*/
+func MoveFile(sourceFile string, destinationFile string) error {
+	return defaultFileSystem.MoveFile(sourceFile, destinationFile)
+}
+
+/*
+MoveDirectories allows you to move a directory from one location to
+another.
+*/
+func MoveDirectories(sourceDir string, destinationDir string) error {
+	return defaultFileSystem.MoveDirectories(sourceDir, destinationDir)
+}
+
+/*
+CreateDirectory allows you to create a directory on your local file system.
+*/
+func CreateDirectory(directoryPath string, permissions uint32) error {
+	return defaultFileSystem.CreateDirectory(directoryPath, permissions)
+}
+
+/*
+IsFile allows you to check if an item on the file system is a file or not.
+*/
+func IsFile(path string) (bool, error) {
+	return defaultFileSystem.IsFile(path)
+}
+
+/*
+GetIoFS allows you to expose a directory of your local file system as a
+standard 'io/fs' file system.
+*/
+func GetIoFS(directoryPath string) fs.FS {
+	return defaultFileSystem.GetIoFS(directoryPath)
+}
+
+/*
+GetLineScannerFromFile allows you to stream the lines of a file one at a
+time without loading the whole file into memory. The scanner must be closed
+once you are finished with it.
+*/
+func GetLineScannerFromFile(fileName string, options LineScannerOptions) (*lineScannerType, error) {
+	return defaultFileSystem.GetLineScannerFromFile(fileName, options)
+}
+
+/*
+GetLinesFromFile allows you to obtain a range of lines from a file, starting
+at the zero-based line index provided, without loading the whole file into
+memory.
+*/
+func GetLinesFromFile(fileName string, startIndex int64, count int) ([]string, error) {
+	return defaultFileSystem.GetLinesFromFile(fileName, startIndex, count)
+}
+
+/*
+CountLinesInFile allows you to count the number of lines in a file without
+loading the whole file into memory.
+*/
+func CountLinesInFile(fileName string) (int64, error) {
+	return defaultFileSystem.CountLinesInFile(fileName)
+}
+
+/*
+GetReverseLineScannerFromFile allows you to iterate over the lines of a
+file starting from the last line and working towards the first. The
+scanner must be closed once you are finished with it.
+*/
+func GetReverseLineScannerFromFile(fileName string, options ReverseLineScannerOptions) (*reverseLineScannerType, error) {
+	return defaultFileSystem.GetReverseLineScannerFromFile(fileName, options)
+}
+
+/*
+GetLastNLinesFromFile allows you to obtain up to the last N lines of a
+file, in the order they appear in the file.
+*/
+func GetLastNLinesFromFile(fileName string, count int) ([]string, error) {
+	return defaultFileSystem.GetLastNLinesFromFile(fileName, count)
+}
+
+/*
+Follow allows you to receive every line appended to a file as it is
+written, in the same way as 'tail -F'. Following continues until the
+context is cancelled.
+*/
+func Follow(ctx context.Context, fileName string, options FollowOptions, callback func(FollowedLine) error) error {
+	return defaultFileSystem.Follow(ctx, fileName, options, callback)
+}
+
+/*
+GetFollowChannel allows you to receive every line appended to a file on a
+channel until the context is cancelled.
+*/
+func GetFollowChannel(ctx context.Context, fileName string, options FollowOptions) (<-chan FollowedLine, <-chan error) {
+	return defaultFileSystem.GetFollowChannel(ctx, fileName, options)
+}
+
+/*
+GetFileQueue allows you to obtain a durable first in, first out queue which
+stores one item per line in the file provided. The queue must be closed once
+you are finished with it.
+*/
+func GetFileQueue(fileName string, options FileQueueOptions) (*fileQueueType, error) {
+	return defaultFileSystem.GetFileQueue(fileName, options)
+}
+
+/*
+GetFileStack allows you to obtain a durable last in, first out stack which
+stores one item per line in the file provided. The stack must be closed once
+you are finished with it.
+*/
+func GetFileStack(fileName string) (*fileStackType, error) {
+	return defaultFileSystem.GetFileStack(fileName)
+}
+
+/*
+InsertLineIntoFile allows you to insert a line into a file so that it ends
+up at the zero-based line index provided.
+*/
+func InsertLineIntoFile(fileName string, lineIndex int64, lineToInsert string) error {
+	return defaultFileSystem.InsertLineIntoFile(fileName, lineIndex, lineToInsert)
+}
+
+/*
+ReplaceLineInFile allows you to replace the line at the zero-based line
+index provided with a new line.
+*/
+func ReplaceLineInFile(fileName string, lineIndex int64, replacementLine string) error {
+	return defaultFileSystem.ReplaceLineInFile(fileName, lineIndex, replacementLine)
+}
+
+/*
+DeleteLinesFromFile allows you to delete a range of lines from a file,
+starting at the zero-based line index provided.
+*/
+func DeleteLinesFromFile(fileName string, startIndex int64, count int64) error {
+	return defaultFileSystem.DeleteLinesFromFile(fileName, startIndex, count)
+}
+
+/*
+AppendLinesToFile allows you to append several lines to the end of a file,
+each terminated with a newline. If you pass in a permissions value of '0',
+the default value of 744 will be used instead.
+*/
+func AppendLinesToFile(fileName string, linesToAppend []string, permissions int) error {
+	return defaultFileSystem.AppendLinesToFile(fileName, linesToAppend, permissions)
+}
+
+/*
+EditFile allows you to run an ordered script of edit commands over a file in
+a single streaming pass, returning the number of lines changed.
+*/
+func EditFile(fileName string, commands []EditCommand) (int64, error) {
+	return defaultFileSystem.EditFile(fileName, commands)
+}
+
+/*
+EditFiles allows you to run an ordered script of edit commands over several
+files, such as those returned by 'FindMatchingContent'.
+*/
+func EditFiles(fileNames []string, commands []EditCommand) (map[string]int64, error) {
+	return defaultFileSystem.EditFiles(fileNames, commands)
+}
+
+/*
+FindReplaceInFileWithOptions allows you to find and replace text from
+within a file using the options provided, such as performing a dry run which
+returns a unified diff instead of writing.
+*/
+func FindReplaceInFileWithOptions(fileName string, regexMatcher string, replacementValue string, options FindReplaceOptions) (FindReplaceResult, error) {
+	return defaultFileSystem.FindReplaceInFileWithOptions(fileName, regexMatcher, replacementValue, options)
+}
+
+/*
+IsFileContainsTextWithOptions allows you to check if a file contains a
+matching string or not, using the options provided.
+*/
+func IsFileContainsTextWithOptions(fileName string, regexMatcher string, options ContainsTextOptions) (bool, error) {
+	return defaultFileSystem.IsFileContainsTextWithOptions(fileName, regexMatcher, options)
+}
+
+/*
+SearchContent allows you to search the files of a directory tree for text
+matching a regular expression, in the same way as 'grep'.
+*/
+func SearchContent(directoryPath string, regexMatcher string, options SearchOptions) ([]SearchMatch, error) {
+	return defaultFileSystem.SearchContent(directoryPath, regexMatcher, options)
+}
+
+/*
+DownloadFileWithOptions allows you to download a file from the internet to
+your local file system using the options provided.
+*/
+func DownloadFileWithOptions(ctx context.Context, url string, fileName string, options DownloadOptions) error {
+	return defaultFileSystem.DownloadFileWithOptions(ctx, url, fileName, options)
+}
+
+/*
+GetDownloadManager allows you to obtain a download manager which runs many
+downloads with a concurrency limit, keeping its queue of jobs in the file
+provided.
+*/
+func GetDownloadManager(fileName string, options DownloadManagerOptions) (*downloadManagerType, error) {
+	return defaultFileSystem.GetDownloadManager(fileName, options)
+}
+
+/*
+GetDownloadCache allows you to obtain a cache which stores downloaded files
+in the directory provided and only fetches them again when they are no
+longer fresh.
+*/
+func GetDownloadCache(directoryPath string, options DownloadCacheOptions) (*downloadCacheType, error) {
+	return defaultFileSystem.GetDownloadCache(directoryPath, options)
+}
+
+/*
+GetDefaultDownloadCache allows you to obtain a download cache stored in a
+directory named after your application within the cache directory of the
+user.
+*/
+func GetDefaultDownloadCache(applicationName string, options DownloadCacheOptions) (*downloadCacheType, error) {
+	cacheDirectory, err := GetDefaultCacheDirectory()
+	if err != nil {
+		return nil, err
+	}
+	return defaultFileSystem.GetDownloadCache(filepath.Join(cacheDirectory, applicationName, "downloads"), options)
+}
+
+/*
+DownloadFileToDirectory allows you to download a file from the internet
+into the directory provided, naming it after the file name suggested by the
+server or the URL. The path of the downloaded file is returned.
+*/
+func DownloadFileToDirectory(ctx context.Context, url string, directoryPath string, options DownloadOptions) (string, error) {
+	return defaultFileSystem.DownloadFileToDirectory(ctx, url, directoryPath, options)
+}
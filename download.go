@@ -0,0 +1,600 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+defaultUserAgent is the 'User-Agent' sent with a download when the caller
+does not supply any headers, so that the request looks like it is from a
+browser.
+*/
+const defaultUserAgent = "Mozilla/5.0 (X11; Fedora; Linux x86_64; rv:52.0) Gecko/20100101 Firefox/52.0"
+
+/*
+maximumChecksumFileSize is the most data read from a checksum file.
+*/
+const maximumChecksumFileSize = 1024 * 1024
+
+/*
+DownloadOptions allows you to control how a file is downloaded. In
+addition, the following information should be noted:
+
+- If no header is provided, a browser 'User-Agent' is sent instead.
+
+- If no client is provided, a new 'http.Client' is used.
+
+- The checksum, if provided, is verified as the data is received. It may be
+given as hexadecimal prefixed with the algorithm, such as "sha256:<hex>",
+as plain hexadecimal, or in Subresource Integrity format, such as
+"sha512-<base64>". SHA-256, SHA-512 and MD5 are supported.
+
+- Alternatively, a checksum URL may point to a checksum file, such as the
+output of 'sha256sum', which is downloaded first. The line for the file
+named in the download URL is used when it lists several files.
+
+- When the checksum does not match, the partial file is removed and a
+'ChecksumError' is returned.
+
+- If you pass in a timeout of '0', a default of 30 seconds is used. An
+attempt which receives no data for longer than the timeout, whether waiting
+for a response or part way through the body, fails with
+'ErrDownloadTimeout'.
+
+- A retry count of '0' means a failed download is not retried. Only
+transient failures, such as dropped connections, timeouts, or the status
+codes 408, 429, 500, 502, 503 and 504, are retried. Each retry resumes from
+the data already received.
+
+- The delay between attempts starts at the retry delay, 1 second by
+default, and doubles after every attempt up to the maximum retry delay, 30
+seconds by default. Random jitter is applied, unless the server asked for a
+delay with 'Retry-After', which is honoured up to the maximum.
+
+- The progress callback, if provided, is called with a 'DownloadProgress'
+every progress interval, 1 second by default, and once more when the
+download completes. It is never called concurrently.
+
+- If you pass in a bytes per second value of '0', the download is not rate
+limited. Otherwise data is received no faster than the rate provided.
+When downloading in segments, the rate applies to all of them combined.
+
+- A segment count greater than '1' splits the download into that many
+ranges which are fetched concurrently, each retried on its own. This only
+happens when the server supports ranges, otherwise the file is downloaded
+as a single stream.
+*/
+type DownloadOptions struct {
+	Header            http.Header
+	Client            *http.Client
+	Checksum          string
+	ChecksumURL       string
+	Timeout           time.Duration
+	RetryCount        int
+	RetryDelay        time.Duration
+	MaximumRetryDelay time.Duration
+	ProgressCallback  func(DownloadProgress)
+	ProgressInterval  time.Duration
+	BytesPerSecond    int64
+	SegmentCount      int
+}
+
+/*
+downloadStateType records what is known about a partially downloaded file,
+so that a later download can confirm the remote file has not changed before
+resuming it.
+*/
+type downloadStateType struct {
+	url          string
+	entityTag    string
+	lastModified string
+	size         int64
+	segments     []downloadSegmentType
+}
+
+type downloaderType struct {
+	fileSystem    *fileSystemType
+	url           string
+	fileName      string
+	partFileName  string
+	stateFileName string
+	options       DownloadOptions
+	state         downloadStateType
+	checksum      checksumType
+	hasher        hash.Hash
+	progress      *downloadProgressType
+	throttle      *throttleType
+}
+
+/*
+DownloadFileWithOptions allows you to download a file from the internet to
+your local file system using the options provided. In addition, the
+following information should be noted:
+
+- Data is written to a '.part' file next to the target, which is only
+renamed into place once the download completes. An interrupted download
+never leaves a partial file at the target path.
+
+- If a '.part' file from an earlier attempt exists, the download resumes
+from where it stopped using a 'Range' request. The server's 'ETag' or
+'Last-Modified' value is sent with 'If-Range', so a file which has changed
+in the meantime is downloaded again from the start.
+
+- A response whose status code does not indicate success is returned as a
+'StatusError' and nothing is written.
+
+- Cancelling the context stops the download, leaving the '.part' file in
+place so that it can be resumed later.
+*/
+func (shared *fileSystemType) DownloadFileWithOptions(ctx context.Context, url string, fileName string, options DownloadOptions) error {
+	downloader := shared.getDownloader(url, fileName, options)
+	if err := downloader.run(ctx); err != nil {
+		if _, isOpError := err.(*OpError); isOpError {
+			return err
+		}
+		return &OpError{Op: "download", Path: fileName, Err: err}
+	}
+	return nil
+}
+
+/*
+getDownloader allows you to create a downloader which fetches the URL
+provided into the file name provided.
+*/
+func (shared *fileSystemType) getDownloader(url string, fileName string, options DownloadOptions) *downloaderType {
+	var downloader downloaderType
+	downloader.fileSystem = shared
+	downloader.url = url
+	downloader.setFileName(fileName)
+	downloader.options = options
+	downloader.progress = getDownloadProgress(options.ProgressCallback, options.ProgressInterval)
+	if options.BytesPerSecond > 0 {
+		downloader.throttle = getThrottle(options.BytesPerSecond)
+	}
+	return &downloader
+}
+
+/*
+setFileName allows you to change the file name a download is saved to,
+along with the names of the files kept while it is in progress.
+*/
+func (shared *downloaderType) setFileName(fileName string) {
+	shared.fileName = fileName
+	shared.partFileName = fileName + ".part"
+	shared.stateFileName = fileName + ".part.state"
+}
+
+/*
+run allows you to run a download from start to finish, retrying it as
+configured.
+*/
+func (shared *downloaderType) run(ctx context.Context) error {
+	err := shared.retry(ctx, func(ctx context.Context) error {
+		return shared.watch(ctx, shared.prepareChecksum)
+	})
+	if err != nil {
+		return err
+	}
+	if shared.options.SegmentCount > 1 {
+		return shared.downloadSegments(ctx)
+	}
+	return shared.retry(ctx, func(ctx context.Context) error {
+		return shared.watch(ctx, shared.download)
+	})
+}
+
+/*
+watch allows you to run a single attempt at an operation under a watchdog,
+so that it fails with 'ErrDownloadTimeout' if it stops receiving data.
+*/
+func (shared *downloaderType) watch(ctx context.Context, operation func(ctx context.Context, watchdog *downloadWatchdogType) error) error {
+	timeout := shared.options.Timeout
+	if timeout <= 0 {
+		timeout = defaultDownloadTimeout
+	}
+	attemptContext, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watchdog := getDownloadWatchdog(timeout, cancel)
+	defer watchdog.stop()
+	err := operation(attemptContext, watchdog)
+	if err != nil && watchdog.isTimedOut() {
+		return ErrDownloadTimeout
+	}
+	return err
+}
+
+/*
+download allows you to request a download and receive it into the '.part'
+file, resuming from any data already received. A resumed request which the
+server refuses to continue is repeated from the beginning of the file. The
+data already received is hashed before the request is sent, with the
+watchdog stopped, since hashing a large '.part' file may take longer than
+the timeout.
+*/
+func (shared *downloaderType) download(ctx context.Context, watchdog *downloadWatchdogType) error {
+	offset, err := shared.getResumeOffset()
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		watchdog.stop()
+		err := shared.hashPartFile()
+		watchdog.reset()
+		if err != nil {
+			return err
+		}
+	}
+	for {
+		response, err := shared.request(ctx, offset)
+		if err != nil {
+			return err
+		}
+		if offset > 0 && response.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			response.Body.Close()
+			_, totalSize, err := parseContentRange(response.Header.Get("Content-Range"))
+			if err == nil && totalSize == offset {
+				shared.progress.start(offset, totalSize)
+				return shared.finish()
+			}
+			offset = 0
+			continue
+		}
+		if offset > 0 && response.StatusCode == http.StatusPartialContent {
+			startOffset, _, err := parseContentRange(response.Header.Get("Content-Range"))
+			if err != nil || startOffset != offset {
+				response.Body.Close()
+				offset = 0
+				continue
+			}
+		}
+		err = shared.receive(ctx, watchdog, response, offset)
+		response.Body.Close()
+		if err != nil {
+			return err
+		}
+		return shared.finish()
+	}
+}
+
+/*
+getResumeOffset allows you to find the offset a download can safely resume
+from. Zero is returned unless a '.part' file exists along with a record of
+the validator the server sent for it. A '.part' file left by a segmented
+download is cut back to the data received without any gaps.
+*/
+func (shared *downloaderType) getResumeOffset() (int64, error) {
+	fileInfo, err := shared.fileSystem.backend.Stat(shared.partFileName)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !fileInfo.Mode().IsRegular() {
+		return 0, ErrNotRegularFile
+	}
+	state, err := shared.loadState()
+	if err != nil {
+		return 0, err
+	}
+	if state.url != shared.url || getIfRangeValue(state) == "" {
+		return 0, nil
+	}
+	shared.state = state
+	if len(state.segments) == 0 {
+		return fileInfo.Size(), nil
+	}
+	offset := getContiguousOffset(state.segments)
+	if err := shared.truncatePartFile(offset); err != nil {
+		return 0, err
+	}
+	shared.state.segments = nil
+	shared.state.size = 0
+	return offset, shared.saveState()
+}
+
+/*
+request allows you to send the request for a download, asking for the data
+following the offset provided when it is not zero.
+*/
+func (shared *downloaderType) request(ctx context.Context, offset int64) (*http.Response, error) {
+	request, err := shared.getRequest(ctx, shared.url)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		request.Header.Set("If-Range", getIfRangeValue(shared.state))
+	}
+	return shared.getClient().Do(request)
+}
+
+/*
+getRequest allows you to create a request for the URL provided carrying
+the headers configured for the download.
+*/
+func (shared *downloaderType) getRequest(ctx context.Context, url string) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if shared.options.Header == nil {
+		request.Header.Set("User-Agent", defaultUserAgent)
+	} else {
+		request.Header = shared.options.Header.Clone()
+	}
+	return request, nil
+}
+
+/*
+getClient allows you to obtain the HTTP client configured for the download.
+*/
+func (shared *downloaderType) getClient() *http.Client {
+	if shared.options.Client == nil {
+		return &http.Client{}
+	}
+	return shared.options.Client
+}
+
+/*
+prepareChecksum allows you to set up the hash used to verify a download,
+fetching the checksum file first if one was configured.
+*/
+func (shared *downloaderType) prepareChecksum(ctx context.Context, watchdog *downloadWatchdogType) error {
+	var err error
+	switch {
+	case shared.options.Checksum != "" && shared.options.ChecksumURL != "":
+		return ErrInvalidOptions
+	case shared.options.Checksum != "":
+		shared.checksum, err = parseChecksum(shared.options.Checksum)
+	case shared.options.ChecksumURL != "":
+		shared.checksum, err = shared.fetchChecksum(ctx, watchdog, shared.options.ChecksumURL)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	shared.hasher = getChecksumHash(shared.checksum.algorithm)
+	return nil
+}
+
+/*
+fetchChecksum allows you to download a checksum file and find the checksum
+it lists for the file being downloaded.
+*/
+func (shared *downloaderType) fetchChecksum(ctx context.Context, watchdog *downloadWatchdogType, checksumURL string) (checksumType, error) {
+	request, err := shared.getRequest(ctx, checksumURL)
+	if err != nil {
+		return checksumType{}, err
+	}
+	response, err := shared.getClient().Do(request)
+	if err != nil {
+		return checksumType{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return checksumType{}, getStatusError(checksumURL, response)
+	}
+	contents, err := ioutil.ReadAll(io.LimitReader(watchdog.getReader(response.Body), maximumChecksumFileSize))
+	if err != nil {
+		return checksumType{}, err
+	}
+	downloadURL, err := url.Parse(shared.url)
+	if err != nil {
+		return checksumType{}, err
+	}
+	return parseChecksumFile(contents, path.Base(downloadURL.Path))
+}
+
+/*
+hashPartFile allows you to hash the data already held in the '.part' file,
+so that a resumed download can be verified as a whole.
+*/
+func (shared *downloaderType) hashPartFile() error {
+	if shared.hasher == nil {
+		return nil
+	}
+	shared.hasher.Reset()
+	file, err := shared.fileSystem.backend.OpenFile(shared.partFileName, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(shared.hasher, file)
+	return err
+}
+
+/*
+receive allows you to write the body of a response into the '.part' file.
+A partial response is appended to the data already received, while any
+other successful response replaces it.
+*/
+func (shared *downloaderType) receive(ctx context.Context, watchdog *downloadWatchdogType, response *http.Response, offset int64) error {
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return getStatusError(shared.url, response)
+	}
+	backend := shared.fileSystem.backend
+	flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if response.StatusCode == http.StatusPartialContent && offset > 0 {
+		_, totalSize, _ := parseContentRange(response.Header.Get("Content-Range"))
+		shared.progress.start(offset, totalSize)
+	} else {
+		shared.progress.start(0, response.ContentLength)
+		if shared.hasher != nil {
+			shared.hasher.Reset()
+		}
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		shared.state = downloadStateType{
+			url:          shared.url,
+			entityTag:    response.Header.Get("ETag"),
+			lastModified: response.Header.Get("Last-Modified"),
+		}
+		if err := shared.saveState(); err != nil {
+			return err
+		}
+	}
+	file, err := backend.OpenFile(shared.partFileName, flag, 0666)
+	if err != nil {
+		return err
+	}
+	var writer io.Writer = file
+	if shared.hasher != nil {
+		writer = io.MultiWriter(file, shared.hasher)
+	}
+	var reader io.Reader = watchdog.getReader(response.Body)
+	if shared.throttle != nil {
+		reader = shared.throttle.getReader(ctx, reader)
+	}
+	receivedSize, err := io.Copy(writer, shared.progress.getReader(reader))
+	if err == nil && response.ContentLength >= 0 && receivedSize != response.ContentLength {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+/*
+finish allows you to move a completed '.part' file into place and discard
+the state recorded for it. If the data does not match the expected
+checksum, the '.part' file is removed instead.
+*/
+func (shared *downloaderType) finish() error {
+	backend := shared.fileSystem.backend
+	if shared.hasher != nil {
+		if actualDigest := shared.hasher.Sum(nil); !bytes.Equal(actualDigest, shared.checksum.digest) {
+			backend.Remove(shared.partFileName)
+			backend.Remove(shared.stateFileName)
+			return &ChecksumError{
+				Algorithm:      shared.checksum.algorithm,
+				ExpectedDigest: hex.EncodeToString(shared.checksum.digest),
+				ActualDigest:   hex.EncodeToString(actualDigest),
+			}
+		}
+	}
+	if err := backend.Rename(shared.partFileName, shared.fileName); err != nil {
+		return err
+	}
+	backend.Remove(shared.stateFileName)
+	syncDirectory(backend, filepath.Dir(shared.fileName))
+	shared.progress.finish()
+	return nil
+}
+
+/*
+loadState allows you to read the state recorded for a '.part' file. An
+empty state is returned if none was recorded.
+*/
+func (shared *downloaderType) loadState() (downloadStateType, error) {
+	var state downloadStateType
+	stateContents, err := shared.fileSystem.readFile(shared.stateFileName)
+	if err != nil && !os.IsNotExist(err) {
+		return state, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(stateContents))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "url":
+			state.url = fields[1]
+		case "etag":
+			state.entityTag = fields[1]
+		case "last-modified":
+			state.lastModified = fields[1]
+		case "size":
+			if _, err := fmt.Sscan(fields[1], &state.size); err != nil {
+				return state, fmt.Errorf("corrupt download state '%s': %w", scanner.Text(), err)
+			}
+		case "segment":
+			var segment downloadSegmentType
+			if _, err := fmt.Sscan(fields[1], &segment.startOffset, &segment.endOffset, &segment.nextOffset); err != nil {
+				return state, fmt.Errorf("corrupt download state '%s': %w", scanner.Text(), err)
+			}
+			state.segments = append(state.segments, segment)
+		}
+	}
+	return state, nil
+}
+
+/*
+saveState allows you to record the state of a '.part' file so that it can
+be resumed later.
+*/
+func (shared *downloaderType) saveState() error {
+	var stateContents strings.Builder
+	fmt.Fprintf(&stateContents, "url %s\n", shared.state.url)
+	if shared.state.entityTag != "" {
+		fmt.Fprintf(&stateContents, "etag %s\n", shared.state.entityTag)
+	}
+	if shared.state.lastModified != "" {
+		fmt.Fprintf(&stateContents, "last-modified %s\n", shared.state.lastModified)
+	}
+	if len(shared.state.segments) > 0 {
+		fmt.Fprintf(&stateContents, "size %d\n", shared.state.size)
+	}
+	for _, segment := range shared.state.segments {
+		fmt.Fprintf(&stateContents, "segment %d %d %d\n", segment.startOffset, segment.endOffset, segment.nextOffset)
+	}
+	return shared.fileSystem.writeFile(shared.stateFileName, []byte(stateContents.String()), 0644)
+}
+
+/*
+getIfRangeValue allows you to obtain the validator to send with 'If-Range'
+for a resumed download. Weak entity tags cannot be used for ranges, so the
+modification time is used for them instead. An empty string means the
+download cannot be resumed safely.
+*/
+func getIfRangeValue(state downloadStateType) string {
+	if state.entityTag != "" && !strings.HasPrefix(state.entityTag, "W/") {
+		return state.entityTag
+	}
+	return state.lastModified
+}
+
+/*
+parseContentRange allows you to obtain the first byte offset and total size
+from a 'Content-Range' header. A start offset of '-1' is returned for an
+unsatisfied range, and a total size of '-1' when the size is unknown.
+*/
+func parseContentRange(contentRange string) (int64, int64, error) {
+	var startOffset, endOffset, totalSize int64
+	rangeSpecification := strings.TrimPrefix(strings.TrimSpace(contentRange), "bytes ")
+	slashIndex := strings.LastIndex(rangeSpecification, "/")
+	if slashIndex < 0 {
+		return 0, 0, fmt.Errorf("invalid content range '%s'", contentRange)
+	}
+	totalSize = -1
+	if sizeText := rangeSpecification[slashIndex+1:]; sizeText != "*" {
+		if _, err := fmt.Sscanf(sizeText, "%d", &totalSize); err != nil {
+			return 0, 0, fmt.Errorf("invalid content range '%s'", contentRange)
+		}
+	}
+	if rangeText := rangeSpecification[:slashIndex]; rangeText == "*" {
+		startOffset = -1
+	} else if _, err := fmt.Sscanf(rangeText, "%d-%d", &startOffset, &endOffset); err != nil {
+		return 0, 0, fmt.Errorf("invalid content range '%s'", contentRange)
+	}
+	return startOffset, totalSize, nil
+}
@@ -0,0 +1,429 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+defaultDownloadCacheSize is the most data a download cache keeps when no
+maximum size is configured.
+*/
+const defaultDownloadCacheSize = 100 * 1024 * 1024
+
+/*
+DownloadCacheOptions allows you to control a download cache. In addition,
+the following information should be noted:
+
+- If you pass in a maximum size of '0', a default of 100 MiB is used. Once
+the cache grows beyond its maximum size, the entries used least recently
+are removed.
+
+- The download options are used for every request the cache makes, except
+that checksums and segmented downloads are not supported.
+*/
+type DownloadCacheOptions struct {
+	MaximumSize     int64
+	DownloadOptions DownloadOptions
+}
+
+type downloadCacheType struct {
+	fileSystem    *fileSystemType
+	directoryPath string
+	options       DownloadCacheOptions
+	mutex         sync.Mutex
+}
+
+/*
+downloadCacheEntryType records what is known about a cached response, so
+that it can be checked for freshness and revalidated with the server.
+*/
+type downloadCacheEntryType struct {
+	url            string
+	entityTag      string
+	lastModified   string
+	expirationTime time.Time
+}
+
+/*
+GetDownloadCache allows you to obtain a cache which stores downloaded files
+in the directory provided and only fetches them again when they are no
+longer fresh. In addition, the following information should be noted:
+
+- Responses are kept fresh for as long as their 'Cache-Control' max age, or
+their 'Expires' header, allows.
+
+- Once a response is no longer fresh, it is revalidated using its 'ETag'
+and 'Last-Modified' values. A '304 Not Modified' response keeps the cached
+copy, while any other successful response replaces it.
+
+- Responses marked 'no-cache', or without any freshness information, are
+revalidated every time they are used. Responses marked 'no-store' are
+never written to the cache directory, and are fetched again every time.
+
+- Fetches through one cache are made one at a time.
+*/
+func (shared *fileSystemType) GetDownloadCache(directoryPath string, options DownloadCacheOptions) (*downloadCacheType, error) {
+	if options.MaximumSize <= 0 {
+		options.MaximumSize = defaultDownloadCacheSize
+	}
+	options.DownloadOptions.Checksum = ""
+	options.DownloadOptions.ChecksumURL = ""
+	options.DownloadOptions.SegmentCount = 0
+	if err := shared.backend.MkdirAll(directoryPath, 0755); err != nil {
+		return nil, err
+	}
+	var cache downloadCacheType
+	cache.fileSystem = shared
+	cache.directoryPath = directoryPath
+	cache.options = options
+	return &cache, nil
+}
+
+/*
+Fetch allows you to obtain the path of a cached copy of the URL provided,
+downloading or revalidating it first if the cached copy is missing or no
+longer fresh. In addition, the following information should be noted:
+
+- A cached file should not be modified, and may be removed by a later
+fetch once it is the least recently used entry.
+
+- If the response is marked 'no-store', it is saved to a temporary file
+outside the cache directory instead. That file belongs to the caller, who
+should remove it once finished with it. Use 'FetchContents' to avoid this.
+*/
+func (shared *downloadCacheType) Fetch(ctx context.Context, url string) (string, error) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	fileName, _, err := shared.fetch(ctx, url)
+	if err != nil {
+		return "", &OpError{Op: "fetch", Path: url, Err: err}
+	}
+	return fileName, nil
+}
+
+/*
+FetchContents allows you to obtain the contents of a cached copy of the URL
+provided, downloading or revalidating it first if required.
+*/
+func (shared *downloadCacheType) FetchContents(ctx context.Context, url string) ([]byte, error) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	fileName, isStored, err := shared.fetch(ctx, url)
+	if err != nil {
+		return nil, &OpError{Op: "fetch", Path: url, Err: err}
+	}
+	if !isStored {
+		defer shared.fileSystem.backend.Remove(fileName)
+	}
+	return shared.fileSystem.readFile(fileName)
+}
+
+/*
+Clear allows you to remove every entry from the cache.
+*/
+func (shared *downloadCacheType) Clear() error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	backend := shared.fileSystem.backend
+	if err := backend.RemoveAll(shared.directoryPath); err != nil {
+		return err
+	}
+	return backend.MkdirAll(shared.directoryPath, 0755)
+}
+
+/*
+fetch allows you to bring the cache entry for a URL up to date, returning
+the path of its file. False is returned if the response was marked
+'no-store', in which case the file is a temporary file outside the cache
+which the caller must remove. The mutex must be held.
+*/
+func (shared *downloadCacheType) fetch(ctx context.Context, url string) (string, bool, error) {
+	key := getDownloadCacheKey(url)
+	fileName := filepath.Join(shared.directoryPath, key)
+	entry, isCached := shared.loadEntry(key, url)
+	currentTime := time.Now()
+	if isCached && currentTime.Before(entry.expirationTime) {
+		shared.fileSystem.backend.Chtimes(fileName, currentTime, currentTime)
+		return fileName, true, nil
+	}
+	downloader := shared.fileSystem.getDownloader(url, fileName, shared.options.DownloadOptions)
+	var isStored bool
+	err := downloader.retry(ctx, func(ctx context.Context) error {
+		return downloader.watch(ctx, func(ctx context.Context, watchdog *downloadWatchdogType) error {
+			var err error
+			entry, isStored, err = shared.revalidate(ctx, watchdog, downloader, entry, isCached)
+			return err
+		})
+	})
+	if err != nil {
+		return "", false, err
+	}
+	if !isStored {
+		shared.removeEntry(key)
+		return downloader.fileName, false, nil
+	}
+	if err := shared.saveEntry(key, entry); err != nil {
+		return "", false, err
+	}
+	currentTime = time.Now()
+	shared.fileSystem.backend.Chtimes(fileName, currentTime, currentTime)
+	return fileName, true, shared.evict(key)
+}
+
+/*
+revalidate allows you to make a single attempt at a conditional request
+for a cache entry. The updated entry is returned, and the cached file is
+replaced unless the server reports that it has not been modified. A
+response marked 'no-store' is saved to a temporary file outside the cache
+instead, in which case false is returned and the downloader is left
+pointing at the temporary file.
+*/
+func (shared *downloadCacheType) revalidate(ctx context.Context, watchdog *downloadWatchdogType, downloader *downloaderType, entry downloadCacheEntryType, isCached bool) (downloadCacheEntryType, bool, error) {
+	downloader.setFileName(filepath.Join(shared.directoryPath, getDownloadCacheKey(downloader.url)))
+	request, err := downloader.getRequest(ctx, downloader.url)
+	if err != nil {
+		return entry, false, err
+	}
+	if isCached && entry.entityTag != "" {
+		request.Header.Set("If-None-Match", entry.entityTag)
+	}
+	if isCached && entry.lastModified != "" {
+		request.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+	response, err := downloader.getClient().Do(request)
+	if err != nil {
+		return entry, false, err
+	}
+	defer response.Body.Close()
+	responseTime := time.Now()
+	if response.StatusCode == http.StatusNotModified && isCached {
+		if entityTag := response.Header.Get("ETag"); entityTag != "" {
+			entry.entityTag = entityTag
+		}
+		if lastModified := response.Header.Get("Last-Modified"); lastModified != "" {
+			entry.lastModified = lastModified
+		}
+		entry.expirationTime = getCacheExpirationTime(response.Header, responseTime)
+		return entry, true, nil
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return entry, false, getStatusError(downloader.url, response)
+	}
+	_, isNoStore := parseCacheControl(response.Header.Get("Cache-Control"))["no-store"]
+	if isNoStore {
+		backend := shared.fileSystem.backend
+		if err := backend.MkdirAll(os.TempDir(), 0755); err != nil {
+			return entry, false, err
+		}
+		file, temporaryFileName, err := createTemporaryFile(backend, filepath.Join(os.TempDir(), getDownloadCacheKey(downloader.url)), 0600)
+		if err != nil {
+			return entry, false, err
+		}
+		file.Close()
+		downloader.setFileName(temporaryFileName)
+	}
+	err = downloader.receive(ctx, watchdog, response, 0)
+	if err == nil {
+		err = downloader.finish()
+	}
+	if err != nil {
+		if isNoStore {
+			shared.fileSystem.backend.Remove(downloader.fileName)
+			shared.fileSystem.backend.Remove(downloader.partFileName)
+			shared.fileSystem.backend.Remove(downloader.stateFileName)
+		}
+		return entry, false, err
+	}
+	entry = downloadCacheEntryType{
+		url:            downloader.url,
+		entityTag:      response.Header.Get("ETag"),
+		lastModified:   response.Header.Get("Last-Modified"),
+		expirationTime: getCacheExpirationTime(response.Header, responseTime),
+	}
+	return entry, !isNoStore, nil
+}
+
+/*
+loadEntry allows you to read the record of a cache entry. False is returned
+if the entry, or its file, does not exist or belongs to a different URL.
+*/
+func (shared *downloadCacheType) loadEntry(key string, url string) (downloadCacheEntryType, bool) {
+	var entry downloadCacheEntryType
+	fileName := filepath.Join(shared.directoryPath, key)
+	if _, err := shared.fileSystem.backend.Stat(fileName); err != nil {
+		return entry, false
+	}
+	entryContents, err := shared.fileSystem.readFile(fileName + ".meta")
+	if err != nil {
+		return entry, false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(entryContents))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "url":
+			entry.url = fields[1]
+		case "etag":
+			entry.entityTag = fields[1]
+		case "last-modified":
+			entry.lastModified = fields[1]
+		case "expires":
+			expirationTime, err := time.Parse(time.RFC3339Nano, fields[1])
+			if err != nil {
+				return entry, false
+			}
+			entry.expirationTime = expirationTime
+		}
+	}
+	return entry, entry.url == url
+}
+
+/*
+saveEntry allows you to record a cache entry next to its file.
+*/
+func (shared *downloadCacheType) saveEntry(key string, entry downloadCacheEntryType) error {
+	var entryContents strings.Builder
+	fmt.Fprintf(&entryContents, "url %s\n", entry.url)
+	if entry.entityTag != "" {
+		fmt.Fprintf(&entryContents, "etag %s\n", entry.entityTag)
+	}
+	if entry.lastModified != "" {
+		fmt.Fprintf(&entryContents, "last-modified %s\n", entry.lastModified)
+	}
+	if !entry.expirationTime.IsZero() {
+		fmt.Fprintf(&entryContents, "expires %s\n", entry.expirationTime.Format(time.RFC3339Nano))
+	}
+	fileName := filepath.Join(shared.directoryPath, key+".meta")
+	return shared.fileSystem.writeFile(fileName, []byte(entryContents.String()), 0644)
+}
+
+/*
+removeEntry allows you to remove an entry, and the record kept for it,
+from the cache.
+*/
+func (shared *downloadCacheType) removeEntry(key string) {
+	fileName := filepath.Join(shared.directoryPath, key)
+	shared.fileSystem.backend.Remove(fileName)
+	shared.fileSystem.backend.Remove(fileName + ".meta")
+}
+
+/*
+evict allows you to remove the least recently used entries until the cache
+is no larger than its maximum size. The entry for the key provided is
+always kept, since it has just been fetched.
+*/
+func (shared *downloadCacheType) evict(keptKey string) error {
+	backend := shared.fileSystem.backend
+	fileInfos, err := backend.ReadDir(shared.directoryPath)
+	if err != nil {
+		return err
+	}
+	var entryInfos []os.FileInfo
+	var totalSize int64
+	for _, fileInfo := range fileInfos {
+		if !fileInfo.Mode().IsRegular() || len(fileInfo.Name()) != sha256.Size*2 {
+			continue
+		}
+		entryInfos = append(entryInfos, fileInfo)
+		totalSize += fileInfo.Size()
+	}
+	sort.Slice(entryInfos, func(first int, second int) bool {
+		return entryInfos[first].ModTime().Before(entryInfos[second].ModTime())
+	})
+	for _, fileInfo := range entryInfos {
+		if totalSize <= shared.options.MaximumSize {
+			break
+		}
+		if fileInfo.Name() == keptKey {
+			continue
+		}
+		fileName := filepath.Join(shared.directoryPath, fileInfo.Name())
+		if err := backend.Remove(fileName); err != nil {
+			return err
+		}
+		backend.Remove(fileName + ".meta")
+		totalSize -= fileInfo.Size()
+	}
+	return nil
+}
+
+/*
+getDownloadCacheKey allows you to obtain the name a URL is stored under in
+a download cache.
+*/
+func getDownloadCacheKey(url string) string {
+	digest := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(digest[:])
+}
+
+/*
+getCacheExpirationTime allows you to work out until when a response may be
+used without revalidating it. A zero time is returned when it must always
+be revalidated.
+*/
+func getCacheExpirationTime(header http.Header, responseTime time.Time) time.Time {
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	_, isNoStore := directives["no-store"]
+	_, isNoCache := directives["no-cache"]
+	if isNoStore || isNoCache {
+		return time.Time{}
+	}
+	if maximumAge, isMaximumAgeSet := directives["max-age"]; isMaximumAgeSet {
+		maximumAgeSeconds, err := strconv.ParseInt(maximumAge, 10, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		ageSeconds, _ := strconv.ParseInt(strings.TrimSpace(header.Get("Age")), 10, 64)
+		return responseTime.Add(time.Duration(maximumAgeSeconds-ageSeconds) * time.Second)
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		expirationTime, err := http.ParseTime(expires)
+		if err != nil {
+			return time.Time{}
+		}
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			return responseTime.Add(expirationTime.Sub(date))
+		}
+		return expirationTime
+	}
+	return time.Time{}
+}
+
+/*
+parseCacheControl allows you to split a 'Cache-Control' header into its
+directives. Directive names are lower cased, and directives without a value
+map to an empty string.
+*/
+func parseCacheControl(cacheControl string) map[string]string {
+	directives := make(map[string]string)
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "" {
+			continue
+		}
+		fields := strings.SplitN(directive, "=", 2)
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		value := ""
+		if len(fields) == 2 {
+			value = strings.Trim(strings.TrimSpace(fields[1]), `"`)
+		}
+		directives[name] = value
+	}
+	return directives
+}
@@ -0,0 +1,126 @@
+package filesystem
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+/*
+getCacheServer allows you to create a test server which serves the path
+requested with the 'Cache-Control' header provided, answering conditional
+requests with '304 Not Modified' and counting each kind of response.
+*/
+func getCacheServer(cacheControl string, fullCount *int32, notModifiedCount *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		entityTag := `"` + strings.Trim(request.URL.Path, "/") + `"`
+		writer.Header().Set("Cache-Control", cacheControl)
+		writer.Header().Set("ETag", entityTag)
+		if request.Header.Get("If-None-Match") == entityTag {
+			atomic.AddInt32(notModifiedCount, 1)
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(fullCount, 1)
+		writer.Write([]byte("Contents of " + request.URL.Path + strings.Repeat(".", 100)))
+	}))
+}
+
+func TestDownloadCache(test *testing.T) {
+	var fullCount, notModifiedCount int32
+	server := getCacheServer("max-age=60", &fullCount, &notModifiedCount)
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	cache, err := fileSystem.GetDownloadCache("/cache", DownloadCacheOptions{})
+	assert.NoErrorf(test, err, "An error was not expected when creating a download cache.")
+	contents, err := cache.FetchContents(context.Background(), server.URL+"/manifest")
+	assert.NoErrorf(test, err, "An error was not expected when fetching an uncached URL.")
+	assert.Truef(test, strings.HasPrefix(string(contents), "Contents of /manifest"), "The contents fetched were not as expected.")
+	fileName, err := cache.Fetch(context.Background(), server.URL+"/manifest")
+	assert.NoErrorf(test, err, "An error was not expected when fetching a cached URL.")
+	cachedContents, _ := fileSystem.GetFileContents(fileName)
+	assert.Equalf(test, string(contents), string(cachedContents), "The cached file was expected to hold the contents fetched.")
+	assert.Equalf(test, int32(1), atomic.LoadInt32(&fullCount), "A fresh cache entry was not expected to be fetched again.")
+	assert.Equalf(test, int32(0), atomic.LoadInt32(&notModifiedCount), "A fresh cache entry was not expected to be revalidated.")
+	err = cache.Clear()
+	assert.NoErrorf(test, err, "An error was not expected when clearing the cache.")
+	cache.Fetch(context.Background(), server.URL+"/manifest")
+	assert.Equalf(test, int32(2), atomic.LoadInt32(&fullCount), "A cleared cache was expected to fetch the URL again.")
+}
+
+func TestDownloadCacheRevalidation(test *testing.T) {
+	var fullCount, notModifiedCount int32
+	server := getCacheServer("no-cache", &fullCount, &notModifiedCount)
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	cache, _ := fileSystem.GetDownloadCache("/cache", DownloadCacheOptions{})
+	for index := 0; index < 3; index++ {
+		contents, err := cache.FetchContents(context.Background(), server.URL+"/manifest")
+		assert.NoErrorf(test, err, "An error was not expected when fetching a URL which must be revalidated.")
+		assert.Truef(test, strings.HasPrefix(string(contents), "Contents of /manifest"), "The contents fetched were not as expected.")
+	}
+	assert.Equalf(test, int32(1), atomic.LoadInt32(&fullCount), "A revalidated cache entry was not expected to be fetched again.")
+	assert.Equalf(test, int32(2), atomic.LoadInt32(&notModifiedCount), "A cache entry marked 'no-cache' was expected to be revalidated every time.")
+	var noStoreFullCount, noStoreNotModifiedCount int32
+	noStoreServer := getCacheServer("no-store", &noStoreFullCount, &noStoreNotModifiedCount)
+	defer noStoreServer.Close()
+	cache.Clear()
+	contents, err := cache.FetchContents(context.Background(), noStoreServer.URL+"/manifest")
+	assert.NoErrorf(test, err, "An error was not expected when fetching a response marked 'no-store'.")
+	assert.Truef(test, strings.HasPrefix(string(contents), "Contents of /manifest"), "The contents fetched were not as expected.")
+	fileName, err := cache.Fetch(context.Background(), noStoreServer.URL+"/manifest")
+	assert.NoErrorf(test, err, "An error was not expected when fetching a response marked 'no-store'.")
+	assert.Falsef(test, strings.HasPrefix(fileName, "/cache/"), "A response marked 'no-store' was expected to be saved outside the cache directory.")
+	fetchedContents, _ := fileSystem.GetFileContentsAsBytes(fileName)
+	assert.Equalf(test, string(contents), string(fetchedContents), "The temporary file was expected to hold the contents fetched.")
+	fileSystem.DeleteFile(fileName)
+	assert.Equalf(test, int32(2), atomic.LoadInt32(&noStoreFullCount), "A response marked 'no-store' was expected to be fetched every time.")
+	assert.Equalf(test, int32(0), atomic.LoadInt32(&noStoreNotModifiedCount), "A response marked 'no-store' was not expected to be revalidated.")
+	fileInfos, _ := fileSystem.backend.ReadDir("/cache")
+	assert.Emptyf(test, fileInfos, "Nothing was expected to be left in the cache directory by a response marked 'no-store'.")
+	temporaryFileInfos, _ := fileSystem.backend.ReadDir(os.TempDir())
+	assert.Emptyf(test, temporaryFileInfos, "No temporary files were expected to be left behind.")
+}
+
+func TestDownloadCacheEviction(test *testing.T) {
+	var fullCount, notModifiedCount int32
+	server := getCacheServer("max-age=60", &fullCount, &notModifiedCount)
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	cache, _ := fileSystem.GetDownloadCache("/cache", DownloadCacheOptions{MaximumSize: 250})
+	cache.Fetch(context.Background(), server.URL+"/first")
+	time.Sleep(10 * time.Millisecond)
+	cache.Fetch(context.Background(), server.URL+"/second")
+	time.Sleep(10 * time.Millisecond)
+	cache.Fetch(context.Background(), server.URL+"/first")
+	time.Sleep(10 * time.Millisecond)
+	cache.Fetch(context.Background(), server.URL+"/third")
+	assert.Equalf(test, int32(3), atomic.LoadInt32(&fullCount), "Every URL was expected to be fetched once before eviction.")
+	cache.Fetch(context.Background(), server.URL+"/first")
+	assert.Equalf(test, int32(3), atomic.LoadInt32(&fullCount), "The most recently used entry was not expected to be evicted.")
+	cache.Fetch(context.Background(), server.URL+"/second")
+	assert.Equalf(test, int32(4), atomic.LoadInt32(&fullCount), "The least recently used entry was expected to be evicted.")
+}
+
+func TestGetCacheExpirationTime(test *testing.T) {
+	responseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	header.Set("Cache-Control", `public, max-age="120"`)
+	header.Set("Age", "20")
+	assert.Equalf(test, responseTime.Add(100*time.Second), getCacheExpirationTime(header, responseTime), "The max age was expected to be reduced by the age of the response.")
+	header = http.Header{}
+	header.Set("Date", "Mon, 01 Jan 2024 10:00:00 GMT")
+	header.Set("Expires", "Mon, 01 Jan 2024 10:05:00 GMT")
+	assert.Equalf(test, responseTime.Add(5*time.Minute), getCacheExpirationTime(header, responseTime), "The expiry time was expected to be relative to the date of the response.")
+	header.Set("Expires", "0")
+	assert.Truef(test, getCacheExpirationTime(header, responseTime).IsZero(), "An invalid expiry time was expected to require revalidation.")
+	header.Set("Cache-Control", "max-age=60, no-cache")
+	assert.Truef(test, getCacheExpirationTime(header, responseTime).IsZero(), "A response marked 'no-cache' was expected to require revalidation.")
+	assert.Truef(test, getCacheExpirationTime(http.Header{}, responseTime).IsZero(), "A response without freshness information was expected to require revalidation.")
+}
@@ -0,0 +1,442 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+/*
+defaultConcurrencyLimit is the number of downloads a download manager runs
+at once when no limit is configured.
+*/
+const defaultConcurrencyLimit = 4
+
+/*
+defaultHostConcurrencyLimit is the number of downloads a download manager
+runs at once from a single host when no limit is configured.
+*/
+const defaultHostConcurrencyLimit = 2
+
+/*
+DownloadStatus identifies how far a job of a download manager has got.
+*/
+type DownloadStatus int
+
+const (
+	/*
+		DownloadQueued means the job is waiting for a free slot.
+	*/
+	DownloadQueued DownloadStatus = iota
+	/*
+		DownloadRunning means the job is being downloaded.
+	*/
+	DownloadRunning
+	/*
+		DownloadCompleted means the job was downloaded to every file name
+		requested for it.
+	*/
+	DownloadCompleted
+	/*
+		DownloadFailed means the job could not be downloaded. The error is
+		recorded with the job.
+	*/
+	DownloadFailed
+)
+
+/*
+DownloadManagerOptions allows you to control how a download manager runs
+its jobs. In addition, the following information should be noted:
+
+- If you pass in a concurrency limit of '0', a default of 4 is used.
+
+- If you pass in a host concurrency limit of '0', a default of 2 is used.
+This limits the number of jobs running at once against the same host.
+
+- The progress callback, if provided, is called with a copy of a job each
+time the progress of that job is reported, so that jobs can be told apart.
+Calls are never made concurrently, even for different jobs, and they are
+made without holding any lock on the download manager.
+
+- The download options are used for every job. A progress callback set in
+them is also called for every job, but it is not told which job the
+progress belongs to. It is never called concurrently either.
+*/
+type DownloadManagerOptions struct {
+	ConcurrencyLimit     int
+	HostConcurrencyLimit int
+	ProgressCallback     func(job DownloadJob)
+	DownloadOptions      DownloadOptions
+}
+
+/*
+DownloadJob describes a URL being fetched by a download manager, along with
+every file name it should be saved as.
+*/
+type DownloadJob struct {
+	URL       string
+	FileNames []string
+	Status    DownloadStatus
+	Progress  DownloadProgress
+	Err       error
+}
+
+type downloadManagerType struct {
+	fileSystem        *fileSystemType
+	fileName          string
+	options           DownloadManagerOptions
+	mutex             sync.Mutex
+	progressMutex     sync.Mutex
+	condition         *sync.Cond
+	ctx               context.Context
+	jobs              []*DownloadJob
+	jobsByURL         map[string]*DownloadJob
+	runningCount      int
+	hostRunningCounts map[string]int
+}
+
+/*
+GetDownloadManager allows you to obtain a download manager which keeps its
+queue of jobs in the file provided. In addition, the following information
+should be noted:
+
+- Any jobs which were unfinished when the queue was last saved are loaded
+as queued, so a restarted program continues where it stopped. Downloads
+which were interrupted resume from their '.part' files.
+
+- Adding a URL which already has a job does not download it again. The new
+file name is added to the existing job, and receives a copy of the file
+once it has been downloaded.
+
+- Jobs only run once 'Start' has been called.
+
+- A queue file should only be used by one download manager at a time.
+*/
+func (shared *fileSystemType) GetDownloadManager(fileName string, options DownloadManagerOptions) (*downloadManagerType, error) {
+	if options.ConcurrencyLimit <= 0 {
+		options.ConcurrencyLimit = defaultConcurrencyLimit
+	}
+	if options.HostConcurrencyLimit <= 0 {
+		options.HostConcurrencyLimit = defaultHostConcurrencyLimit
+	}
+	var manager downloadManagerType
+	manager.fileSystem = shared
+	manager.fileName = fileName
+	manager.options = options
+	manager.condition = sync.NewCond(&manager.mutex)
+	manager.jobsByURL = make(map[string]*DownloadJob)
+	manager.hostRunningCounts = make(map[string]int)
+	if err := manager.loadQueue(); err != nil {
+		return nil, &OpError{Op: "load", Path: fileName, Err: err}
+	}
+	return &manager, nil
+}
+
+/*
+Add allows you to add a job which downloads the URL provided to the file
+name provided. If the URL already has a job, the file name is added to it
+instead, and a job which failed is queued again. If the job has already
+completed, the file is copied to the new file name straight away. The URL
+must be valid, and the file name can not contain a newline.
+*/
+func (shared *downloadManagerType) Add(downloadURL string, fileName string) error {
+	if strings.Contains(fileName, "\n") {
+		return &OpError{Op: "add", Path: shared.fileName, Err: ErrInvalidItem}
+	}
+	if _, err := url.Parse(downloadURL); err != nil {
+		return &OpError{Op: "add", Path: downloadURL, Err: err}
+	}
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	job, isExisting := shared.jobsByURL[downloadURL]
+	if !isExisting {
+		job = &DownloadJob{URL: downloadURL}
+		shared.jobs = append(shared.jobs, job)
+		shared.jobsByURL[downloadURL] = job
+	}
+	isNewFileName := true
+	for _, existingFileName := range job.FileNames {
+		if existingFileName == fileName {
+			isNewFileName = false
+		}
+	}
+	if !isNewFileName && job.Status != DownloadFailed {
+		return nil
+	}
+	if job.Status == DownloadCompleted {
+		sourceFileName := job.FileNames[0]
+		shared.mutex.Unlock()
+		err := shared.fileSystem.CopyFile(sourceFileName, fileName)
+		shared.mutex.Lock()
+		if err != nil {
+			return &OpError{Op: "add", Path: fileName, Err: err}
+		}
+		job.FileNames = append(job.FileNames, fileName)
+		return nil
+	}
+	if isNewFileName {
+		job.FileNames = append(job.FileNames, fileName)
+	}
+	if job.Status == DownloadFailed {
+		job.Status = DownloadQueued
+		job.Err = nil
+	}
+	if err := shared.saveQueue(); err != nil {
+		return &OpError{Op: "add", Path: shared.fileName, Err: err}
+	}
+	shared.schedule()
+	return nil
+}
+
+/*
+Start allows you to begin running queued jobs. Cancelling the context
+provided stops every running job, leaving it queued so that it can be
+resumed by a later download manager.
+*/
+func (shared *downloadManagerType) Start(ctx context.Context) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	shared.ctx = ctx
+	shared.schedule()
+	go func() {
+		<-ctx.Done()
+		shared.mutex.Lock()
+		shared.condition.Broadcast()
+		shared.mutex.Unlock()
+	}()
+}
+
+/*
+Wait allows you to block until every job has finished. If the download
+manager is stopped, or was never started, it only waits for the jobs which
+are running.
+*/
+func (shared *downloadManagerType) Wait() {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	for shared.runningCount > 0 || (shared.isRunning() && shared.isQueuedJobPresent()) {
+		shared.condition.Wait()
+	}
+}
+
+/*
+GetJob allows you to obtain the current state of the job for the URL
+provided. False is returned if there is no job for the URL.
+*/
+func (shared *downloadManagerType) GetJob(url string) (DownloadJob, bool) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	job, isExisting := shared.jobsByURL[url]
+	if !isExisting {
+		return DownloadJob{}, false
+	}
+	return copyDownloadJob(job), true
+}
+
+/*
+GetJobs allows you to obtain the current state of every job, in the order
+they were added.
+*/
+func (shared *downloadManagerType) GetJobs() []DownloadJob {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	jobs := make([]DownloadJob, 0, len(shared.jobs))
+	for _, job := range shared.jobs {
+		jobs = append(jobs, copyDownloadJob(job))
+	}
+	return jobs
+}
+
+/*
+isRunning allows you to check if the download manager has been started and
+not stopped. The mutex must be held.
+*/
+func (shared *downloadManagerType) isRunning() bool {
+	return shared.ctx != nil && shared.ctx.Err() == nil
+}
+
+/*
+isQueuedJobPresent allows you to check if any job is waiting to run. The
+mutex must be held.
+*/
+func (shared *downloadManagerType) isQueuedJobPresent() bool {
+	for _, job := range shared.jobs {
+		if job.Status == DownloadQueued {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+schedule allows you to start as many queued jobs as the concurrency limits
+allow, in the order they were added. The mutex must be held.
+*/
+func (shared *downloadManagerType) schedule() {
+	if !shared.isRunning() {
+		return
+	}
+	for _, job := range shared.jobs {
+		if shared.runningCount >= shared.options.ConcurrencyLimit {
+			return
+		}
+		host := getDownloadHost(job.URL)
+		if job.Status != DownloadQueued || shared.hostRunningCounts[host] >= shared.options.HostConcurrencyLimit {
+			continue
+		}
+		job.Status = DownloadRunning
+		shared.runningCount++
+		shared.hostRunningCounts[host]++
+		go shared.runJob(shared.ctx, job, host)
+	}
+}
+
+/*
+runJob allows you to download a job to its first file name, and then copy
+it to every other file name requested for it, including any added while it
+was running.
+*/
+func (shared *downloadManagerType) runJob(ctx context.Context, job *DownloadJob, host string) {
+	shared.mutex.Lock()
+	sourceFileName := job.FileNames[0]
+	shared.mutex.Unlock()
+	options := shared.options.DownloadOptions
+	progressCallback := options.ProgressCallback
+	options.ProgressCallback = func(progress DownloadProgress) {
+		shared.mutex.Lock()
+		job.Progress = progress
+		jobCopy := copyDownloadJob(job)
+		shared.mutex.Unlock()
+		shared.progressMutex.Lock()
+		defer shared.progressMutex.Unlock()
+		if shared.options.ProgressCallback != nil {
+			shared.options.ProgressCallback(jobCopy)
+		}
+		if progressCallback != nil {
+			progressCallback(progress)
+		}
+	}
+	err := shared.fileSystem.DownloadFileWithOptions(ctx, job.URL, sourceFileName, options)
+	// The mutex is held from the last check for file names still to be
+	// copied until the final status is set, so that a file name added by
+	// 'Add' while the job is running is never left without a copy.
+	copiedCount := 1
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	for err == nil && copiedCount < len(job.FileNames) {
+		pendingFileNames := append([]string{}, job.FileNames[copiedCount:]...)
+		shared.mutex.Unlock()
+		for _, fileName := range pendingFileNames {
+			if err = shared.fileSystem.CopyFile(sourceFileName, fileName); err != nil {
+				break
+			}
+		}
+		copiedCount += len(pendingFileNames)
+		shared.mutex.Lock()
+	}
+	shared.runningCount--
+	shared.hostRunningCounts[host]--
+	switch {
+	case err == nil:
+		job.Status = DownloadCompleted
+	case ctx.Err() != nil:
+		job.Status = DownloadQueued
+	default:
+		job.Status = DownloadFailed
+		job.Err = err
+	}
+	shared.saveQueue()
+	shared.schedule()
+	shared.condition.Broadcast()
+}
+
+/*
+loadQueue allows you to read the jobs which were unfinished when the queue
+was last saved. Each job is saved as a 'job' line with its URL and file
+name separated by tabs, which a valid URL can never contain.
+*/
+func (shared *downloadManagerType) loadQueue() error {
+	queueContents, err := shared.fileSystem.readFile(shared.fileName)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(queueContents))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 || fields[0] != "job" {
+			continue
+		}
+		job, isExisting := shared.jobsByURL[fields[1]]
+		if !isExisting {
+			job = &DownloadJob{URL: fields[1]}
+			shared.jobs = append(shared.jobs, job)
+			shared.jobsByURL[job.URL] = job
+		}
+		job.FileNames = append(job.FileNames, fields[2])
+	}
+	return scanner.Err()
+}
+
+/*
+saveQueue allows you to atomically replace the saved queue with every job
+which has not yet finished. The mutex must be held.
+*/
+func (shared *downloadManagerType) saveQueue() error {
+	var queueContents strings.Builder
+	for _, job := range shared.jobs {
+		if job.Status != DownloadQueued && job.Status != DownloadRunning {
+			continue
+		}
+		for _, fileName := range job.FileNames {
+			queueContents.WriteString("job\t" + job.URL + "\t" + fileName + "\n")
+		}
+	}
+	backend := shared.fileSystem.backend
+	temporaryFileName := shared.fileName + ".tmp"
+	file, err := backend.OpenFile(temporaryFileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(queueContents.String())
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := backend.Rename(temporaryFileName, shared.fileName); err != nil {
+		return err
+	}
+	syncDirectory(backend, filepath.Dir(shared.fileName))
+	return nil
+}
+
+/*
+getDownloadHost allows you to obtain the host a URL refers to, which is
+used to apply the per host concurrency limit.
+*/
+func getDownloadHost(downloadURL string) string {
+	parsedURL, err := url.Parse(downloadURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsedURL.Host)
+}
+
+/*
+copyDownloadJob allows you to take a copy of a job which can be handed to a
+caller without sharing any state with the download manager.
+*/
+func copyDownloadJob(job *DownloadJob) DownloadJob {
+	jobCopy := *job
+	jobCopy.FileNames = append([]string{}, job.FileNames...)
+	return jobCopy
+}
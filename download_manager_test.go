@@ -0,0 +1,186 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+/*
+getConcurrencyServer allows you to create a test server which records the
+greatest number of requests it served at once.
+*/
+func getConcurrencyServer(maximumCount *int32, requestCount *int32) *httptest.Server {
+	var currentCount int32
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(requestCount, 1)
+		count := atomic.AddInt32(&currentCount, 1)
+		defer atomic.AddInt32(&currentCount, -1)
+		for {
+			previousMaximum := atomic.LoadInt32(maximumCount)
+			if count <= previousMaximum || atomic.CompareAndSwapInt32(maximumCount, previousMaximum, count) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		writer.Write([]byte("Contents of " + request.URL.Path))
+	}))
+}
+
+func TestDownloadManager(test *testing.T) {
+	var firstMaximum, secondMaximum, firstRequestCount, secondRequestCount int32
+	firstServer := getConcurrencyServer(&firstMaximum, &firstRequestCount)
+	defer firstServer.Close()
+	secondServer := getConcurrencyServer(&secondMaximum, &secondRequestCount)
+	defer secondServer.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	var activeCallbackCount int32
+	isConcurrentCallSeen := false
+	progressCount := 0
+	progressURLs := make(map[string]bool)
+	options := DownloadManagerOptions{ConcurrencyLimit: 3, HostConcurrencyLimit: 2}
+	options.ProgressCallback = func(job DownloadJob) {
+		if atomic.AddInt32(&activeCallbackCount, 1) > 1 {
+			isConcurrentCallSeen = true
+		}
+		progressURLs[job.URL] = true
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&activeCallbackCount, -1)
+	}
+	options.DownloadOptions.ProgressCallback = func(progress DownloadProgress) {
+		progressCount++
+	}
+	manager, err := fileSystem.GetDownloadManager("/downloads.queue", options)
+	assert.NoErrorf(test, err, "An error was not expected when creating a download manager.")
+	for index := 0; index < 4; index++ {
+		manager.Add(firstServer.URL+"/first"+strconv.Itoa(index), "/first"+strconv.Itoa(index)+".txt")
+		manager.Add(secondServer.URL+"/second"+strconv.Itoa(index), "/second"+strconv.Itoa(index)+".txt")
+	}
+	manager.Add(firstServer.URL+"/first0", "/duplicate.txt")
+	manager.Start(context.Background())
+	manager.Wait()
+	for _, job := range manager.GetJobs() {
+		assert.Equalf(test, DownloadCompleted, job.Status, "The job for '%s' was expected to complete.", job.URL)
+	}
+	assert.Equalf(test, 8, len(manager.GetJobs()), "A duplicate URL was not expected to add a job.")
+	assert.Equalf(test, int32(4), atomic.LoadInt32(&firstRequestCount), "A duplicate URL was not expected to be downloaded twice.")
+	duplicateContents, _ := fileSystem.GetFileContents("/duplicate.txt")
+	assert.Equalf(test, "Contents of /first0", string(duplicateContents), "The file name added to a duplicate URL was expected to receive a copy.")
+	assert.Truef(test, atomic.LoadInt32(&firstMaximum) <= 2 && atomic.LoadInt32(&secondMaximum) <= 2, "The per host concurrency limit was exceeded.")
+	assert.Truef(test, atomic.LoadInt32(&firstMaximum)+atomic.LoadInt32(&secondMaximum) >= 3, "Jobs were expected to run concurrently.")
+	job, _ := manager.GetJob(secondServer.URL + "/second3")
+	assert.Equalf(test, int64(len("Contents of /second3")), job.Progress.ReceivedSize, "The progress of each job was expected to be recorded.")
+	assert.Truef(test, progressCount >= 8, "The progress callback of the download options was expected to be called for every job.")
+	assert.Equalf(test, 8, len(progressURLs), "The progress callback was expected to be told about every job.")
+	assert.Falsef(test, isConcurrentCallSeen, "The progress callback was not expected to be called concurrently.")
+	queueContents, _ := fileSystem.GetFileContents("/downloads.queue")
+	assert.Equalf(test, "", string(queueContents), "The saved queue was expected to be empty once every job completed.")
+	err = manager.Add(firstServer.URL+"/first1", "/late.txt")
+	assert.NoErrorf(test, err, "An error was not expected when adding a file name to a completed job.")
+	lateContents, _ := fileSystem.GetFileContents("/late.txt")
+	assert.Equalf(test, "Contents of /first1", string(lateContents), "A file name added to a completed job was expected to receive a copy.")
+}
+
+func TestDownloadManagerAddWhileFinishing(test *testing.T) {
+	var maximumCount, requestCount int32
+	server := getConcurrencyServer(&maximumCount, &requestCount)
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	manager, _ := fileSystem.GetDownloadManager("/downloads.queue", DownloadManagerOptions{ConcurrencyLimit: 8, HostConcurrencyLimit: 8})
+	var addedFileNames []string
+	for index := 0; index < 8; index++ {
+		manager.Add(server.URL+"/file"+strconv.Itoa(index), "/file"+strconv.Itoa(index)+".txt")
+	}
+	manager.Start(context.Background())
+	for copyIndex := 0; ; copyIndex++ {
+		isFinished := true
+		for index := 0; index < 8; index++ {
+			url := server.URL + "/file" + strconv.Itoa(index)
+			if job, _ := manager.GetJob(url); job.Status != DownloadCompleted {
+				isFinished = false
+			}
+			fileName := "/file" + strconv.Itoa(index) + "_copy" + strconv.Itoa(copyIndex) + ".txt"
+			if manager.Add(url, fileName) == nil {
+				addedFileNames = append(addedFileNames, fileName)
+			}
+		}
+		if isFinished {
+			break
+		}
+	}
+	manager.Wait()
+	for _, fileName := range addedFileNames {
+		assert.Truef(test, fileSystem.IsFileExists(fileName), "The file name '%s' was added successfully but never received a copy.", fileName)
+	}
+}
+
+func TestDownloadManagerPersistence(test *testing.T) {
+	var maximumCount, requestCount int32
+	server := getConcurrencyServer(&maximumCount, &requestCount)
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	manager, _ := fileSystem.GetDownloadManager("/downloads.queue", DownloadManagerOptions{})
+	manager.Add(server.URL+"/first", "/first.txt")
+	manager.Add(server.URL+"/second", "/second with spaces.txt")
+	manager.Add(server.URL+"/second", "/second copy.txt")
+	manager.Add(server.URL+"/path with spaces", "/third.txt")
+	assert.Truef(test, errors.Is(manager.Add(server.URL+"/fourth", "/fourth\n.txt"), ErrInvalidItem), "A file name containing a newline was expected to be rejected.")
+	assert.Errorf(test, manager.Add(server.URL+"/fourth\t", "/fourth.txt"), "A URL containing a control character was expected to be rejected.")
+	manager.Wait()
+
+	restartedManager, err := fileSystem.GetDownloadManager("/downloads.queue", DownloadManagerOptions{})
+	assert.NoErrorf(test, err, "An error was not expected when loading a saved queue.")
+	jobs := restartedManager.GetJobs()
+	assert.Equalf(test, 3, len(jobs), "The unfinished jobs were expected to be loaded.")
+	assert.Equalf(test, []string{"/second with spaces.txt", "/second copy.txt"}, jobs[1].FileNames, "The file names of a loaded job were not as expected.")
+	assert.Equalf(test, server.URL+"/path with spaces", jobs[2].URL, "A URL containing spaces was expected to be loaded intact.")
+	assert.Equalf(test, []string{"/third.txt"}, jobs[2].FileNames, "The file name of a job with spaces in its URL was not as expected.")
+	assert.Equalf(test, DownloadQueued, jobs[0].Status, "A loaded job was expected to be queued.")
+	restartedManager.Start(context.Background())
+	restartedManager.Wait()
+	copyContents, _ := fileSystem.GetFileContents("/second copy.txt")
+	assert.Equalf(test, "Contents of /second", string(copyContents), "A loaded job was expected to be completed.")
+}
+
+func TestDownloadManagerFailureAndCancel(test *testing.T) {
+	isBlocked := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/missing" {
+			http.NotFound(writer, request)
+			return
+		}
+		select {
+		case <-isBlocked:
+		case <-request.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(isBlocked)
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	manager, _ := fileSystem.GetDownloadManager("/downloads.queue", DownloadManagerOptions{})
+	manager.Add(server.URL+"/missing", "/missing.txt")
+	manager.Add(server.URL+"/slow", "/slow.txt")
+	ctx, cancel := context.WithCancel(context.Background())
+	manager.Start(ctx)
+	for {
+		if job, _ := manager.GetJob(server.URL + "/missing"); job.Status == DownloadFailed {
+			assert.Truef(test, errors.Is(job.Err, ErrUnexpectedStatus), "The error of a failed job was expected to be recorded.")
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	job, _ := manager.GetJob(server.URL + "/slow")
+	assert.Equalf(test, DownloadRunning, job.Status, "The slow job was expected to be running.")
+	cancel()
+	manager.Wait()
+	job, _ = manager.GetJob(server.URL + "/slow")
+	assert.Equalf(test, DownloadQueued, job.Status, "A job stopped by cancelling the context was expected to be queued again.")
+	queueContents, _ := fileSystem.GetFileContents("/downloads.queue")
+	assert.Equalf(test, "job\t"+server.URL+"/slow\t/slow.txt", string(queueContents), "Only the unfinished job was expected to remain in the saved queue.")
+}
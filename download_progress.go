@@ -0,0 +1,153 @@
+package filesystem
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+/*
+defaultProgressInterval is how often download progress is reported when
+no interval is configured.
+*/
+const defaultProgressInterval = time.Second
+
+/*
+DownloadProgress describes how far a download has got. In addition, the
+following information should be noted:
+
+- The received size includes any data kept from an earlier attempt which
+was resumed.
+
+- The total size is '-1' when the server did not report it, in which case
+the remaining time is '-1' as well.
+
+- The rate is the average since the download was started, and only counts
+data received by the current call.
+*/
+type DownloadProgress struct {
+	ReceivedSize   int64
+	TotalSize      int64
+	BytesPerSecond float64
+	RemainingTime  time.Duration
+}
+
+/*
+downloadProgressType keeps track of the data received by a download and
+reports it to a callback at a fixed interval.
+*/
+type downloadProgressType struct {
+	mutex           sync.Mutex
+	callback        func(DownloadProgress)
+	interval        time.Duration
+	startTime       time.Time
+	lastReportTime  time.Time
+	receivedSize    int64
+	transferredSize int64
+	totalSize       int64
+	isStarted       bool
+}
+
+type progressReaderType struct {
+	reader   io.Reader
+	progress *downloadProgressType
+}
+
+/*
+getDownloadProgress allows you to create a progress tracker which reports
+to the callback provided. A nil callback disables reporting.
+*/
+func getDownloadProgress(callback func(DownloadProgress), interval time.Duration) *downloadProgressType {
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+	var progress downloadProgressType
+	progress.callback = callback
+	progress.interval = interval
+	progress.totalSize = -1
+	return &progress
+}
+
+/*
+start allows you to record the amount of data already received, and the
+total expected, when a response begins.
+*/
+func (shared *downloadProgressType) start(receivedSize int64, totalSize int64) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	if !shared.isStarted {
+		shared.isStarted = true
+		shared.startTime = time.Now()
+		shared.lastReportTime = shared.startTime
+	}
+	shared.receivedSize = receivedSize
+	shared.totalSize = totalSize
+}
+
+/*
+add allows you to record data which has been received, reporting progress
+if the interval has elapsed since it was last reported.
+*/
+func (shared *downloadProgressType) add(byteCount int64) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	shared.receivedSize += byteCount
+	shared.transferredSize += byteCount
+	if shared.callback != nil && time.Since(shared.lastReportTime) >= shared.interval {
+		shared.report()
+	}
+}
+
+/*
+finish allows you to report progress a final time once a download has
+completed.
+*/
+func (shared *downloadProgressType) finish() {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	if shared.callback != nil {
+		shared.report()
+	}
+}
+
+/*
+report allows you to send the current progress to the callback. The mutex
+must be held, which also ensures the callback is never called
+concurrently.
+*/
+func (shared *downloadProgressType) report() {
+	var progress DownloadProgress
+	currentTime := time.Now()
+	progress.ReceivedSize = shared.receivedSize
+	progress.TotalSize = shared.totalSize
+	progress.RemainingTime = -1
+	if elapsedTime := currentTime.Sub(shared.startTime); shared.isStarted && elapsedTime > 0 {
+		progress.BytesPerSecond = float64(shared.transferredSize) / elapsedTime.Seconds()
+	}
+	if progress.TotalSize >= 0 {
+		remainingSize := progress.TotalSize - progress.ReceivedSize
+		if remainingSize <= 0 {
+			progress.RemainingTime = 0
+		} else if progress.BytesPerSecond > 0 {
+			progress.RemainingTime = time.Duration(float64(remainingSize) / progress.BytesPerSecond * float64(time.Second))
+		}
+	}
+	shared.lastReportTime = currentTime
+	shared.callback(progress)
+}
+
+/*
+getReader allows you to wrap a reader so that every byte read from it is
+recorded as progress.
+*/
+func (shared *downloadProgressType) getReader(reader io.Reader) io.Reader {
+	return &progressReaderType{reader: reader, progress: shared}
+}
+
+func (shared *progressReaderType) Read(buffer []byte) (int, error) {
+	byteCount, err := shared.reader.Read(buffer)
+	if byteCount > 0 {
+		shared.progress.add(int64(byteCount))
+	}
+	return byteCount, err
+}
@@ -0,0 +1,53 @@
+package filesystem
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadFileProgress(test *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 2000))
+	entityTag := `"version-1"`
+	server, _ := getDownloadServer(&content, &entityTag)
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.writeFile("/file.bin.part", content[:5000], 0644)
+	fileSystem.writeFile("/file.bin.part.state", []byte("url "+server.URL+"\netag \"version-1\"\n"), 0644)
+	var reports []DownloadProgress
+	options := DownloadOptions{
+		ProgressCallback: func(progress DownloadProgress) { reports = append(reports, progress) },
+		ProgressInterval: 10 * time.Millisecond,
+		BytesPerSecond:   100000,
+	}
+	startTime := time.Now()
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", options)
+	elapsedTime := time.Since(startTime)
+	assert.NoErrorf(test, err, "An error was not expected when downloading with progress reporting.")
+	assert.Truef(test, elapsedTime >= 40*time.Millisecond, "Downloading 15 KB at 100 KB per second took only '%s'.", elapsedTime)
+	assert.Truef(test, len(reports) > 2, "Progress was expected to be reported several times.")
+	for index, report := range reports {
+		assert.Equalf(test, int64(20000), report.TotalSize, "The total size reported was not as expected.")
+		assert.Truef(test, report.ReceivedSize > 5000, "The received size was expected to include the resumed data.")
+		if index > 0 {
+			assert.Truef(test, report.ReceivedSize >= reports[index-1].ReceivedSize, "The received size was not expected to go backwards.")
+		}
+	}
+	finalReport := reports[len(reports)-1]
+	assert.Equalf(test, int64(20000), finalReport.ReceivedSize, "The final report was expected to cover the whole file.")
+	assert.Equalf(test, time.Duration(0), finalReport.RemainingTime, "No time was expected to remain once the download completed.")
+	assert.Truef(test, finalReport.BytesPerSecond > 0 && finalReport.BytesPerSecond < 400000, "The rate reported was not as expected.")
+}
+
+func TestDownloadProgressUnknownSize(test *testing.T) {
+	var reports []DownloadProgress
+	progress := getDownloadProgress(func(report DownloadProgress) { reports = append(reports, report) }, time.Hour)
+	progress.start(0, -1)
+	progress.add(100)
+	assert.Equalf(test, 0, len(reports), "Progress was not expected to be reported before the interval elapsed.")
+	progress.finish()
+	assert.Equalf(test, int64(100), reports[0].ReceivedSize, "The received size reported was not as expected.")
+	assert.Equalf(test, time.Duration(-1), reports[0].RemainingTime, "The remaining time was expected to be unknown without a total size.")
+}
@@ -0,0 +1,234 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+/*
+defaultDownloadTimeout is how long a download waits without receiving any
+data when no timeout is configured.
+*/
+const defaultDownloadTimeout = 30 * time.Second
+
+/*
+defaultRetryDelay is the delay before the first retry of a download when
+no delay is configured.
+*/
+const defaultRetryDelay = time.Second
+
+/*
+defaultMaximumRetryDelay is the longest delay between two attempts of a
+download when no maximum is configured.
+*/
+const defaultMaximumRetryDelay = 30 * time.Second
+
+/*
+maximumStatusBodyLength is the most data kept from the body of a response
+which is reported as a 'StatusError'.
+*/
+const maximumStatusBodyLength = 512
+
+var retryRandom = rand.New(rand.NewSource(time.Now().UnixNano()))
+var retryRandomMutex sync.Mutex
+
+/*
+downloadWatchdogType cancels an attempt at a download once no data has
+been received for longer than its timeout.
+*/
+type downloadWatchdogType struct {
+	timer     *time.Timer
+	timeout   time.Duration
+	isExpired int32
+}
+
+type watchdogReaderType struct {
+	reader   io.Reader
+	watchdog *downloadWatchdogType
+}
+
+/*
+getDownloadWatchdog allows you to create a watchdog which calls the cancel
+function provided if it is not reset within the timeout provided.
+*/
+func getDownloadWatchdog(timeout time.Duration, cancel context.CancelFunc) *downloadWatchdogType {
+	var watchdog downloadWatchdogType
+	watchdog.timeout = timeout
+	watchdog.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&watchdog.isExpired, 1)
+		cancel()
+	})
+	return &watchdog
+}
+
+/*
+reset allows you to restart the timeout of a watchdog after data has been
+received.
+*/
+func (shared *downloadWatchdogType) reset() {
+	shared.timer.Reset(shared.timeout)
+}
+
+/*
+stop allows you to stop a watchdog once an attempt has finished.
+*/
+func (shared *downloadWatchdogType) stop() {
+	shared.timer.Stop()
+}
+
+/*
+isTimedOut allows you to check if a watchdog cancelled its attempt.
+*/
+func (shared *downloadWatchdogType) isTimedOut() bool {
+	return atomic.LoadInt32(&shared.isExpired) == 1
+}
+
+/*
+getReader allows you to wrap a reader so that the watchdog is reset every
+time data is read from it.
+*/
+func (shared *downloadWatchdogType) getReader(reader io.Reader) io.Reader {
+	return &watchdogReaderType{reader: reader, watchdog: shared}
+}
+
+func (shared *watchdogReaderType) Read(buffer []byte) (int, error) {
+	byteCount, err := shared.reader.Read(buffer)
+	if byteCount > 0 {
+		shared.watchdog.reset()
+	}
+	return byteCount, err
+}
+
+/*
+retry allows you to run an operation, running it again after a delay for
+as long as it fails with a transient error and retries remain. The delay
+doubles after every attempt, with random jitter applied, unless the server
+asked for a specific delay with 'Retry-After'.
+*/
+func (shared *downloaderType) retry(ctx context.Context, operation func(ctx context.Context) error) error {
+	retryDelay := shared.options.RetryDelay
+	if retryDelay <= 0 {
+		retryDelay = defaultRetryDelay
+	}
+	maximumRetryDelay := shared.options.MaximumRetryDelay
+	if maximumRetryDelay <= 0 {
+		maximumRetryDelay = defaultMaximumRetryDelay
+	}
+	for attempt := 0; ; attempt++ {
+		err := operation(ctx)
+		if err == nil || attempt >= shared.options.RetryCount || ctx.Err() != nil || !isTransientDownloadError(err) {
+			return err
+		}
+		waitDuration := getJitteredDelay(retryDelay)
+		var statusError *StatusError
+		if errors.As(err, &statusError) && statusError.retryAfter > 0 {
+			waitDuration = statusError.retryAfter
+		}
+		if waitDuration > maximumRetryDelay {
+			waitDuration = maximumRetryDelay
+		}
+		timer := time.NewTimer(waitDuration)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		retryDelay *= 2
+		if retryDelay > maximumRetryDelay {
+			retryDelay = maximumRetryDelay
+		}
+	}
+}
+
+/*
+getJitteredDelay allows you to obtain a random delay between half of the
+delay provided and the full delay, so that many clients failing at once do
+not all retry at the same moment.
+*/
+func getJitteredDelay(delay time.Duration) time.Duration {
+	retryRandomMutex.Lock()
+	defer retryRandomMutex.Unlock()
+	return delay/2 + time.Duration(retryRandom.Int63n(int64(delay/2)+1))
+}
+
+/*
+isTransientDownloadError allows you to check if a failed download is worth
+trying again. Dropped connections, timeouts and the status codes servers
+use to report overload or temporary failure are all considered transient.
+*/
+func isTransientDownloadError(err error) bool {
+	var statusError *StatusError
+	if errors.As(err, &statusError) {
+		switch statusError.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, ErrDownloadTimeout) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+	var netOpError *net.OpError
+	return errors.As(err, &netOpError)
+}
+
+/*
+getStatusError allows you to describe an unsuccessful response, keeping the
+start of its body and any delay the server asked for with 'Retry-After'.
+*/
+func getStatusError(url string, response *http.Response) *StatusError {
+	var statusError StatusError
+	statusError.URL = url
+	statusError.StatusCode = response.StatusCode
+	statusError.Status = response.Status
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maximumStatusBodyLength))
+	statusError.Body = strings.TrimSpace(string(body))
+	statusError.retryAfter = parseRetryAfter(response.Header.Get("Retry-After"))
+	return &statusError
+}
+
+/*
+parseRetryAfter allows you to obtain the delay requested by a 'Retry-After'
+header, which may be a number of seconds or a date. Zero is returned when
+no valid delay was requested.
+*/
+func parseRetryAfter(retryAfter string) time.Duration {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if retryAfter == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(retryAfter, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		if seconds > int64(math.MaxInt64/time.Second) {
+			seconds = int64(math.MaxInt64 / time.Second)
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if retryTime, err := http.ParseTime(retryAfter); err == nil {
+		if delay := time.Until(retryTime); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadFileStatusError(test *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requestCount, 1)
+		writer.WriteHeader(http.StatusNotFound)
+		writer.Write([]byte("  No such release.\n"))
+	}))
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{RetryCount: 3, RetryDelay: time.Millisecond})
+	var statusError *StatusError
+	assert.Truef(test, errors.As(err, &statusError), "An unsuccessful status was expected to return a StatusError.")
+	assert.Truef(test, errors.Is(err, ErrUnexpectedStatus), "An unsuccessful status was expected to match ErrUnexpectedStatus.")
+	assert.Equalf(test, http.StatusNotFound, statusError.StatusCode, "The status code reported was not as expected.")
+	assert.Equalf(test, "No such release.", statusError.Body, "The body reported was not as expected.")
+	assert.Equalf(test, int32(1), atomic.LoadInt32(&requestCount), "A status which is not transient was not expected to be retried.")
+	assert.Falsef(test, fileSystem.IsFileExists("/file.bin"), "Nothing was expected to be written for an unsuccessful status.")
+}
+
+func TestDownloadFileRetry(test *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	var requestCount int32
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("ETag", `"version-1"`)
+		switch atomic.AddInt32(&requestCount, 1) {
+		case 1:
+			writer.Header().Set("Retry-After", "0")
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			writer.Header().Set("Content-Length", "10000")
+			writer.Write(content[:2500])
+			writer.(http.Flusher).Flush()
+			connection, _, _ := writer.(http.Hijacker).Hijack()
+			connection.Close()
+		default:
+			ranges = append(ranges, request.Header.Get("Range"))
+			http.ServeContent(writer, request, "file.bin", time.Time{}, strings.NewReader(string(content)))
+		}
+	}))
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{RetryCount: 1, RetryDelay: time.Millisecond})
+	assert.Truef(test, errors.Is(err, io.ErrUnexpectedEOF), "The download was expected to fail once its retries were used up.")
+	err = fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{RetryCount: 2, RetryDelay: time.Millisecond})
+	assert.NoErrorf(test, err, "An error was not expected when a retry succeeded.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Equalf(test, content, downloadedContent, "The retried content was not as expected.")
+	assert.Equalf(test, []string{"bytes=2500-"}, ranges, "The retry was expected to resume from the data already received.")
+}
+
+func TestDownloadFileTimeoutAndCancel(test *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		select {
+		case <-request.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{Timeout: 50 * time.Millisecond})
+	assert.Truef(test, errors.Is(err, ErrDownloadTimeout), "A server which sends nothing was expected to time out.")
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	err = fileSystem.DownloadFileWithOptions(ctx, server.URL, "/file.bin", DownloadOptions{RetryCount: 5})
+	assert.Truef(test, errors.Is(err, context.Canceled), "Cancelling the context was expected to stop the download.")
+}
+
+func TestIsTransientDownloadError(test *testing.T) {
+	assert.Truef(test, isTransientDownloadError(&StatusError{StatusCode: http.StatusTooManyRequests}), "A 429 status was expected to be transient.")
+	assert.Truef(test, isTransientDownloadError(&OpError{Op: "download", Err: io.ErrUnexpectedEOF}), "A dropped connection was expected to be transient.")
+	assert.Truef(test, isTransientDownloadError(ErrDownloadTimeout), "A timeout was expected to be transient.")
+	assert.Falsef(test, isTransientDownloadError(&StatusError{StatusCode: http.StatusForbidden}), "A 403 status was not expected to be transient.")
+	assert.Falsef(test, isTransientDownloadError(&ChecksumError{}), "A checksum mismatch was not expected to be transient.")
+	for attempt := 0; attempt < 100; attempt++ {
+		delay := getJitteredDelay(time.Second)
+		assert.Truef(test, delay >= 500*time.Millisecond && delay <= time.Second, "The jittered delay '%s' was out of range.", delay)
+	}
+}
+
+func TestParseRetryAfter(test *testing.T) {
+	assert.Equalf(test, 120*time.Second, parseRetryAfter("120"), "A delay in seconds was not parsed as expected.")
+	assert.Equalf(test, time.Duration(0), parseRetryAfter("-5"), "A negative delay was expected to be ignored.")
+	assert.Equalf(test, time.Duration(0), parseRetryAfter("soon"), "An invalid delay was expected to be ignored.")
+	retryTime := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	delay := parseRetryAfter(retryTime)
+	assert.Truef(test, delay > 59*time.Minute && delay <= time.Hour, "A delay given as a date was not parsed as expected.")
+}
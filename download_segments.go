@@ -0,0 +1,391 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+minimumSegmentSize is the smallest range a segmented download is split
+into, so that small files are not fetched with many tiny requests.
+*/
+const minimumSegmentSize = 64 * 1024
+
+/*
+segmentStateSaveInterval is how often the progress of every segment is
+recorded while a segmented download runs.
+*/
+const segmentStateSaveInterval = time.Second
+
+/*
+downloadSegmentType is a byte range of a segmented download along with how
+much of it has been received. The end offset is exclusive.
+*/
+type downloadSegmentType struct {
+	startOffset int64
+	endOffset   int64
+	nextOffset  int64
+}
+
+type segmentedDownloadType struct {
+	downloader *downloaderType
+	file       File
+	mutex      sync.Mutex
+	saveMutex  sync.Mutex
+	segments   []downloadSegmentType
+}
+
+type segmentWriterType struct {
+	segmentedDownload *segmentedDownloadType
+	index             int
+}
+
+/*
+downloadSegments allows you to run a download as several concurrent range
+requests written into a preallocated '.part' file. The progress of every
+segment is recorded in the state file, so that an interrupted download
+only fetches the ranges which are still missing. If the server does not
+support ranges, the file is downloaded as a single stream instead.
+*/
+func (shared *downloaderType) downloadSegments(ctx context.Context) error {
+	var remoteState downloadStateType
+	var isRangeSupported bool
+	err := shared.retry(ctx, func(ctx context.Context) error {
+		return shared.watch(ctx, func(ctx context.Context, watchdog *downloadWatchdogType) error {
+			var err error
+			remoteState, isRangeSupported, err = shared.probe(ctx)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if !isRangeSupported || remoteState.size <= 0 {
+		return shared.retry(ctx, func(ctx context.Context) error {
+			return shared.watch(ctx, shared.download)
+		})
+	}
+	segmentedDownload, err := shared.getSegmentedDownload(remoteState)
+	if err != nil {
+		return err
+	}
+	err = segmentedDownload.run(ctx)
+	if closeErr := segmentedDownload.close(); err == nil {
+		err = closeErr
+	}
+	if errors.Is(err, ErrRemoteFileChanged) {
+		shared.fileSystem.backend.Remove(shared.partFileName)
+		shared.fileSystem.backend.Remove(shared.stateFileName)
+	}
+	if err != nil {
+		return err
+	}
+	if err := shared.hashPartFile(); err != nil {
+		return err
+	}
+	return shared.finish()
+}
+
+/*
+probe allows you to find out whether the server supports ranges for a
+download, along with the size of the file and its validators, by
+requesting its first byte.
+*/
+func (shared *downloaderType) probe(ctx context.Context) (downloadStateType, bool, error) {
+	var remoteState downloadStateType
+	request, err := shared.getRequest(ctx, shared.url)
+	if err != nil {
+		return remoteState, false, err
+	}
+	request.Header.Set("Range", "bytes=0-0")
+	response, err := shared.getClient().Do(request)
+	if err != nil {
+		return remoteState, false, err
+	}
+	defer response.Body.Close()
+	switch {
+	case response.StatusCode == http.StatusPartialContent:
+		_, totalSize, err := parseContentRange(response.Header.Get("Content-Range"))
+		if err != nil || totalSize < 0 {
+			return remoteState, false, nil
+		}
+		remoteState.url = shared.url
+		remoteState.entityTag = response.Header.Get("ETag")
+		remoteState.lastModified = response.Header.Get("Last-Modified")
+		remoteState.size = totalSize
+		return remoteState, true, nil
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		return remoteState, false, nil
+	case response.StatusCode >= 200 && response.StatusCode <= 299:
+		return remoteState, false, nil
+	}
+	return remoteState, false, getStatusError(shared.url, response)
+}
+
+/*
+getSegmentedDownload allows you to prepare the '.part' file for a segmented
+download. Segments recorded by an earlier attempt are reused when the
+remote file has not changed, and a '.part' file written as a single stream
+is kept as the first segment. Otherwise the file is split afresh.
+*/
+func (shared *downloaderType) getSegmentedDownload(remoteState downloadStateType) (*segmentedDownloadType, error) {
+	backend := shared.fileSystem.backend
+	state, err := shared.loadState()
+	if err != nil {
+		return nil, err
+	}
+	partFileInfo, statErr := backend.Stat(shared.partFileName)
+	isResumable := statErr == nil && partFileInfo.Mode().IsRegular() && state.url == shared.url &&
+		getIfRangeValue(state) != "" && state.entityTag == remoteState.entityTag && state.lastModified == remoteState.lastModified
+	var segments []downloadSegmentType
+	flag := os.O_RDWR | os.O_CREATE
+	switch {
+	case isResumable && len(state.segments) > 0 && state.size == remoteState.size && partFileInfo.Size() == remoteState.size:
+		segments = state.segments
+	case isResumable && len(state.segments) == 0 && partFileInfo.Size() <= remoteState.size:
+		receivedSize := partFileInfo.Size()
+		if receivedSize > 0 {
+			segments = append(segments, downloadSegmentType{startOffset: 0, endOffset: receivedSize, nextOffset: receivedSize})
+		}
+		segments = append(segments, splitSegments(receivedSize, remoteState.size, shared.options.SegmentCount)...)
+	default:
+		segments = splitSegments(0, remoteState.size, shared.options.SegmentCount)
+		flag |= os.O_TRUNC
+	}
+	file, err := backend.OpenFile(shared.partFileName, flag, 0666)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(remoteState.size); err != nil {
+		file.Close()
+		return nil, err
+	}
+	var segmentedDownload segmentedDownloadType
+	segmentedDownload.downloader = shared
+	segmentedDownload.file = file
+	segmentedDownload.segments = segments
+	shared.state = remoteState
+	if err := segmentedDownload.save(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	var receivedSize int64
+	for _, segment := range segments {
+		receivedSize += segment.nextOffset - segment.startOffset
+	}
+	shared.progress.start(receivedSize, remoteState.size)
+	return &segmentedDownload, nil
+}
+
+/*
+run allows you to fetch every incomplete segment concurrently, retrying
+each one on its own. The first segment to fail stops all of the others.
+*/
+func (shared *segmentedDownloadType) run(ctx context.Context) error {
+	downloader := shared.downloader
+	segmentContext, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var waitGroup sync.WaitGroup
+	var errorMutex sync.Mutex
+	var firstErr error
+	for index, segment := range shared.segments {
+		if segment.nextOffset >= segment.endOffset {
+			continue
+		}
+		waitGroup.Add(1)
+		go func(index int) {
+			defer waitGroup.Done()
+			err := downloader.retry(segmentContext, func(ctx context.Context) error {
+				return downloader.watch(ctx, func(ctx context.Context, watchdog *downloadWatchdogType) error {
+					return shared.downloadSegment(ctx, watchdog, index)
+				})
+			})
+			if err != nil {
+				errorMutex.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errorMutex.Unlock()
+				cancel()
+			}
+		}(index)
+	}
+	isFinished := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(segmentStateSaveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-isFinished:
+				return
+			case <-ticker.C:
+				shared.save()
+			}
+		}
+	}()
+	waitGroup.Wait()
+	close(isFinished)
+	return firstErr
+}
+
+/*
+downloadSegment allows you to make a single attempt at fetching the rest of
+a segment. A response which is not for the range requested means the
+remote file has changed, since 'If-Range' is sent with every request.
+*/
+func (shared *segmentedDownloadType) downloadSegment(ctx context.Context, watchdog *downloadWatchdogType, index int) error {
+	downloader := shared.downloader
+	shared.mutex.Lock()
+	segment := shared.segments[index]
+	shared.mutex.Unlock()
+	remainingSize := segment.endOffset - segment.nextOffset
+	if remainingSize <= 0 {
+		return nil
+	}
+	request, err := downloader.getRequest(ctx, downloader.url)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", segment.nextOffset, segment.endOffset-1))
+	if ifRangeValue := getIfRangeValue(downloader.state); ifRangeValue != "" {
+		request.Header.Set("If-Range", ifRangeValue)
+	}
+	response, err := downloader.getClient().Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusOK {
+		return ErrRemoteFileChanged
+	}
+	if response.StatusCode != http.StatusPartialContent {
+		return getStatusError(downloader.url, response)
+	}
+	startOffset, totalSize, err := parseContentRange(response.Header.Get("Content-Range"))
+	if err != nil {
+		return err
+	}
+	if startOffset != segment.nextOffset || totalSize != downloader.state.size {
+		return ErrRemoteFileChanged
+	}
+	var reader io.Reader = watchdog.getReader(response.Body)
+	if downloader.throttle != nil {
+		reader = downloader.throttle.getReader(ctx, reader)
+	}
+	reader = io.LimitReader(downloader.progress.getReader(reader), remainingSize)
+	receivedSize, err := io.Copy(&segmentWriterType{segmentedDownload: shared, index: index}, reader)
+	if err == nil && receivedSize < remainingSize {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (shared *segmentWriterType) Write(buffer []byte) (int, error) {
+	segmentedDownload := shared.segmentedDownload
+	segmentedDownload.mutex.Lock()
+	offset := segmentedDownload.segments[shared.index].nextOffset
+	segmentedDownload.mutex.Unlock()
+	byteCount, err := segmentedDownload.file.WriteAt(buffer, offset)
+	segmentedDownload.mutex.Lock()
+	segmentedDownload.segments[shared.index].nextOffset += int64(byteCount)
+	segmentedDownload.mutex.Unlock()
+	return byteCount, err
+}
+
+/*
+save allows you to record the progress of every segment. The '.part' file
+is synchronised first, so the state never claims data which could still be
+lost.
+*/
+func (shared *segmentedDownloadType) save() error {
+	shared.saveMutex.Lock()
+	defer shared.saveMutex.Unlock()
+	shared.mutex.Lock()
+	segments := append([]downloadSegmentType{}, shared.segments...)
+	shared.mutex.Unlock()
+	if err := shared.file.Sync(); err != nil {
+		return err
+	}
+	shared.downloader.state.segments = segments
+	return shared.downloader.saveState()
+}
+
+/*
+close allows you to record the final progress of every segment and close
+the '.part' file.
+*/
+func (shared *segmentedDownloadType) close() error {
+	err := shared.save()
+	if closeErr := shared.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+/*
+truncatePartFile allows you to cut the '.part' file back to the size
+provided.
+*/
+func (shared *downloaderType) truncatePartFile(size int64) error {
+	file, err := shared.fileSystem.backend.OpenFile(shared.partFileName, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	err = file.Truncate(size)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+/*
+splitSegments allows you to divide a byte range into at most the number of
+segments provided, none of which is smaller than the minimum segment size
+unless the range itself is.
+*/
+func splitSegments(startOffset int64, endOffset int64, count int) []downloadSegmentType {
+	var segments []downloadSegmentType
+	segmentSize := (endOffset - startOffset + int64(count) - 1) / int64(count)
+	if segmentSize < minimumSegmentSize {
+		segmentSize = minimumSegmentSize
+	}
+	for offset := startOffset; offset < endOffset; offset += segmentSize {
+		segmentEndOffset := offset + segmentSize
+		if segmentEndOffset > endOffset {
+			segmentEndOffset = endOffset
+		}
+		segments = append(segments, downloadSegmentType{startOffset: offset, endOffset: segmentEndOffset, nextOffset: offset})
+	}
+	return segments
+}
+
+/*
+getContiguousOffset allows you to find the offset up to which the segments
+provided have been received without any gaps.
+*/
+func getContiguousOffset(segments []downloadSegmentType) int64 {
+	sortedSegments := append([]downloadSegmentType{}, segments...)
+	sort.Slice(sortedSegments, func(first int, second int) bool {
+		return sortedSegments[first].startOffset < sortedSegments[second].startOffset
+	})
+	var offset int64
+	for _, segment := range sortedSegments {
+		if segment.startOffset > offset {
+			break
+		}
+		if segment.nextOffset > offset {
+			offset = segment.nextOffset
+		}
+		if segment.nextOffset < segment.endOffset {
+			break
+		}
+	}
+	return offset
+}
@@ -0,0 +1,152 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadFileSegments(test *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	entityTag := `"version-1"`
+	server, getRanges := getDownloadServer(&content, &entityTag)
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	digest := sha256.Sum256(content)
+	options := DownloadOptions{SegmentCount: 4, Checksum: hex.EncodeToString(digest[:])}
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", options)
+	assert.NoErrorf(test, err, "An error was not expected when downloading in segments.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Truef(test, bytes.Equal(content, downloadedContent), "The content downloaded in segments was not as expected.")
+	assert.Falsef(test, fileSystem.IsFileExists("/file.bin.part.state"), "The state file was expected to be removed.")
+	ranges := getRanges()
+	assert.Equalf(test, "bytes=0-0", ranges[0], "The download was expected to start by probing for range support.")
+	assert.ElementsMatchf(test, []string{"bytes=0-262143", "bytes=262144-524287", "bytes=524288-786431", "bytes=786432-1048575"}, ranges[1:], "The segments requested were not as expected.")
+}
+
+func TestDownloadFileSegmentsResume(test *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	var requestMutex sync.Mutex
+	var ranges []string
+	var isInterrupted int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requestMutex.Lock()
+		ranges = append(ranges, request.Header.Get("Range"))
+		requestMutex.Unlock()
+		writer.Header().Set("ETag", `"version-1"`)
+		if request.Header.Get("Range") == "bytes=524288-786431" && atomic.CompareAndSwapInt32(&isInterrupted, 0, 1) {
+			writer.Header().Set("Content-Range", "bytes 524288-786431/1048576")
+			writer.Header().Set("Content-Length", "262144")
+			writer.WriteHeader(http.StatusPartialContent)
+			writer.Write(content[524288:600000])
+			writer.(http.Flusher).Flush()
+			connection, _, _ := writer.(http.Hijacker).Hijack()
+			connection.Close()
+			return
+		}
+		http.ServeContent(writer, request, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{SegmentCount: 4})
+	assert.Errorf(test, err, "An error was expected when a segment was interrupted.")
+	stateContents, _ := fileSystem.GetFileContents("/file.bin.part.state")
+	assert.Containsf(test, string(stateContents), "segment 524288 786432 600000", "The progress of the interrupted segment was expected to be recorded.")
+	requestMutex.Lock()
+	ranges = nil
+	requestMutex.Unlock()
+	err = fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{SegmentCount: 4})
+	assert.NoErrorf(test, err, "An error was not expected when resuming a segmented download.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Truef(test, bytes.Equal(content, downloadedContent), "The resumed content was not as expected.")
+	assert.Containsf(test, ranges, "bytes=600000-786431", "The interrupted segment was expected to resume where it stopped.")
+	assert.NotContainsf(test, ranges, "bytes=524288-786431", "The interrupted segment was not expected to be fetched from its start again.")
+}
+
+func TestDownloadFileSegmentsRetry(test *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	var failureCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("ETag", `"version-1"`)
+		if strings.HasPrefix(request.Header.Get("Range"), "bytes=262144-") && atomic.AddInt32(&failureCount, 1) <= 2 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		http.ServeContent(writer, request, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	options := DownloadOptions{SegmentCount: 4, RetryCount: 2, RetryDelay: time.Millisecond}
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", options)
+	assert.NoErrorf(test, err, "A failing segment was expected to be retried on its own.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Truef(test, bytes.Equal(content, downloadedContent), "The retried content was not as expected.")
+}
+
+func TestDownloadFileSegmentsChanged(test *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&requestCount, 1) == 1 {
+			writer.Header().Set("ETag", `"version-1"`)
+		} else {
+			writer.Header().Set("ETag", `"version-2"`)
+		}
+		http.ServeContent(writer, request, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{SegmentCount: 4})
+	assert.Truef(test, errors.Is(err, ErrRemoteFileChanged), "A file which changed part way through was expected to be reported.")
+	assert.Falsef(test, fileSystem.IsFileExists("/file.bin.part"), "The '.part' file was expected to be removed once the remote file changed.")
+}
+
+func TestDownloadFileSegmentsFallback(test *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requestCount, 1)
+		writer.Write(content)
+	}))
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{SegmentCount: 4})
+	assert.NoErrorf(test, err, "An error was not expected when the server does not support ranges.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Truef(test, bytes.Equal(content, downloadedContent), "The content downloaded as a single stream was not as expected.")
+	assert.Equalf(test, int32(2), atomic.LoadInt32(&requestCount), "A single stream was expected to follow the probe.")
+}
+
+func TestDownloadFileSegmentsToSingleStream(test *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	entityTag := `"version-1"`
+	server, getRanges := getDownloadServer(&content, &entityTag)
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	partContent := append(append([]byte{}, content[:300000]...), make([]byte, len(content)-300000)...)
+	fileSystem.writeFile("/file.bin.part", partContent, 0644)
+	state := "url " + server.URL + "\netag \"version-1\"\nsize 1048576\nsegment 0 262144 262144\nsegment 262144 524288 300000\nsegment 524288 1048576 600000\n"
+	fileSystem.writeFile("/file.bin.part.state", []byte(state), 0644)
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{})
+	assert.NoErrorf(test, err, "An error was not expected when resuming a segmented download as a single stream.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Truef(test, bytes.Equal(content, downloadedContent), "The content resumed as a single stream was not as expected.")
+	assert.Equalf(test, []string{"bytes=300000-"}, getRanges(), "The single stream was expected to resume after the data received without gaps.")
+}
+
+func TestGetContiguousOffset(test *testing.T) {
+	segments := []downloadSegmentType{{100, 200, 150}, {0, 100, 100}, {200, 300, 300}}
+	assert.Equalf(test, int64(150), getContiguousOffset(segments), "The contiguous offset was not as expected.")
+	assert.Equalf(test, int64(0), getContiguousOffset([]downloadSegmentType{{100, 200, 200}}), "A gap at the start was expected to give an offset of zero.")
+	assert.Equalf(test, 1, len(splitSegments(0, 1000, 8)), "A small range was expected to be kept as one segment.")
+}
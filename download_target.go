@@ -0,0 +1,229 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+defaultDownloadFileName is the name a download is saved under when neither
+the server nor the URL suggests a usable one.
+*/
+const defaultDownloadFileName = "download"
+
+/*
+maximumDownloadFileNameLength is the longest file name, in bytes, chosen
+for a download. It leaves room within the usual limit of 255 bytes for a
+collision suffix and the '.part.state' file kept while downloading.
+*/
+const maximumDownloadFileNameLength = 200
+
+/*
+maximumDownloadFileNameSuffix is the highest collision suffix tried before
+giving up on finding a free file name.
+*/
+const maximumDownloadFileNameSuffix = 9999
+
+/*
+reservedFileNames are the names which Windows does not allow for a file,
+regardless of their extension.
+*/
+var reservedFileNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+/*
+DownloadFileToDirectory allows you to download a file from the internet
+into the directory provided, letting the server name it. The path of the
+downloaded file is returned. In addition, the following information should
+be noted:
+
+- The file name is taken from the 'Content-Disposition' header of the
+response, preferring an RFC 5987 'filename*' value. If the header does not
+name the file, the last element of the URL path is used, after following
+any redirects.
+
+- The name is reduced to a single path element, and characters which are
+not allowed in file names on common platforms are replaced with '_'.
+
+- If a file with the chosen name already exists, a suffix such as ' (1)' is
+added before the extension. The name is reserved by creating an empty file,
+which is removed again if the download fails.
+
+- The server is asked for the file name with a separate request for the
+first byte of the file, before the download itself begins.
+*/
+func (shared *fileSystemType) DownloadFileToDirectory(ctx context.Context, url string, directoryPath string, options DownloadOptions) (string, error) {
+	downloader := shared.getDownloader(url, "", options)
+	var remoteFileName string
+	err := downloader.retry(ctx, func(ctx context.Context) error {
+		return downloader.watch(ctx, func(ctx context.Context, watchdog *downloadWatchdogType) error {
+			var err error
+			remoteFileName, err = downloader.getRemoteFileName(ctx)
+			return err
+		})
+	})
+	if err != nil {
+		return "", &OpError{Op: "download", Path: directoryPath, Err: err}
+	}
+	fileName, err := shared.reserveFileName(directoryPath, remoteFileName)
+	if err != nil {
+		return "", &OpError{Op: "download", Path: directoryPath, Err: err}
+	}
+	if err := shared.DownloadFileWithOptions(ctx, url, fileName, options); err != nil {
+		shared.backend.Remove(fileName)
+		return "", err
+	}
+	return fileName, nil
+}
+
+/*
+getRemoteFileName allows you to ask the server what a download should be
+called, requesting only its first byte so that little data is transferred
+when ranges are supported.
+*/
+func (shared *downloaderType) getRemoteFileName(ctx context.Context) (string, error) {
+	request, err := shared.getRequest(ctx, shared.url)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Range", "bytes=0-0")
+	response, err := shared.getClient().Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if (response.StatusCode < 200 || response.StatusCode > 299) && response.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		return "", getStatusError(shared.url, response)
+	}
+	if fileName := getContentDispositionFileName(response.Header.Get("Content-Disposition")); fileName != "" {
+		return fileName, nil
+	}
+	if fileName := getSafeFileName(path.Base(response.Request.URL.Path)); fileName != "" {
+		return fileName, nil
+	}
+	return defaultDownloadFileName, nil
+}
+
+/*
+reserveFileName allows you to claim a free file name within a directory by
+creating an empty file, adding a numbered suffix to the name provided until
+one is found which does not exist yet.
+*/
+func (shared *fileSystemType) reserveFileName(directoryPath string, fileName string) (string, error) {
+	baseName, extension := splitFileExtension(fileName)
+	for index := 0; index <= maximumDownloadFileNameSuffix; index++ {
+		candidateFileName := fileName
+		if index > 0 {
+			candidateFileName = fmt.Sprintf("%s (%d)%s", baseName, index, extension)
+		}
+		filePath := filepath.Join(directoryPath, candidateFileName)
+		file, err := shared.backend.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			return filePath, file.Close()
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+	}
+	return "", &os.PathError{Op: "open", Path: filepath.Join(directoryPath, fileName), Err: os.ErrExist}
+}
+
+/*
+getContentDispositionFileName allows you to obtain the safe file name
+suggested by a 'Content-Disposition' header. An RFC 5987 'filename*' value
+is decoded and preferred over a plain 'filename' value. An empty string is
+returned if the header does not suggest a usable name.
+*/
+func getContentDispositionFileName(contentDisposition string) string {
+	if contentDisposition == "" {
+		return ""
+	}
+	_, parameters, err := mime.ParseMediaType(contentDisposition)
+	if err != nil {
+		return ""
+	}
+	return getSafeFileName(parameters["filename"])
+}
+
+/*
+getSafeFileName allows you to turn a suggested file name into one which is
+safe to create. In addition, the following information should be noted:
+
+- Only the last element of a path is kept, with either kind of slash
+treated as a separator.
+
+- Control characters, invalid UTF-8 and the characters '<>:"/\|?*' are
+replaced with '_'.
+
+- Leading dots are removed so that the file is not hidden, along with
+trailing dots and spaces, which Windows does not allow.
+
+- A name reserved by Windows, such as 'CON', is prefixed with '_'.
+
+- An empty string is returned if nothing usable remains.
+*/
+func getSafeFileName(fileName string) string {
+	fileName = strings.ReplaceAll(fileName, `\`, "/")
+	fileName = path.Base(fileName)
+	if fileName == "/" || fileName == "." || fileName == ".." {
+		return ""
+	}
+	fileName = strings.ToValidUTF8(fileName, "_")
+	var safeFileName strings.Builder
+	for _, character := range fileName {
+		if character < 0x20 || character == 0x7f || strings.ContainsRune(`<>:"/\|?*`, character) {
+			safeFileName.WriteRune('_')
+		} else {
+			safeFileName.WriteRune(character)
+		}
+	}
+	fileName = strings.TrimLeft(strings.TrimSpace(safeFileName.String()), ".")
+	fileName = strings.TrimRight(fileName, ". ")
+	if fileName == "" {
+		return ""
+	}
+	if reservedFileNames[strings.ToUpper(strings.SplitN(fileName, ".", 2)[0])] {
+		fileName = "_" + fileName
+	}
+	if len(fileName) > maximumDownloadFileNameLength {
+		baseName, extension := splitFileExtension(fileName)
+		if len(extension) > maximumDownloadFileNameLength/2 {
+			baseName, extension = fileName, ""
+		}
+		baseName = baseName[:maximumDownloadFileNameLength-len(extension)]
+		for !utf8.ValidString(baseName) {
+			baseName = baseName[:len(baseName)-1]
+		}
+		fileName = strings.TrimRight(baseName, ". ") + extension
+	}
+	return fileName
+}
+
+/*
+splitFileExtension allows you to split a file name into its base name and
+extension, treating a compressed tar extension such as '.tar.gz' as a
+single extension.
+*/
+func splitFileExtension(fileName string) (string, string) {
+	extension := path.Ext(fileName)
+	if extension == fileName {
+		return fileName, ""
+	}
+	baseName := strings.TrimSuffix(fileName, extension)
+	if innerExtension := path.Ext(baseName); strings.EqualFold(innerExtension, ".tar") && innerExtension != baseName {
+		extension = innerExtension + extension
+		baseName = strings.TrimSuffix(baseName, innerExtension)
+	}
+	return baseName, extension
+}
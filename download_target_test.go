@@ -0,0 +1,61 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDownloadFileToDirectory(test *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/attachment":
+			writer.Header().Set("Content-Disposition", `attachment; filename="fallback.txt"; filename*=UTF-8''r%C3%A9sum%C3%A9.txt`)
+		case "/redirect":
+			http.Redirect(writer, request, "/releases/tool%20v1.tar.gz?token=1", http.StatusFound)
+			return
+		case "/missing":
+			http.NotFound(writer, request)
+			return
+		}
+		writer.Write([]byte("Contents of " + request.URL.Path))
+	}))
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.CreateDirectory("/downloads", 0755)
+	fileName, err := fileSystem.DownloadFileToDirectory(context.Background(), server.URL+"/attachment", "/downloads", DownloadOptions{})
+	assert.NoErrorf(test, err, "An error was not expected when downloading to a directory.")
+	assert.Equalf(test, "/downloads/résumé.txt", fileName, "The file name was expected to be taken from 'filename*'.")
+	contents, _ := fileSystem.GetFileContents(fileName)
+	assert.Equalf(test, "Contents of /attachment", string(contents), "The downloaded contents were not as expected.")
+	fileName, _ = fileSystem.DownloadFileToDirectory(context.Background(), server.URL+"/attachment", "/downloads", DownloadOptions{})
+	assert.Equalf(test, "/downloads/résumé (1).txt", fileName, "A colliding file name was expected to receive a suffix.")
+	fileName, _ = fileSystem.DownloadFileToDirectory(context.Background(), server.URL+"/redirect", "/downloads", DownloadOptions{})
+	assert.Equalf(test, "/downloads/tool v1.tar.gz", fileName, "The file name was expected to be taken from the redirected URL.")
+	fileName, _ = fileSystem.DownloadFileToDirectory(context.Background(), server.URL+"/redirect", "/downloads", DownloadOptions{})
+	assert.Equalf(test, "/downloads/tool v1 (1).tar.gz", fileName, "A suffix was expected to be added before a compressed tar extension.")
+	fileName, _ = fileSystem.DownloadFileToDirectory(context.Background(), server.URL+"/", "/downloads", DownloadOptions{})
+	assert.Equalf(test, "/downloads/download", fileName, "A default file name was expected when none was suggested.")
+	_, err = fileSystem.DownloadFileToDirectory(context.Background(), server.URL+"/missing", "/downloads", DownloadOptions{})
+	assert.Truef(test, errors.Is(err, ErrUnexpectedStatus), "A failed response was expected to return an unexpected status error.")
+	isExists := fileSystem.IsFileExists("/downloads/missing")
+	assert.Falsef(test, isExists, "No file was expected to be left behind by a failed download.")
+}
+
+func TestGetSafeFileName(test *testing.T) {
+	assert.Equalf(test, "passwd", getSafeFileName("../../etc/passwd"), "Only the last path element was expected to be kept.")
+	assert.Equalf(test, "evil.exe", getSafeFileName(`C:\Windows\evil.exe`), "A backslash was expected to be treated as a separator.")
+	assert.Equalf(test, "a_b_c_.txt", getSafeFileName("a<b>c?.txt"), "Invalid characters were expected to be replaced.")
+	assert.Equalf(test, "tab_name", getSafeFileName("tab\tname"), "Control characters were expected to be replaced.")
+	assert.Equalf(test, "hidden", getSafeFileName("..hidden. . "), "Leading dots and trailing dots and spaces were expected to be removed.")
+	assert.Equalf(test, "_con.txt", getSafeFileName("con.txt"), "A reserved Windows name was expected to be prefixed.")
+	assert.Equalf(test, "", getSafeFileName("/"), "An empty string was expected when nothing usable remains.")
+	longFileName := getSafeFileName(strings.Repeat("é", 150) + ".zip")
+	assert.Truef(test, len(longFileName) <= maximumDownloadFileNameLength && strings.HasSuffix(longFileName, "é.zip"), "A long file name was expected to be shortened while keeping its extension.")
+	assert.Equalf(test, "report.pdf", getContentDispositionFileName(`attachment; filename="report.pdf"`), "A plain file name was expected to be used.")
+	assert.Equalf(test, "", getContentDispositionFileName("inline"), "No file name was expected from a header without one.")
+}
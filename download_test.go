@@ -0,0 +1,213 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+getDownloadServer allows you to create a test server which serves the
+content provided with range support, recording the 'Range' header of every
+request it receives.
+*/
+func getDownloadServer(content *[]byte, entityTag *string) (*httptest.Server, func() []string) {
+	var mutex sync.Mutex
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		ranges = append(ranges, request.Header.Get("Range"))
+		currentContent := *content
+		currentEntityTag := *entityTag
+		mutex.Unlock()
+		writer.Header().Set("ETag", currentEntityTag)
+		http.ServeContent(writer, request, "file.bin", time.Time{}, bytes.NewReader(currentContent))
+	}))
+	getRanges := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string{}, ranges...)
+	}
+	return server, getRanges
+}
+
+func TestDownloadFileWithOptions(test *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	entityTag := `"version-1"`
+	server, getRanges := getDownloadServer(&content, &entityTag)
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{})
+	assert.NoErrorf(test, err, "An error was not expected when downloading a file.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Equalf(test, content, downloadedContent, "The downloaded content was not as expected.")
+	assert.Falsef(test, fileSystem.IsFileExists("/file.bin.part"), "The '.part' file was expected to be renamed into place.")
+	assert.Falsef(test, fileSystem.IsFileExists("/file.bin.part.state"), "The state file was expected to be removed.")
+	assert.Equalf(test, []string{""}, getRanges(), "A fresh download was not expected to request a range.")
+}
+
+func TestDownloadFileResume(test *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	entityTag := `"version-1"`
+	server, getRanges := getDownloadServer(&content, &entityTag)
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.writeFile("/file.bin.part", content[:4000], 0644)
+	fileSystem.writeFile("/file.bin.part.state", []byte("url "+server.URL+"\netag \"version-1\"\n"), 0644)
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{})
+	assert.NoErrorf(test, err, "An error was not expected when resuming a download.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Equalf(test, content, downloadedContent, "The resumed content was not as expected.")
+	assert.Equalf(test, []string{"bytes=4000-"}, getRanges(), "The download was expected to resume from the end of the '.part' file.")
+
+	fileSystem.writeFile("/file.bin.part", content[:4000], 0644)
+	fileSystem.writeFile("/file.bin.part.state", []byte("url "+server.URL+"\netag \"version-0\"\n"), 0644)
+	err = fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{})
+	assert.NoErrorf(test, err, "An error was not expected when the remote file had changed.")
+	downloadedContent, _ = fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Equalf(test, content, downloadedContent, "A changed remote file was expected to be downloaded from the start.")
+
+	fileSystem.writeFile("/file.bin.part", content, 0644)
+	fileSystem.writeFile("/file.bin.part.state", []byte("url "+server.URL+"\netag \"version-1\"\n"), 0644)
+	err = fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{})
+	assert.NoErrorf(test, err, "An error was not expected when the '.part' file was already complete.")
+	downloadedContent, _ = fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Equalf(test, content, downloadedContent, "A complete '.part' file was expected to be moved into place.")
+}
+
+func TestDownloadFileInterrupted(test *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	var isInterrupted bool
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("ETag", `"version-1"`)
+		if !isInterrupted {
+			isInterrupted = true
+			writer.Header().Set("Content-Length", "10000")
+			writer.Write(content[:2500])
+			writer.(http.Flusher).Flush()
+			connection, _, _ := writer.(http.Hijacker).Hijack()
+			connection.Close()
+			return
+		}
+		http.ServeContent(writer, request, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.writeFile("/file.bin", []byte("Previous version"), 0644)
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{})
+	assert.Errorf(test, err, "An error was expected when the connection was interrupted.")
+	previousContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Equalf(test, []byte("Previous version"), previousContent, "An interrupted download was not expected to touch the target file.")
+	partSize, _ := fileSystem.GetFileSize("/file.bin.part")
+	assert.Equalf(test, int64(2500), partSize, "The data received before the interruption was expected to be kept.")
+	err = fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{})
+	assert.NoErrorf(test, err, "An error was not expected when resuming an interrupted download.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Equalf(test, content, downloadedContent, "The resumed content was not as expected.")
+}
+
+func TestDownloadFileStatus(test *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{})
+	var opError *OpError
+	assert.Truef(test, errors.As(err, &opError), "An unsuccessful status was expected to return an OpError.")
+	_, err = fileSystem.backend.Stat("/file.bin")
+	assert.Truef(test, os.IsNotExist(err), "Nothing was expected to be written for an unsuccessful status.")
+}
+
+func TestDownloadFileChecksum(test *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	entityTag := `"version-1"`
+	server, _ := getDownloadServer(&content, &entityTag)
+	defer server.Close()
+	digest := sha256.Sum256(content)
+	checksum := "sha256:" + hex.EncodeToString(digest[:])
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{Checksum: checksum})
+	assert.NoErrorf(test, err, "An error was not expected when the checksum matched.")
+
+	fileSystem.writeFile("/file.bin.part", content[:4000], 0644)
+	fileSystem.writeFile("/file.bin.part.state", []byte("url "+server.URL+"\netag \"version-1\"\n"), 0644)
+	err = fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{Checksum: checksum})
+	assert.NoErrorf(test, err, "An error was not expected when the checksum of a resumed download matched.")
+
+	fileSystem.writeFile("/file.bin.part", []byte(strings.Repeat("X", 4000)), 0644)
+	fileSystem.writeFile("/file.bin.part.state", []byte("url "+server.URL+"\netag \"version-1\"\n"), 0644)
+	err = fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{Checksum: checksum})
+	var checksumError *ChecksumError
+	assert.Truef(test, errors.As(err, &checksumError), "A mismatched checksum was expected to return a ChecksumError.")
+	assert.Truef(test, errors.Is(err, ErrChecksumMismatch), "A mismatched checksum was expected to match ErrChecksumMismatch.")
+	assert.Equalf(test, "sha256", checksumError.Algorithm, "The algorithm reported was not as expected.")
+	assert.Falsef(test, fileSystem.IsFileExists("/file.bin.part"), "The '.part' file was expected to be removed on a mismatch.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Equalf(test, content, downloadedContent, "A mismatched download was not expected to replace the target file.")
+
+	err = fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{Checksum: checksum, ChecksumURL: server.URL})
+	assert.Truef(test, errors.Is(err, ErrInvalidOptions), "A checksum and checksum URL together were expected to be rejected.")
+}
+
+/*
+slowPartFileBackendType delays every read-only opening of a '.part' file,
+so that hashing the data already received takes a long time.
+*/
+type slowPartFileBackendType struct {
+	Backend
+	delay time.Duration
+}
+
+func (shared *slowPartFileBackendType) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if strings.HasSuffix(name, ".part") && flag == os.O_RDONLY {
+		time.Sleep(shared.delay)
+	}
+	return shared.Backend.OpenFile(name, flag, perm)
+}
+
+func TestDownloadFileChecksumSlowResume(test *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	entityTag := `"version-1"`
+	server, _ := getDownloadServer(&content, &entityTag)
+	defer server.Close()
+	digest := sha256.Sum256(content)
+	checksum := "sha256:" + hex.EncodeToString(digest[:])
+	fileSystem := GetFileSystem(&slowPartFileBackendType{Backend: GetMemoryBackend(), delay: 200 * time.Millisecond})
+	fileSystem.writeFile("/file.bin.part", content[:4000], 0644)
+	fileSystem.writeFile("/file.bin.part.state", []byte("url "+server.URL+"\netag \"version-1\"\n"), 0644)
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL, "/file.bin", DownloadOptions{Checksum: checksum, Timeout: 100 * time.Millisecond})
+	assert.NoErrorf(test, err, "Hashing the data already received was not expected to trip the download timeout.")
+	downloadedContent, _ := fileSystem.GetFileContentsAsBytes("/file.bin")
+	assert.Equalf(test, content, downloadedContent, "The resumed content was not as expected.")
+}
+
+func TestDownloadFileChecksumURL(test *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+	digest := sha512.Sum512(content)
+	otherDigest := sha512.Sum512([]byte("other"))
+	checksumFile := hex.EncodeToString(otherDigest[:]) + "  other.bin\n" + hex.EncodeToString(digest[:]) + "  file.bin\n"
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/SHA512SUMS" {
+			writer.Write([]byte(checksumFile))
+			return
+		}
+		writer.Write(content)
+	}))
+	defer server.Close()
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.DownloadFileWithOptions(context.Background(), server.URL+"/releases/file.bin", "/file.bin", DownloadOptions{ChecksumURL: server.URL + "/SHA512SUMS"})
+	assert.NoErrorf(test, err, "An error was not expected when the checksum file matched.")
+	err = fileSystem.DownloadFileWithOptions(context.Background(), server.URL+"/releases/other.bin", "/other.bin", DownloadOptions{ChecksumURL: server.URL + "/SHA512SUMS"})
+	assert.Truef(test, errors.Is(err, ErrChecksumMismatch), "A file not matching the checksum file was expected to be rejected.")
+	assert.Falsef(test, fileSystem.IsFileExists("/other.bin"), "A rejected download was not expected to be written.")
+}
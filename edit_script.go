@@ -0,0 +1,292 @@
+package filesystem
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+/*
+EditAction identifies what an edit command does to the lines it applies to.
+*/
+type EditAction int
+
+const (
+	/*
+		EditSubstitute replaces text matching the pattern of the command with
+		its replacement. Capture groups can be referred to in the replacement
+		as '$1' or '${name}'.
+	*/
+	EditSubstitute EditAction = iota
+	/*
+		EditDelete removes the line. No later commands are applied to it.
+	*/
+	EditDelete
+	/*
+		EditInsertBefore writes the text of the command as a new line before
+		the line.
+	*/
+	EditInsertBefore
+	/*
+		EditInsertAfter writes the text of the command as a new line after
+		the line.
+	*/
+	EditInsertAfter
+)
+
+/*
+EditAddress allows you to restrict an edit command to certain lines, in the
+same way as a 'sed' address. In addition, the following information should
+be noted:
+
+- Line numbers start at '1'. A value of '0' means no line number is set.
+
+- If no start or end is set, every line is addressed.
+
+- If only a start is set, every line matching it is addressed, which is a
+single line when the start is a line number.
+
+- If an end is set as well, every line from a line matching the start up to
+and including a line matching the end is addressed. The end pattern is only
+checked from the line after the start, and an end line number at or before
+the start line addresses the start line alone. Once a range ends, a later
+line matching the start begins a new range.
+
+- If only an end is set, the range begins at the first line.
+*/
+type EditAddress struct {
+	StartLineNumber int64
+	StartPattern    string
+	EndLineNumber   int64
+	EndPattern      string
+}
+
+/*
+EditCommand is a single step of an edit script. In addition, the following
+information should be noted:
+
+- A line must be addressed and match the pattern for the command to apply
+to it. An empty pattern matches every line, except for substitution, which
+requires a pattern.
+
+- The replacement is used by substitution, while the text is used by the
+insert actions.
+
+- When 'IsGlobal' is set, substitution replaces every match on a line
+rather than only the first.
+
+- When 'IsFirstMatchOnly' is set, the command applies to the first line it
+matches in each file and is ignored afterwards.
+*/
+type EditCommand struct {
+	Action           EditAction
+	Address          EditAddress
+	Pattern          string
+	Replacement      string
+	Text             string
+	IsGlobal         bool
+	IsFirstMatchOnly bool
+}
+
+type editCommandType struct {
+	command      EditCommand
+	pattern      *regexp.Regexp
+	startPattern *regexp.Regexp
+	endPattern   *regexp.Regexp
+	isInRange    bool
+	isDone       bool
+}
+
+/*
+EditFile allows you to run an ordered script of edit commands over a file in
+a single streaming pass. Every command is applied to each line in turn, with
+later commands seeing the result of earlier ones. The file is only rewritten
+if something changed, in which case it is replaced atomically through a
+temporary file. The number of lines which were substituted, deleted or
+inserted is returned.
+*/
+func (shared *fileSystemType) EditFile(fileName string, commands []EditCommand) (int64, error) {
+	compiledCommands, err := compileEditCommands(fileName, commands)
+	if err != nil {
+		return 0, err
+	}
+	var changedLineCount int64
+	err = shared.rewriteLines("edit", fileName, rewriteOptionsType{}, func(editor *lineEditorType, lineIndex int64, line []byte, isDelimited bool) error {
+		lineCount, err := applyEditCommands(editor, compiledCommands, lineIndex+1, line, isDelimited)
+		changedLineCount += lineCount
+		return err
+	}, func(editor *lineEditorType, lineCount int64) error {
+		return nil
+	})
+	return changedLineCount, err
+}
+
+/*
+EditFiles allows you to run an ordered script of edit commands over several
+files, such as those returned by 'FindMatchingContent'. Each file is edited
+as described by 'EditFile', with ranges and first match tracking starting
+afresh for every file. The number of changed lines is returned for each file
+edited. Editing stops at the first file which fails.
+*/
+func (shared *fileSystemType) EditFiles(fileNames []string, commands []EditCommand) (map[string]int64, error) {
+	changedLineCounts := make(map[string]int64)
+	for _, fileName := range fileNames {
+		changedLineCount, err := shared.EditFile(fileName, commands)
+		if err != nil {
+			return changedLineCounts, err
+		}
+		changedLineCounts[fileName] = changedLineCount
+	}
+	return changedLineCounts, nil
+}
+
+/*
+compileEditCommands allows you to validate a script of edit commands and
+compile every pattern it contains.
+*/
+func compileEditCommands(fileName string, commands []EditCommand) ([]*editCommandType, error) {
+	var compiledCommands []*editCommandType
+	for _, command := range commands {
+		address := command.Address
+		isValid := command.Action >= EditSubstitute && command.Action <= EditInsertAfter
+		isValid = isValid && address.StartLineNumber >= 0 && address.EndLineNumber >= 0
+		isValid = isValid && (command.Action != EditSubstitute || command.Pattern != "")
+		if !isValid {
+			return nil, &OpError{Op: "edit", Path: fileName, Err: ErrInvalidOptions}
+		}
+		compiledCommand := &editCommandType{command: command}
+		patterns := []struct {
+			pattern string
+			target  **regexp.Regexp
+		}{
+			{command.Pattern, &compiledCommand.pattern},
+			{address.StartPattern, &compiledCommand.startPattern},
+			{address.EndPattern, &compiledCommand.endPattern},
+		}
+		for _, pattern := range patterns {
+			if pattern.pattern == "" {
+				continue
+			}
+			regex, err := compilePattern("edit", fileName, pattern.pattern)
+			if err != nil {
+				return nil, err
+			}
+			*pattern.target = regex
+		}
+		compiledCommands = append(compiledCommands, compiledCommand)
+	}
+	return compiledCommands, nil
+}
+
+/*
+applyEditCommands allows you to run every command against a single line and
+write out the result, returning the number of lines changed.
+*/
+func applyEditCommands(editor *lineEditorType, commands []*editCommandType, lineNumber int64, line []byte, isDelimited bool) (int64, error) {
+	text := string(line)
+	isCarriageReturned := strings.HasSuffix(text, "\r")
+	text = strings.TrimSuffix(text, "\r")
+	var linesBefore []string
+	var linesAfter []string
+	isSubstituted := false
+	isDeleted := false
+	for _, command := range commands {
+		if command.isDone || !command.isAddressed(lineNumber, text) {
+			continue
+		}
+		if command.pattern != nil && !command.pattern.MatchString(text) {
+			continue
+		}
+		if command.command.IsFirstMatchOnly {
+			command.isDone = true
+		}
+		switch command.command.Action {
+		case EditSubstitute:
+			text = command.substitute(text)
+			isSubstituted = true
+		case EditDelete:
+			isDeleted = true
+		case EditInsertBefore:
+			linesBefore = append(linesBefore, command.command.Text)
+		case EditInsertAfter:
+			linesAfter = append(linesAfter, command.command.Text)
+		}
+		if isDeleted {
+			break
+		}
+	}
+	for _, lineBefore := range linesBefore {
+		if err := editor.writeLine(lineBefore); err != nil {
+			return 0, err
+		}
+	}
+	changedLineCount := int64(len(linesBefore) + len(linesAfter))
+	if isDeleted {
+		editor.isChanged = true
+		changedLineCount++
+	} else {
+		if isCarriageReturned {
+			text += "\r"
+		}
+		if isSubstituted && !bytes.Equal(line, []byte(text)) {
+			editor.isChanged = true
+			changedLineCount++
+		}
+		if err := editor.copyLine([]byte(text), isDelimited); err != nil {
+			return 0, err
+		}
+	}
+	for _, lineAfter := range linesAfter {
+		if err := editor.writeLine(lineAfter); err != nil {
+			return 0, err
+		}
+	}
+	return changedLineCount, nil
+}
+
+/*
+isAddressed allows you to check if a line falls within the address of a
+command, keeping track of whether a range is currently open.
+*/
+func (shared *editCommandType) isAddressed(lineNumber int64, text string) bool {
+	address := shared.command.Address
+	isStartSet := address.StartLineNumber > 0 || shared.startPattern != nil
+	isEndSet := address.EndLineNumber > 0 || shared.endPattern != nil
+	isStartMatched := address.StartLineNumber == lineNumber || (shared.startPattern != nil && shared.startPattern.MatchString(text))
+	if !isStartSet {
+		isStartMatched = lineNumber == 1
+		if !isEndSet {
+			return true
+		}
+	}
+	if !isEndSet {
+		return isStartMatched
+	}
+	if shared.isInRange {
+		if (address.EndLineNumber > 0 && lineNumber >= address.EndLineNumber) || (shared.endPattern != nil && shared.endPattern.MatchString(text)) {
+			shared.isInRange = false
+		}
+		return true
+	}
+	if !isStartMatched {
+		return false
+	}
+	shared.isInRange = address.EndLineNumber == 0 || lineNumber < address.EndLineNumber
+	return true
+}
+
+/*
+substitute allows you to replace the first, or every, match of the pattern
+of a command within a line, expanding any capture groups in the replacement.
+*/
+func (shared *editCommandType) substitute(text string) string {
+	if shared.command.IsGlobal {
+		return shared.pattern.ReplaceAllString(text, shared.command.Replacement)
+	}
+	match := shared.pattern.FindStringSubmatchIndex(text)
+	if match == nil {
+		return text
+	}
+	replacement := shared.pattern.ExpandString(nil, shared.command.Replacement, text, match)
+	return text[:match[0]] + string(replacement) + text[match[1]:]
+}
@@ -0,0 +1,67 @@
+package filesystem
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEditFile(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/app.conf", []byte("# Settings\r\nport = 80\r\nhost = old.example.com\r\n[debug]\r\nlevel = 1\r\nverbose = yes\r\n[cache]\r\nlevel = 1\r\n"), 0)
+	commands := []EditCommand{
+		{Action: EditSubstitute, Pattern: `^port = (\d+)$`, Replacement: "port = 80${1}"},
+		{Action: EditSubstitute, Pattern: `o`, Replacement: "0", IsGlobal: true, Address: EditAddress{StartLineNumber: 3}},
+		{Action: EditDelete, Pattern: `^verbose`, Address: EditAddress{StartPattern: `^\[debug\]`, EndPattern: `^\[`}},
+		{Action: EditSubstitute, Pattern: `1`, Replacement: "2", Address: EditAddress{StartPattern: `^\[debug\]`, EndPattern: `^\[`}},
+		{Action: EditInsertAfter, Pattern: `^\[`, Text: "enabled = true"},
+		{Action: EditInsertBefore, Pattern: `^\[`, Text: "", IsFirstMatchOnly: true},
+		{Action: EditDelete, Pattern: `^#`},
+	}
+	changedLineCount, err := fileSystem.EditFile("/app.conf", commands)
+	assert.NoErrorf(test, err, "An error was not expected when editing a file.")
+	assert.Equalf(test, int64(8), changedLineCount, "The number of changed lines was not as expected.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/app.conf")
+	expectedContents := "port = 8080\r\nh0st = 0ld.example.c0m\r\n\r\n[debug]\r\nenabled = true\r\nlevel = 2\r\n[cache]\r\nenabled = true\r\nlevel = 1\r\n"
+	assert.Equalf(test, expectedContents, string(fileContents), "The edited file was not as expected.")
+}
+
+func TestEditFileAddresses(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/numbers.txt", []byte("1\n2\n3\n4\n5\n6"), 0)
+	commands := []EditCommand{
+		{Action: EditDelete, Address: EditAddress{StartLineNumber: 2, EndLineNumber: 3}},
+		{Action: EditSubstitute, Pattern: `^`, Replacement: "> ", Address: EditAddress{StartPattern: `5`, EndLineNumber: 1}},
+		{Action: EditSubstitute, Pattern: `$`, Replacement: " <", Address: EditAddress{EndPattern: `^4$`}},
+	}
+	_, err := fileSystem.EditFile("/numbers.txt", commands)
+	assert.NoErrorf(test, err, "An error was not expected when editing a file.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/numbers.txt")
+	assert.Equalf(test, "1 <\n4 <\n> 5\n6", string(fileContents), "Lines were not addressed as expected.")
+	fileInfo, _ := fileSystem.backend.Stat("/numbers.txt")
+	changedLineCount, err := fileSystem.EditFile("/numbers.txt", []EditCommand{{Action: EditDelete, Pattern: `missing`}})
+	assert.NoErrorf(test, err, "An error was not expected when an edit changes nothing.")
+	assert.Equalf(test, int64(0), changedLineCount, "No lines were expected to change.")
+	unchangedFileInfo, _ := fileSystem.backend.Stat("/numbers.txt")
+	assert.Truef(test, isSameFile(fileInfo, unchangedFileInfo), "A file was not expected to be replaced when nothing changed.")
+	_, err = fileSystem.EditFile("/numbers.txt", []EditCommand{{Action: EditSubstitute}})
+	assert.Truef(test, errors.Is(err, ErrInvalidOptions), "A substitution without a pattern was expected to be rejected.")
+	_, err = fileSystem.EditFile("/numbers.txt", []EditCommand{{Action: EditDelete, Pattern: `(`}})
+	assert.Truef(test, errors.Is(err, ErrInvalidPattern), "An invalid pattern was expected to be rejected.")
+}
+
+func TestEditFiles(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.CreateDirectory("/configs", 0755)
+	fileSystem.WriteBytesToFile("/configs/first.conf", []byte("mode = a\nmode = a\n"), 0)
+	fileSystem.WriteBytesToFile("/configs/second.conf", []byte("mode = a\n"), 0)
+	fileNames, _ := fileSystem.FindMatchingContent("/configs", []string{`\.conf$`}, true, false, false)
+	commands := []EditCommand{{Action: EditSubstitute, Pattern: `a`, Replacement: "b", IsFirstMatchOnly: true}}
+	changedLineCounts, err := fileSystem.EditFiles(fileNames, commands)
+	assert.NoErrorf(test, err, "An error was not expected when editing several files.")
+	assert.Equalf(test, 2, len(changedLineCounts), "Both files were expected to be edited.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/configs/first.conf")
+	assert.Equalf(test, "mode = b\nmode = a\n", string(fileContents), "Only the first match was expected to be changed.")
+	fileContents, _ = fileSystem.GetFileContentsAsBytes("/configs/second.conf")
+	assert.Equalf(test, "mode = b\n", string(fileContents), "The first match was expected to be tracked separately for each file.")
+}
@@ -0,0 +1,221 @@
+package filesystem
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+/*
+ErrNotOpen is returned when an operation is performed on a file instance
+which does not currently have a file open.
+*/
+var ErrNotOpen = errors.New("no file is open")
+
+/*
+ErrInvalidPattern is returned when a regular expression supplied by the
+caller could not be compiled.
+*/
+var ErrInvalidPattern = errors.New("invalid pattern")
+
+/*
+ErrDestinationExists is returned when a move operation would overwrite an
+entry which already exists.
+*/
+var ErrDestinationExists = errors.New("destination already exists")
+
+/*
+ErrNotRegularFile is returned when an operation which only works on
+regular files is given a directory, device or other special entry.
+*/
+var ErrNotRegularFile = errors.New("not a regular file")
+
+/*
+ErrInvalidOptions is returned when a combination of options supplied by the
+caller contradict each other.
+*/
+var ErrInvalidOptions = errors.New("invalid combination of options")
+
+/*
+ErrIsSymlink is returned when a file is opened with symbolic links rejected
+and the path provided refers to a symbolic link.
+*/
+var ErrIsSymlink = errors.New("file is a symbolic link")
+
+/*
+ErrLineTooLong is returned when a line being scanned exceeds the maximum
+line length configured for the scanner.
+*/
+var ErrLineTooLong = errors.New("line exceeds the maximum line length")
+
+/*
+ErrQueueEmpty is returned when an item is requested from a queue which has
+no items available.
+*/
+var ErrQueueEmpty = errors.New("queue is empty")
+
+/*
+ErrStackEmpty is returned when an item is requested from a stack which has
+no items.
+*/
+var ErrStackEmpty = errors.New("stack is empty")
+
+/*
+ErrInvalidItem is returned when an item added to a line based structure,
+such as a queue or stack, contains a newline or is too long to be read back.
+*/
+var ErrInvalidItem = errors.New("item contains a newline or is too long")
+
+/*
+ErrItemNotPending is returned when a queue item is acknowledged or released
+but it is not currently dequeued.
+*/
+var ErrItemNotPending = errors.New("item is not pending")
+
+/*
+ErrLineOutOfRange is returned when a line index refers to a line beyond the
+end of a file.
+*/
+var ErrLineOutOfRange = errors.New("line index out of range")
+
+/*
+ErrPathEscapesRoot is returned by a rooted backend whenever a path would
+resolve to a location outside of its root directory.
+*/
+var ErrPathEscapesRoot = errors.New("path escapes the root directory")
+
+/*
+ErrInvalidChecksum is returned when an expected checksum, or a checksum
+file, is not in a recognised format.
+*/
+var ErrInvalidChecksum = errors.New("invalid checksum")
+
+/*
+ErrChecksumMismatch is returned when downloaded data does not match the
+checksum it was expected to have.
+*/
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+/*
+ErrUnexpectedStatus is returned when an HTTP request receives a response
+whose status code does not indicate success.
+*/
+var ErrUnexpectedStatus = errors.New("unexpected HTTP status")
+
+/*
+ErrDownloadTimeout is returned when a download receives no data for longer
+than its configured timeout.
+*/
+var ErrDownloadTimeout = errors.New("download timed out waiting for data")
+
+/*
+ErrRemoteFileChanged is returned when the file being downloaded changes on
+the server part way through a segmented download.
+*/
+var ErrRemoteFileChanged = errors.New("remote file changed during download")
+
+/*
+OpError records a failed package operation along with the path, or paths,
+it was operating on. The underlying cause can be inspected with
+'errors.Is' and 'errors.As'.
+*/
+type OpError struct {
+	Op      string
+	Path    string
+	NewPath string
+	Err     error
+}
+
+func (shared *OpError) Error() string {
+	message := shared.Op
+	if shared.Path != "" {
+		message += " " + shared.Path
+	}
+	if shared.NewPath != "" {
+		message += " -> " + shared.NewPath
+	}
+	return message + ": " + shared.Err.Error()
+}
+
+func (shared *OpError) Unwrap() error {
+	return shared.Err
+}
+
+/*
+PatternError records a regular expression which could not be compiled. It
+matches 'ErrInvalidPattern' when inspected with 'errors.Is', while the
+underlying syntax error remains available through 'errors.As'.
+*/
+type PatternError struct {
+	Pattern string
+	Err     error
+}
+
+func (shared *PatternError) Error() string {
+	return ErrInvalidPattern.Error() + " '" + shared.Pattern + "': " + shared.Err.Error()
+}
+
+func (shared *PatternError) Unwrap() error {
+	return shared.Err
+}
+
+func (shared *PatternError) Is(target error) bool {
+	return target == ErrInvalidPattern
+}
+
+/*
+compilePattern allows you to compile a regular expression supplied by the
+caller, reporting failures as an 'OpError' instead of panicking.
+*/
+func compilePattern(operation string, path string, pattern string) (*regexp.Regexp, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, &OpError{Op: operation, Path: path, Err: &PatternError{Pattern: pattern, Err: err}}
+	}
+	return regex, nil
+}
+
+/*
+ChecksumError records data whose digest did not match the one expected. It
+matches 'ErrChecksumMismatch' when inspected with 'errors.Is'. Digests are
+reported in hexadecimal.
+*/
+type ChecksumError struct {
+	Algorithm      string
+	ExpectedDigest string
+	ActualDigest   string
+}
+
+func (shared *ChecksumError) Error() string {
+	return ErrChecksumMismatch.Error() + ": expected " + shared.Algorithm + " " + shared.ExpectedDigest + ", got " + shared.ActualDigest
+}
+
+func (shared *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+/*
+StatusError records an HTTP response whose status code does not indicate
+success, along with the start of its body to help explain why. It matches
+'ErrUnexpectedStatus' when inspected with 'errors.Is'.
+*/
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+	retryAfter time.Duration
+}
+
+func (shared *StatusError) Error() string {
+	message := ErrUnexpectedStatus.Error() + " '" + shared.Status + "' from " + shared.URL
+	if shared.Body != "" {
+		message += ": " + strconv.Quote(shared.Body)
+	}
+	return message
+}
+
+func (shared *StatusError) Is(target error) bool {
+	return target == ErrUnexpectedStatus
+}
@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"regexp/syntax"
+	"testing"
+)
+
+func TestFileInstanceNotOpenErrors(test *testing.T) {
+	var file fileInstanceType
+	err := file.Close()
+	assert.Truef(test, errors.Is(err, ErrNotOpen), "Closing a file which is not open was expected to return ErrNotOpen.")
+	err = file.WriteBytes([]byte{255})
+	assert.Truef(test, errors.Is(err, ErrNotOpen), "Writing bytes without an open file was expected to return ErrNotOpen.")
+	err = file.WriteLine("A sample line")
+	assert.Truef(test, errors.Is(err, ErrNotOpen), "Writing lines without an open file was expected to return ErrNotOpen.")
+	err = file.WriteString("A sample string")
+	assert.Truef(test, errors.Is(err, ErrNotOpen), "Writing strings without an open file was expected to return ErrNotOpen.")
+	_, err = file.GetFileContents()
+	assert.Truef(test, errors.Is(err, ErrNotOpen), "Reading without an open file was expected to return ErrNotOpen.")
+	_, err = file.GetFirstLine()
+	assert.Truef(test, errors.Is(err, ErrNotOpen), "Reading the first line without an open file was expected to return ErrNotOpen.")
+	var opError *OpError
+	assert.Truef(test, errors.As(err, &opError), "An OpError was expected to be returned.")
+	assert.Equalf(test, "read", opError.Op, "The operation recorded by the error was not as expected.")
+}
+
+func TestInvalidPatternErrors(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/file.txt", []byte("sample_string"), 0)
+	_, err := fileSystem.IsFileContainsText("/file.txt", "(unclosed")
+	assert.Truef(test, errors.Is(err, ErrInvalidPattern), "A bad pattern was expected to return ErrInvalidPattern.")
+	var syntaxError *syntax.Error
+	assert.Truef(test, errors.As(err, &syntaxError), "The underlying syntax error was expected to be available.")
+	err = fileSystem.FindReplaceInFile("/file.txt", "[a-", "b")
+	assert.Truef(test, errors.Is(err, ErrInvalidPattern), "A bad pattern was expected to return ErrInvalidPattern.")
+	_, err = fileSystem.GetListOfDirectoryContents("/", []string{"*"}, true, true)
+	assert.Truef(test, errors.Is(err, ErrInvalidPattern), "A bad pattern was expected to return ErrInvalidPattern.")
+	_, err = fileSystem.FindMatchingContent("/", []string{"+"}, true, true, true)
+	assert.Truef(test, errors.Is(err, ErrInvalidPattern), "A bad pattern was expected to return ErrInvalidPattern.")
+}
+
+func TestMoveAndCopyErrors(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/source.txt", []byte("sample_string"), 0)
+	fileSystem.WriteBytesToFile("/target.txt", []byte("sample_string"), 0)
+	fileSystem.CreateDirectory("/source_dir", 0)
+	fileSystem.CreateDirectory("/target_dir", 0)
+	err := fileSystem.MoveFile("/source.txt", "/target.txt")
+	assert.Truef(test, errors.Is(err, ErrDestinationExists), "Moving onto an existing file was expected to return ErrDestinationExists.")
+	var opError *OpError
+	assert.Truef(test, errors.As(err, &opError), "An OpError was expected to be returned.")
+	assert.Equalf(test, "/target.txt", opError.NewPath, "The destination recorded by the error was not as expected.")
+	err = fileSystem.MoveDirectories("/source_dir", "/target_dir")
+	assert.Truef(test, errors.Is(err, ErrDestinationExists), "Moving onto an existing directory was expected to return ErrDestinationExists.")
+	err = fileSystem.CopyFile("/source_dir", "/copy")
+	assert.Truef(test, errors.Is(err, ErrNotRegularFile), "Copying a directory was expected to return ErrNotRegularFile.")
+}
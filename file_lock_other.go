@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package filesystem
+
+/*
+lockFile is not available on this platform, so only goroutines within the
+same process are kept out by the caller.
+*/
+func lockFile(file File) error {
+	return nil
+}
+
+/*
+unlockFile is not available on this platform.
+*/
+func unlockFile(file File) error {
+	return nil
+}
@@ -0,0 +1,49 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package filesystem
+
+import (
+	"syscall"
+)
+
+/*
+lockFile allows you to take an exclusive advisory lock on a file so that
+other processes using the same lock file are kept out. Files which do not
+belong to the local file system are not locked.
+*/
+func lockFile(file File) error {
+	fileDescriptor, isLockable := getLockableFile(file)
+	if !isLockable {
+		return nil
+	}
+	for {
+		err := syscall.Flock(int(fileDescriptor.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+/*
+unlockFile allows you to release a lock taken with 'lockFile'.
+*/
+func unlockFile(file File) error {
+	fileDescriptor, isLockable := getLockableFile(file)
+	if !isLockable {
+		return nil
+	}
+	return syscall.Flock(int(fileDescriptor.Fd()), syscall.LOCK_UN)
+}
+
+/*
+getLockableFile allows you to obtain the operating system file behind a
+file from a backend, if there is one.
+*/
+func getLockableFile(file File) (interface{ Fd() uintptr }, bool) {
+	if rootedFile, isRootedFile := file.(*rootedFileType); isRootedFile {
+		file = rootedFile.File
+	}
+	lockableFile, isLockable := file.(interface{ Fd() uintptr })
+	return lockableFile, isLockable
+}
@@ -0,0 +1,126 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+/*
+fileMutexes holds one mutex per locked file so that lockers created more
+than once within the same process exclude each other, even on backends and
+platforms where file locks are not available.
+*/
+var fileMutexes = struct {
+	sync.Mutex
+	mutexes map[string]*sync.Mutex
+}{mutexes: map[string]*sync.Mutex{}}
+
+type fileLockerType struct {
+	mutex    *sync.Mutex
+	lockFile File
+}
+
+/*
+getFileLocker allows you to obtain a locker which excludes every other
+locker for the same file, both within this process and, where supported,
+in other processes. A '.lock' file is created alongside the file provided.
+*/
+func (shared *fileSystemType) getFileLocker(fileName string) (*fileLockerType, error) {
+	lockFile, err := shared.backend.OpenFile(fileName+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%p:%s", shared.backend, filepath.Clean(fileName))
+	fileMutexes.Lock()
+	defer fileMutexes.Unlock()
+	mutex, isFound := fileMutexes.mutexes[key]
+	if !isFound {
+		mutex = &sync.Mutex{}
+		fileMutexes.mutexes[key] = mutex
+	}
+	var locker fileLockerType
+	locker.mutex = mutex
+	locker.lockFile = lockFile
+	return &locker, nil
+}
+
+/*
+lock allows you to wait until both the in-process and inter-process locks
+are held.
+*/
+func (shared *fileLockerType) lock() error {
+	if shared.lockFile == nil {
+		return os.ErrClosed
+	}
+	shared.mutex.Lock()
+	if err := lockFile(shared.lockFile); err != nil {
+		shared.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+/*
+unlock allows you to release the locks taken by 'lock'.
+*/
+func (shared *fileLockerType) unlock() {
+	unlockFile(shared.lockFile)
+	shared.mutex.Unlock()
+}
+
+/*
+close allows you to release the lock file held by a locker.
+*/
+func (shared *fileLockerType) close() error {
+	if shared.lockFile == nil {
+		return nil
+	}
+	err := shared.lockFile.Close()
+	shared.lockFile = nil
+	return err
+}
+
+/*
+removeTornLine allows you to remove a partially written line left at the
+end of a file by a crash, returning the size of the file once it has been
+removed. This is only safe for files whose lines are written while locked,
+since an unterminated last line can then only be an interrupted write.
+*/
+func removeTornLine(file File) (int64, error) {
+	fileInfo, err := file.Stat()
+	if err != nil || fileInfo.Size() == 0 {
+		return 0, err
+	}
+	lastByte := make([]byte, 1)
+	if _, err := file.ReadAt(lastByte, fileInfo.Size()-1); err != nil {
+		return 0, err
+	}
+	if lastByte[0] == '\n' {
+		return fileInfo.Size(), nil
+	}
+	scanner := getReverseLineScanner(file, nil, fileInfo.Size(), ReverseLineScannerOptions{IsCarriageReturnKept: true})
+	scanner.Scan()
+	if scanner.GetError() != nil {
+		return 0, scanner.GetError()
+	}
+	if err := file.Truncate(scanner.GetOffset()); err != nil {
+		return 0, err
+	}
+	return scanner.GetOffset(), file.Sync()
+}
+
+/*
+syncDirectory allows you to make a rename within a directory durable. Not
+every backend or platform supports synchronizing a directory, so this is
+done on a best effort basis.
+*/
+func syncDirectory(backend Backend, directoryPath string) {
+	directory, err := backend.OpenFile(directoryPath, os.O_RDONLY, 0)
+	if err != nil {
+		return
+	}
+	directory.Sync()
+	directory.Close()
+}
@@ -0,0 +1,24 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package filesystem
+
+import (
+	"os"
+)
+
+/*
+getSystemFileOwner is not available on this platform, so the owner of a
+file on the local file system is never reported.
+*/
+func getSystemFileOwner(fileInfo os.FileInfo) (int, int, bool) {
+	return 0, 0, false
+}
+
+/*
+getSystemLinkCount is not available on this platform, so every file on the
+local file system is treated as having a single hard link.
+*/
+func getSystemLinkCount(fileInfo os.FileInfo) uint64 {
+	return 1
+}
@@ -0,0 +1,33 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package filesystem
+
+import (
+	"os"
+	"syscall"
+)
+
+/*
+getSystemFileOwner allows you to obtain the user and group which own a file
+on the local file system.
+*/
+func getSystemFileOwner(fileInfo os.FileInfo) (int, int, bool) {
+	fileStat, isFileStat := fileInfo.Sys().(*syscall.Stat_t)
+	if !isFileStat {
+		return 0, 0, false
+	}
+	return int(fileStat.Uid), int(fileStat.Gid), true
+}
+
+/*
+getSystemLinkCount allows you to obtain the number of hard links to a file
+on the local file system.
+*/
+func getSystemLinkCount(fileInfo os.FileInfo) uint64 {
+	fileStat, isFileStat := fileInfo.Sys().(*syscall.Stat_t)
+	if !isFileStat {
+		return 1
+	}
+	return uint64(fileStat.Nlink)
+}
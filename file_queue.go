@@ -0,0 +1,512 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+defaultCompactionThreshold is the number of acknowledged bytes at the start
+of a queue file which triggers compaction when no threshold is configured.
+*/
+const defaultCompactionThreshold = 1024 * 1024
+
+/*
+FileQueueOptions allows you to control how a file queue is maintained. In
+addition, the following information should be noted:
+
+- If you pass in a compaction threshold of '0', acknowledged items are
+removed from the start of the queue file once they take up 1 MiB.
+
+- The visibility timeout is how long a dequeued item may stay pending
+before it is handed out again, so that an item dequeued by a consumer which
+crashed is not lost. It should be longer than an item takes to process. If
+you pass in a visibility timeout of '0', dequeued items stay pending until
+they are acknowledged or released.
+*/
+type FileQueueOptions struct {
+	CompactionThreshold int64
+	VisibilityTimeout   time.Duration
+}
+
+/*
+FileQueueItem is an item obtained from a file queue. The ID uniquely
+identifies the item for the lifetime of the queue, even across compaction,
+and is used to acknowledge or release it.
+*/
+type FileQueueItem struct {
+	ID   int64
+	Text string
+}
+
+type fileQueueType struct {
+	fileSystem *fileSystemType
+	fileName   string
+	options    FileQueueOptions
+	locker     *fileLockerType
+}
+
+type fileQueuePendingItemType struct {
+	start               int64
+	end                 int64
+	isReleased          bool
+	leaseExpirationTime int64
+}
+
+/*
+fileQueueStateType is the persisted position of a queue. All offsets are
+logical offsets into the stream of every item ever enqueued, so that the
+base must be subtracted to obtain an offset within the queue file.
+*/
+type fileQueueStateType struct {
+	base             int64
+	head             int64
+	pendingItems     []fileQueuePendingItemType
+	compactionOffset int64
+}
+
+/*
+GetFileQueue allows you to obtain a durable first in, first out queue which
+stores one item per line in the file provided. In addition, the following
+information should be noted:
+
+- Alongside the queue file, a '.state' file records the position of the
+queue and a '.lock' file is used to lock the queue between processes.
+
+- Dequeuing an item only moves a pointer, so it takes the same time no
+matter how large the queue is. Acknowledged items are removed from the
+queue file in bulk once the compaction threshold is reached.
+
+- Every dequeued item is handed out exactly once while its visibility
+timeout lasts. It remains pending until it is acknowledged, released so
+that it can be dequeued again, or any visibility timeout configured
+expires, in which case it is handed out again as though it had been
+released.
+
+- Every change is synchronized to disk before the method making it returns,
+and an interrupted compaction is completed the next time the queue is used.
+
+- Locking between processes is only available for queues on the local
+file system on Linux and macOS. Otherwise only queues within the same
+process are kept from interfering with each other.
+
+- The queue must be closed once you are finished with it.
+*/
+func (shared *fileSystemType) GetFileQueue(fileName string, options FileQueueOptions) (*fileQueueType, error) {
+	if options.CompactionThreshold <= 0 {
+		options.CompactionThreshold = defaultCompactionThreshold
+	}
+	locker, err := shared.getFileLocker(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var queue fileQueueType
+	queue.fileSystem = shared
+	queue.fileName = fileName
+	queue.options = options
+	queue.locker = locker
+	return &queue, nil
+}
+
+/*
+Close allows you to release the lock file held by a queue.
+*/
+func (shared *fileQueueType) Close() error {
+	return shared.locker.close()
+}
+
+/*
+Enqueue allows you to add an item to the end of the queue. Items can not
+contain a newline or be longer than 1 MiB, since they could not be read
+back.
+*/
+func (shared *fileQueueType) Enqueue(item string) error {
+	if strings.Contains(item, "\n") || len(item) > defaultMaximumLineLength {
+		return &OpError{Op: "enqueue", Path: shared.fileName, Err: ErrInvalidItem}
+	}
+	return shared.withLock("enqueue", func(state *fileQueueStateType) (bool, error) {
+		file, err := shared.fileSystem.backend.OpenFile(shared.fileName, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return false, err
+		}
+		defer file.Close()
+		fileSize, err := removeTornLine(file)
+		if err != nil {
+			return false, err
+		}
+		if _, err := file.WriteAt([]byte(item+"\n"), fileSize); err != nil {
+			return false, err
+		}
+		return false, file.Sync()
+	})
+}
+
+/*
+Peek allows you to obtain the next item that would be dequeued without
+removing it from the queue. If no items are available, 'ErrQueueEmpty' is
+returned.
+*/
+func (shared *fileQueueType) Peek() (FileQueueItem, error) {
+	var item FileQueueItem
+	err := shared.withLock("peek", func(state *fileQueueStateType) (bool, error) {
+		var err error
+		item, _, _, err = shared.getNextItem(state)
+		return false, err
+	})
+	return item, err
+}
+
+/*
+Dequeue allows you to take the next item from the queue. The item is handed
+out to no one else and stays pending until it is acknowledged with 'Ack',
+returned with 'Release' or any visibility timeout configured expires. If no
+items are available, 'ErrQueueEmpty' is returned.
+*/
+func (shared *fileQueueType) Dequeue() (FileQueueItem, error) {
+	var item FileQueueItem
+	err := shared.withLock("dequeue", func(state *fileQueueStateType) (bool, error) {
+		var end int64
+		var pendingIndex int
+		var err error
+		item, end, pendingIndex, err = shared.getNextItem(state)
+		if err != nil {
+			return false, err
+		}
+		var leaseExpirationTime int64
+		if shared.options.VisibilityTimeout > 0 {
+			leaseExpirationTime = time.Now().Add(shared.options.VisibilityTimeout).UnixNano()
+		}
+		if pendingIndex >= 0 {
+			state.pendingItems[pendingIndex].isReleased = false
+			state.pendingItems[pendingIndex].leaseExpirationTime = leaseExpirationTime
+			return true, nil
+		}
+		state.pendingItems = append(state.pendingItems, fileQueuePendingItemType{start: item.ID, end: end, leaseExpirationTime: leaseExpirationTime})
+		state.head = end
+		return true, nil
+	})
+	return item, err
+}
+
+/*
+Ack allows you to acknowledge that a dequeued item has been processed, so
+that it is permanently removed from the queue. An item whose visibility
+timeout has expired can still be acknowledged, even if it has since been
+handed out again.
+*/
+func (shared *fileQueueType) Ack(item FileQueueItem) error {
+	return shared.withLock("ack", func(state *fileQueueStateType) (bool, error) {
+		index := state.getPendingIndex(item.ID)
+		if index < 0 || state.pendingItems[index].isReleased {
+			return false, ErrItemNotPending
+		}
+		state.pendingItems = append(state.pendingItems[:index], state.pendingItems[index+1:]...)
+		return true, nil
+	})
+}
+
+/*
+Release allows you to return a dequeued item to the queue without
+processing it. Released items are dequeued again before any new items.
+*/
+func (shared *fileQueueType) Release(item FileQueueItem) error {
+	return shared.withLock("release", func(state *fileQueueStateType) (bool, error) {
+		index := state.getPendingIndex(item.ID)
+		if index < 0 || state.pendingItems[index].isReleased {
+			return false, ErrItemNotPending
+		}
+		state.pendingItems[index].isReleased = true
+		return true, nil
+	})
+}
+
+/*
+Len allows you to obtain the number of items waiting to be dequeued. Items
+which are pending acknowledgement are not included, unless their visibility
+timeout has expired. Since the remaining items must be counted, this takes
+time proportional to their size.
+*/
+func (shared *fileQueueType) Len() (int64, error) {
+	var itemCount int64
+	err := shared.withLock("len", func(state *fileQueueStateType) (bool, error) {
+		currentTime := time.Now()
+		for _, pendingItem := range state.pendingItems {
+			if pendingItem.isAvailable(currentTime) {
+				itemCount++
+			}
+		}
+		file, err := shared.openForReading()
+		if file == nil {
+			return false, err
+		}
+		defer file.Close()
+		scanner := getLineScanner(file, nil, LineScannerOptions{StartOffset: state.head - state.base, IsCarriageReturnKept: true})
+		for scanner.Scan() && scanner.isDelimited {
+			itemCount++
+		}
+		return false, scanner.GetError()
+	})
+	return itemCount, err
+}
+
+/*
+withLock allows you to run an operation while holding both the in-process
+and inter-process locks for the queue. The operation receives the current
+state and reports whether it changed it, in which case the state is saved
+and the queue is compacted if needed.
+*/
+func (shared *fileQueueType) withLock(operation string, action func(state *fileQueueStateType) (bool, error)) error {
+	if err := shared.locker.lock(); err != nil {
+		return &OpError{Op: operation, Path: shared.fileName, Err: err}
+	}
+	defer shared.locker.unlock()
+	state, err := shared.loadState()
+	if err == nil {
+		var isChanged bool
+		isChanged, err = action(&state)
+		if err == nil && isChanged {
+			err = shared.saveState(state)
+		}
+		if err == nil && isChanged {
+			err = shared.compact(state)
+		}
+	}
+	if err != nil {
+		if _, isOpError := err.(*OpError); !isOpError {
+			err = &OpError{Op: operation, Path: shared.fileName, Err: err}
+		}
+	}
+	return err
+}
+
+/*
+getNextItem allows you to find the item which the next dequeue would hand
+out, along with the logical offset at which it ends. Pending items which
+are released or whose visibility timeout has expired come first, in which
+case the index of the pending item is returned too.
+*/
+func (shared *fileQueueType) getNextItem(state *fileQueueStateType) (FileQueueItem, int64, int, error) {
+	start, end, pendingIndex := state.head, int64(-1), -1
+	currentTime := time.Now()
+	for index, pendingItem := range state.pendingItems {
+		if pendingItem.isAvailable(currentTime) && (pendingIndex < 0 || pendingItem.start < start) {
+			start, end, pendingIndex = pendingItem.start, pendingItem.end, index
+		}
+	}
+	file, err := shared.openForReading()
+	if file == nil {
+		if err == nil {
+			err = ErrQueueEmpty
+		}
+		return FileQueueItem{}, 0, -1, err
+	}
+	defer file.Close()
+	scanner := getLineScanner(file, nil, LineScannerOptions{StartOffset: start - state.base, IsCarriageReturnKept: true})
+	if !scanner.Scan() || !scanner.isDelimited {
+		if scanner.GetError() != nil {
+			return FileQueueItem{}, 0, -1, scanner.GetError()
+		}
+		return FileQueueItem{}, 0, -1, ErrQueueEmpty
+	}
+	if end < 0 {
+		end = scanner.GetNextOffset() + state.base
+	}
+	return FileQueueItem{ID: start, Text: scanner.GetText()}, end, pendingIndex, nil
+}
+
+/*
+openForReading allows you to open the queue file for reading. If the queue
+file does not exist yet, no file and no error are returned.
+*/
+func (shared *fileQueueType) openForReading() (File, error) {
+	file, err := shared.fileSystem.backend.OpenFile(shared.fileName, os.O_RDONLY, 0)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return file, err
+}
+
+/*
+compact allows you to remove acknowledged items from the start of the queue
+file once they reach the compaction threshold. The steps are ordered so that
+a crash at any point leaves the queue recoverable by 'loadState':
+
+- The remaining items are written and synchronized to a '.compact' file.
+
+- The state is saved with the amount being removed recorded.
+
+- The '.compact' file replaces the queue file.
+
+- The state is saved with its offsets adjusted and the record removed.
+*/
+func (shared *fileQueueType) compact(state fileQueueStateType) error {
+	acknowledgedOffset := state.head
+	for _, pendingItem := range state.pendingItems {
+		if pendingItem.start < acknowledgedOffset {
+			acknowledgedOffset = pendingItem.start
+		}
+	}
+	removedSize := acknowledgedOffset - state.base
+	if removedSize < shared.options.CompactionThreshold {
+		return nil
+	}
+	backend := shared.fileSystem.backend
+	source, err := backend.OpenFile(shared.fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := backend.OpenFile(shared.fileName+".compact", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(target, io.NewSectionReader(source, removedSize, 1<<62))
+	if err == nil {
+		err = target.Sync()
+	}
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	state.compactionOffset = removedSize
+	if err := shared.saveState(state); err != nil {
+		return err
+	}
+	return shared.finishCompaction(state)
+}
+
+/*
+finishCompaction allows you to complete a compaction whose state has
+already been saved, either straight away or after a crash.
+*/
+func (shared *fileQueueType) finishCompaction(state fileQueueStateType) error {
+	backend := shared.fileSystem.backend
+	if _, err := backend.Lstat(shared.fileName + ".compact"); err == nil {
+		if err := backend.Rename(shared.fileName+".compact", shared.fileName); err != nil {
+			return err
+		}
+		syncDirectory(backend, filepath.Dir(shared.fileName))
+	}
+	state.base += state.compactionOffset
+	state.compactionOffset = 0
+	return shared.saveState(state)
+}
+
+/*
+loadState allows you to read the persisted state of the queue, completing
+or cleaning up after any compaction which was interrupted.
+*/
+func (shared *fileQueueType) loadState() (fileQueueStateType, error) {
+	var state fileQueueStateType
+	stateContents, err := shared.fileSystem.readFile(shared.fileName + ".state")
+	if err != nil && !os.IsNotExist(err) {
+		return state, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(stateContents))
+	for scanner.Scan() {
+		var pendingItem fileQueuePendingItemType
+		var key string
+		fields := scanner.Text()
+		if _, err := fmt.Sscan(fields, &key); err != nil {
+			continue
+		}
+		switch key {
+		case "base":
+			_, err = fmt.Sscan(fields, &key, &state.base)
+		case "head":
+			_, err = fmt.Sscan(fields, &key, &state.head)
+		case "compaction":
+			_, err = fmt.Sscan(fields, &key, &state.compactionOffset)
+		case "pending":
+			_, err = fmt.Sscan(fields, &key, &pendingItem.start, &pendingItem.end, &pendingItem.isReleased, &pendingItem.leaseExpirationTime)
+			state.pendingItems = append(state.pendingItems, pendingItem)
+		}
+		if err != nil {
+			return state, fmt.Errorf("corrupt queue state '%s': %w", fields, err)
+		}
+	}
+	if state.compactionOffset > 0 {
+		if err := shared.finishCompaction(state); err != nil {
+			return state, err
+		}
+		state.base += state.compactionOffset
+		state.compactionOffset = 0
+	} else {
+		shared.fileSystem.backend.Remove(shared.fileName + ".compact")
+	}
+	return state, nil
+}
+
+/*
+saveState allows you to atomically replace the persisted state of the
+queue. The new state is synchronized to disk before it replaces the old one.
+*/
+func (shared *fileQueueType) saveState(state fileQueueStateType) error {
+	var stateContents strings.Builder
+	fmt.Fprintf(&stateContents, "base %d\nhead %d\n", state.base, state.head)
+	if state.compactionOffset > 0 {
+		fmt.Fprintf(&stateContents, "compaction %d\n", state.compactionOffset)
+	}
+	sort.Slice(state.pendingItems, func(first int, second int) bool {
+		return state.pendingItems[first].start < state.pendingItems[second].start
+	})
+	for _, pendingItem := range state.pendingItems {
+		fmt.Fprintf(&stateContents, "pending %d %d %t %d\n", pendingItem.start, pendingItem.end, pendingItem.isReleased, pendingItem.leaseExpirationTime)
+	}
+	backend := shared.fileSystem.backend
+	temporaryFileName := shared.fileName + ".state.tmp"
+	file, err := backend.OpenFile(temporaryFileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(stateContents.String())
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := backend.Rename(temporaryFileName, shared.fileName+".state"); err != nil {
+		return err
+	}
+	syncDirectory(backend, filepath.Dir(shared.fileName))
+	return nil
+}
+
+/*
+getPendingIndex allows you to find the pending item with the ID provided,
+returning '-1' if there is none.
+*/
+func (shared *fileQueueStateType) getPendingIndex(id int64) int {
+	for index, pendingItem := range shared.pendingItems {
+		if pendingItem.start == id {
+			return index
+		}
+	}
+	return -1
+}
+
+/*
+isAvailable allows you to check if a pending item may be dequeued again,
+either because it was released or because its visibility timeout expired.
+An item dequeued without a visibility timeout has no expiration time and
+never expires.
+*/
+func (shared fileQueuePendingItemType) isAvailable(currentTime time.Time) bool {
+	if shared.isReleased {
+		return true
+	}
+	return shared.leaseExpirationTime > 0 && currentTime.UnixNano() >= shared.leaseExpirationTime
+}
@@ -0,0 +1,145 @@
+package filesystem
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileQueue(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	queue, err := fileSystem.GetFileQueue("/queue.txt", FileQueueOptions{CompactionThreshold: 10})
+	assert.NoErrorf(test, err, "An error was not expected when opening a queue.")
+	defer queue.Close()
+	_, err = queue.Dequeue()
+	assert.Truef(test, errors.Is(err, ErrQueueEmpty), "An empty queue was expected to return ErrQueueEmpty.")
+	assert.Truef(test, errors.Is(queue.Enqueue("Two\nlines"), ErrInvalidItem), "An item containing a newline was expected to be rejected.")
+	assert.Truef(test, errors.Is(queue.Enqueue(strings.Repeat("x", defaultMaximumLineLength+1)), ErrInvalidItem), "An item too long to be dequeued was expected to be rejected.")
+	for _, item := range []string{"First", "Second", "Third"} {
+		assert.NoErrorf(test, queue.Enqueue(item), "An error was not expected when enqueuing an item.")
+	}
+	itemCount, _ := queue.Len()
+	assert.Equalf(test, int64(3), itemCount, "The queue length was not as expected.")
+	item, _ := queue.Peek()
+	assert.Equalf(test, "First", item.Text, "Peek was expected to return the first item.")
+	first, _ := queue.Dequeue()
+	second, _ := queue.Dequeue()
+	assert.Equalf(test, []string{"First", "Second"}, []string{first.Text, second.Text}, "Items were expected to be dequeued in order.")
+	assert.NoErrorf(test, queue.Release(first), "An error was not expected when releasing an item.")
+	item, _ = queue.Dequeue()
+	assert.Equalf(test, first, item, "A released item was expected to be dequeued again first.")
+	assert.NoErrorf(test, queue.Ack(first), "An error was not expected when acknowledging an item.")
+	assert.Truef(test, errors.Is(queue.Ack(first), ErrItemNotPending), "Acknowledging an item twice was expected to fail.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/queue.txt")
+	assert.Equalf(test, "First\nSecond\nThird\n", string(fileContents), "Compaction was not expected while an earlier item is pending.")
+	assert.NoErrorf(test, queue.Ack(second), "An error was not expected when acknowledging an item.")
+	fileContents, _ = fileSystem.GetFileContentsAsBytes("/queue.txt")
+	assert.Equalf(test, "Third\n", string(fileContents), "Acknowledged items were expected to be compacted away.")
+	item, _ = queue.Dequeue()
+	assert.Equalf(test, FileQueueItem{ID: 13, Text: "Third"}, item, "Item IDs were expected to be stable across compaction.")
+	reopenedQueue, _ := fileSystem.GetFileQueue("/queue.txt", FileQueueOptions{})
+	defer reopenedQueue.Close()
+	assert.NoErrorf(test, reopenedQueue.Ack(item), "Pending items were expected to survive reopening the queue.")
+	itemCount, _ = reopenedQueue.Len()
+	assert.Equalf(test, int64(0), itemCount, "The queue was expected to be empty.")
+	reopenedQueue.Enqueue("Carriage return\r")
+	item, _ = reopenedQueue.Dequeue()
+	assert.Equalf(test, "Carriage return\r", item.Text, "A trailing carriage return was expected to be kept.")
+}
+
+func TestFileQueueRecovery(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/queue.txt", []byte("Done\nNext\nTorn"), 0)
+	fileSystem.WriteBytesToFile("/queue.txt.compact", []byte("Next\nTorn"), 0)
+	fileSystem.WriteBytesToFile("/queue.txt.state", []byte("base 0\nhead 5\ncompaction 5\n"), 0)
+	queue, _ := fileSystem.GetFileQueue("/queue.txt", FileQueueOptions{})
+	defer queue.Close()
+	assert.NoErrorf(test, queue.Enqueue("Last"), "An error was not expected when enqueuing an item.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/queue.txt")
+	assert.Equalf(test, "Next\nLast\n", string(fileContents), "The interrupted compaction was expected to finish and the torn item removed.")
+	assert.Falsef(test, fileSystem.IsFileExists("/queue.txt.compact"), "The compaction file was expected to be removed.")
+	item, _ := queue.Dequeue()
+	assert.Equalf(test, FileQueueItem{ID: 5, Text: "Next"}, item, "The queue was expected to resume where it left off.")
+}
+
+func TestFileQueueVisibilityTimeout(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	options := FileQueueOptions{CompactionThreshold: 10, VisibilityTimeout: 20 * time.Millisecond}
+	queue, _ := fileSystem.GetFileQueue("/queue.txt", options)
+	for _, item := range []string{"Orphaned", "Second"} {
+		queue.Enqueue(item)
+	}
+	orphanedItem, _ := queue.Dequeue()
+	queue.Close()
+	reopenedQueue, _ := fileSystem.GetFileQueue("/queue.txt", options)
+	defer reopenedQueue.Close()
+	item, _ := reopenedQueue.Dequeue()
+	assert.Equalf(test, "Second", item.Text, "An item was not expected to be handed out again before its visibility timeout.")
+	reopenedQueue.Ack(item)
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/queue.txt")
+	assert.Equalf(test, "Orphaned\nSecond\n", string(fileContents), "Compaction was not expected while an earlier item is pending.")
+	time.Sleep(30 * time.Millisecond)
+	itemCount, _ := reopenedQueue.Len()
+	assert.Equalf(test, int64(1), itemCount, "An item whose visibility timeout expired was expected to be counted.")
+	item, err := reopenedQueue.Dequeue()
+	assert.NoErrorf(test, err, "An error was not expected when dequeuing an expired item.")
+	assert.Equalf(test, orphanedItem, item, "An item whose visibility timeout expired was expected to be handed out again.")
+	assert.NoErrorf(test, reopenedQueue.Ack(item), "An error was not expected when acknowledging an item handed out again.")
+	fileContents, _ = fileSystem.GetFileContentsAsBytes("/queue.txt")
+	assert.Equalf(test, "", string(fileContents), "Compaction was expected to proceed once the orphaned item was acknowledged.")
+	queue, _ = fileSystem.GetFileQueue("/unleased.txt", FileQueueOptions{})
+	defer queue.Close()
+	queue.Enqueue("Unleased")
+	queue.Dequeue()
+	time.Sleep(30 * time.Millisecond)
+	_, err = queue.Dequeue()
+	assert.Truef(test, errors.Is(err, ErrQueueEmpty), "An item dequeued without a visibility timeout was not expected to be handed out again.")
+}
+
+func TestFileQueueExactlyOnce(test *testing.T) {
+	fileName := "/tmp/queue.txt"
+	for _, suffix := range []string{"", ".state", ".lock"} {
+		DeleteFile(fileName + suffix)
+	}
+	queue, _ := GetFileQueue(fileName, FileQueueOptions{CompactionThreshold: 64})
+	for index := 0; index < 200; index++ {
+		queue.Enqueue(strconv.Itoa(index))
+	}
+	queue.Close()
+	var mutex sync.Mutex
+	var waitGroup sync.WaitGroup
+	var dequeuedItems []int
+	for worker := 0; worker < 4; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			workerQueue, _ := GetFileQueue(fileName, FileQueueOptions{CompactionThreshold: 64})
+			defer workerQueue.Close()
+			for {
+				item, err := workerQueue.Dequeue()
+				if err != nil {
+					return
+				}
+				value, _ := strconv.Atoi(item.Text)
+				mutex.Lock()
+				dequeuedItems = append(dequeuedItems, value)
+				mutex.Unlock()
+				workerQueue.Ack(item)
+			}
+		}()
+	}
+	waitGroup.Wait()
+	sort.Ints(dequeuedItems)
+	assert.Equalf(test, 200, len(dequeuedItems), "Every item was expected to be dequeued exactly once.")
+	for index, value := range dequeuedItems {
+		if value != index {
+			assert.Failf(test, "An item was dequeued more than once.", "Item %d was followed by %d.", index, value)
+			break
+		}
+	}
+}
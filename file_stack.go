@@ -0,0 +1,158 @@
+package filesystem
+
+import (
+	"os"
+	"strings"
+)
+
+type fileStackType struct {
+	fileSystem *fileSystemType
+	fileName   string
+	locker     *fileLockerType
+}
+
+/*
+GetFileStack allows you to obtain a durable last in, first out stack which
+stores one item per line in the file provided. In addition, the following
+information should be noted:
+
+- Items are pushed by appending them to the file and popped by reading the
+last line and truncating it away, so the file is never rewritten.
+
+- A '.lock' file is created alongside the stack file and is used to lock the
+stack between processes, where supported, in the same way as 'GetFileQueue'.
+
+- Every change is synchronized to disk before the method making it returns.
+If a push is interrupted by a crash, the partially written item is removed
+the next time the stack is used. If a pop is interrupted before the file is
+truncated, the item remains on the stack.
+
+- The stack must be closed once you are finished with it.
+*/
+func (shared *fileSystemType) GetFileStack(fileName string) (*fileStackType, error) {
+	locker, err := shared.getFileLocker(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var stack fileStackType
+	stack.fileSystem = shared
+	stack.fileName = fileName
+	stack.locker = locker
+	return &stack, nil
+}
+
+/*
+Close allows you to release the lock file held by a stack.
+*/
+func (shared *fileStackType) Close() error {
+	return shared.locker.close()
+}
+
+/*
+Push allows you to add an item to the top of the stack. Items can not
+contain a newline or be longer than 1 MiB, since they could not be read
+back.
+*/
+func (shared *fileStackType) Push(item string) error {
+	if strings.Contains(item, "\n") || len(item) > defaultMaximumLineLength {
+		return &OpError{Op: "push", Path: shared.fileName, Err: ErrInvalidItem}
+	}
+	return shared.withFile("push", func(file File, fileSize int64) error {
+		if _, err := file.WriteAt([]byte(item+"\n"), fileSize); err != nil {
+			return err
+		}
+		return file.Sync()
+	})
+}
+
+/*
+Pop allows you to remove and return the item at the top of the stack. If
+the stack has no items, 'ErrStackEmpty' is returned.
+*/
+func (shared *fileStackType) Pop() (string, error) {
+	var item string
+	err := shared.withFile("pop", func(file File, fileSize int64) error {
+		var offset int64
+		var err error
+		item, offset, err = getTopItem(file, fileSize)
+		if err != nil {
+			return err
+		}
+		if err := file.Truncate(offset); err != nil {
+			return err
+		}
+		return file.Sync()
+	})
+	return item, err
+}
+
+/*
+Peek allows you to obtain the item at the top of the stack without removing
+it. If the stack has no items, 'ErrStackEmpty' is returned.
+*/
+func (shared *fileStackType) Peek() (string, error) {
+	var item string
+	err := shared.withFile("peek", func(file File, fileSize int64) error {
+		var err error
+		item, _, err = getTopItem(file, fileSize)
+		return err
+	})
+	return item, err
+}
+
+/*
+Len allows you to obtain the number of items on the stack. Since every item
+must be counted, this takes time proportional to the size of the stack.
+*/
+func (shared *fileStackType) Len() (int64, error) {
+	var itemCount int64
+	err := shared.withFile("len", func(file File, fileSize int64) error {
+		var err error
+		itemCount, err = countLinesFromScanner(getLineScanner(file, nil, LineScannerOptions{}))
+		return err
+	})
+	return itemCount, err
+}
+
+/*
+withFile allows you to run an operation against the stack file while the
+stack is locked. Any partially written item left by a crash is removed
+before the operation is given the file and its size.
+*/
+func (shared *fileStackType) withFile(operation string, action func(file File, fileSize int64) error) error {
+	err := shared.locker.lock()
+	if err == nil {
+		defer shared.locker.unlock()
+		var file File
+		file, err = shared.fileSystem.backend.OpenFile(shared.fileName, os.O_RDWR|os.O_CREATE, 0644)
+		if err == nil {
+			defer file.Close()
+			var fileSize int64
+			fileSize, err = removeTornLine(file)
+			if err == nil {
+				err = action(file, fileSize)
+			}
+		}
+	}
+	if err != nil {
+		if _, isOpError := err.(*OpError); !isOpError {
+			err = &OpError{Op: operation, Path: shared.fileName, Err: err}
+		}
+	}
+	return err
+}
+
+/*
+getTopItem allows you to read the last line of a stack file along with the
+offset at which it starts.
+*/
+func getTopItem(file File, fileSize int64) (string, int64, error) {
+	scanner := getReverseLineScanner(file, nil, fileSize, ReverseLineScannerOptions{IsCarriageReturnKept: true})
+	if !scanner.Scan() {
+		if scanner.GetError() != nil {
+			return "", 0, scanner.GetError()
+		}
+		return "", 0, ErrStackEmpty
+	}
+	return scanner.GetText(), scanner.GetOffset(), nil
+}
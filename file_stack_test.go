@@ -0,0 +1,97 @@
+package filesystem
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFileStack(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	stack, err := fileSystem.GetFileStack("/stack.txt")
+	assert.NoErrorf(test, err, "An error was not expected when opening a stack.")
+	defer stack.Close()
+	_, err = stack.Pop()
+	assert.Truef(test, errors.Is(err, ErrStackEmpty), "An empty stack was expected to return ErrStackEmpty.")
+	assert.Truef(test, errors.Is(stack.Push("Two\nlines"), ErrInvalidItem), "An item containing a newline was expected to be rejected.")
+	assert.Truef(test, errors.Is(stack.Push(strings.Repeat("x", defaultMaximumLineLength+1)), ErrInvalidItem), "An item too long to be popped was expected to be rejected.")
+	longItem := strings.Repeat("x", defaultMaximumLineLength)
+	stack.Push(longItem)
+	item, err := stack.Pop()
+	assert.NoErrorf(test, err, "An error was not expected when popping the longest item allowed.")
+	assert.Equalf(test, len(longItem), len(item), "The longest item allowed was expected to be popped whole.")
+	for _, item := range []string{"First", "", "Third"} {
+		assert.NoErrorf(test, stack.Push(item), "An error was not expected when pushing an item.")
+	}
+	itemCount, _ := stack.Len()
+	assert.Equalf(test, int64(3), itemCount, "The stack length was not as expected.")
+	item, _ = stack.Peek()
+	assert.Equalf(test, "Third", item, "Peek was expected to return the last item pushed.")
+	var poppedItems []string
+	for {
+		item, err := stack.Pop()
+		if err != nil {
+			break
+		}
+		poppedItems = append(poppedItems, item)
+	}
+	assert.Equalf(test, []string{"Third", "", "First"}, poppedItems, "Items were expected to be popped in reverse order.")
+	fileSize, _ := fileSystem.GetFileSize("/stack.txt")
+	assert.Equalf(test, int64(0), fileSize, "The stack file was expected to be empty once every item was popped.")
+}
+
+func TestFileStackRecovery(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/stack.txt", []byte("First\nSecond\nTor"), 0)
+	stack, _ := fileSystem.GetFileStack("/stack.txt")
+	defer stack.Close()
+	item, err := stack.Pop()
+	assert.NoErrorf(test, err, "An error was not expected when popping an item.")
+	assert.Equalf(test, "Second", item, "A partially pushed item was expected to be discarded.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/stack.txt")
+	assert.Equalf(test, "First\n", string(fileContents), "The stack file was not as expected after recovery.")
+}
+
+func TestFileStackConcurrentPop(test *testing.T) {
+	fileName := "/tmp/stack.txt"
+	DeleteFile(fileName)
+	stack, _ := GetFileStack(fileName)
+	for index := 0; index < 100; index++ {
+		stack.Push(strconv.Itoa(index))
+	}
+	stack.Close()
+	var mutex sync.Mutex
+	var waitGroup sync.WaitGroup
+	var poppedItems []int
+	for worker := 0; worker < 4; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			workerStack, _ := GetFileStack(fileName)
+			defer workerStack.Close()
+			for {
+				item, err := workerStack.Pop()
+				if err != nil {
+					return
+				}
+				value, _ := strconv.Atoi(item)
+				mutex.Lock()
+				poppedItems = append(poppedItems, value)
+				mutex.Unlock()
+			}
+		}()
+	}
+	waitGroup.Wait()
+	sort.Ints(poppedItems)
+	assert.Equalf(test, 100, len(poppedItems), "Every item was expected to be popped exactly once.")
+	for index, value := range poppedItems {
+		if value != index {
+			assert.Failf(test, "An item was popped more than once.", "Item %d was followed by %d.", index, value)
+			break
+		}
+	}
+}
@@ -2,31 +2,100 @@ package filesystem
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 )
 
+type fileSystemType struct {
+	backend Backend
+}
+
 type fileInstanceType struct {
-	fileDescriptor *os.File
+	fileSystem     *fileSystemType
+	fileDescriptor File
+	fileName       string
+	flag           int
+	perm           os.FileMode
+}
+
+/*
+OpenOptions allows you to control how a file instance opens a file. In
+addition, the following information should be noted:
+
+- When neither 'IsReadOnly' nor 'IsWriteOnly' is set, the file is opened
+for both reading and writing.
+
+- 'IsExclusive' creates the file and fails if it already exists.
+
+- 'IsSymlinkRejected' fails the open if the final path element is a
+symbolic link instead of following it.
+
+- If you pass in a permissions value of '0', the default value of 744 will
+be used for newly created files.
+*/
+type OpenOptions struct {
+	IsReadOnly        bool
+	IsWriteOnly       bool
+	IsCreated         bool
+	IsExclusive       bool
+	IsTruncated       bool
+	IsAppended        bool
+	IsSynchronous     bool
+	IsDataSynchronous bool
+	IsSymlinkRejected bool
+	Permissions       int
+}
+
+/*
+GetFileSystem allows you to obtain a file system instance which performs
+all of its operations against the backend provided. Every package level
+helper is also available as a method on the returned instance.
+*/
+func GetFileSystem(backend Backend) *fileSystemType {
+	var fileSystem fileSystemType
+	fileSystem.backend = backend
+	return &fileSystem
+}
+
+/*
+GetBackend allows you to obtain the backend a file system instance is
+bound to.
+*/
+func (shared *fileSystemType) GetBackend() Backend {
+	return shared.backend
 }
 
 /*
 GetFileInstance allows you to obtain a file instance to work on.
 */
-func GetFileInstance() fileInstanceType {
+func (shared *fileSystemType) GetFileInstance() fileInstanceType {
 	var fileInstance fileInstanceType
+	fileInstance.fileSystem = shared
 	return fileInstance
 }
 
+/*
+getFileSystem allows you to obtain the file system a file instance is bound
+to. File instances which were declared directly fall back to the local
+file system.
+*/
+func (shared *fileInstanceType) getFileSystem() *fileSystemType {
+	if shared.fileSystem == nil {
+		shared.fileSystem = defaultFileSystem
+	}
+	return shared.fileSystem
+}
+
 /*
 Open allows you to access a file on the file system in the open state.
 */
@@ -35,30 +104,118 @@ func (shared *fileInstanceType) Open(fileName string, permissions int) error {
 		permissions = 0744
 	}
 	perm := os.FileMode(uint32(permissions))
-	file, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, perm)
+	return shared.open(fileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, perm)
+}
+
+/*
+OpenWithOptions allows you to access a file on the file system using the
+open mode described by the options provided.
+*/
+func (shared *fileInstanceType) OpenWithOptions(fileName string, options OpenOptions) error {
+	flag, err := getOpenFlags(options)
+	if err != nil {
+		return &OpError{Op: "open", Path: fileName, Err: err}
+	}
+	if options.IsSymlinkRejected {
+		fileInfo, err := shared.getFileSystem().backend.Lstat(fileName)
+		if err == nil && fileInfo.Mode()&os.ModeSymlink != 0 {
+			return &OpError{Op: "open", Path: fileName, Err: ErrIsSymlink}
+		}
+	}
+	permissions := options.Permissions
+	if permissions == 0 {
+		permissions = 0744
+	}
+	return shared.open(fileName, flag, os.FileMode(uint32(permissions)))
+}
+
+/*
+getOpenFlags allows you to convert a set of open options into the flags
+understood by a backend.
+*/
+func getOpenFlags(options OpenOptions) (int, error) {
+	if options.IsReadOnly && options.IsWriteOnly {
+		return 0, ErrInvalidOptions
+	}
+	if options.IsReadOnly && (options.IsTruncated || options.IsAppended || options.IsCreated || options.IsExclusive) {
+		return 0, ErrInvalidOptions
+	}
+	flag := os.O_RDWR
+	if options.IsReadOnly {
+		flag = os.O_RDONLY
+	}
+	if options.IsWriteOnly {
+		flag = os.O_WRONLY
+	}
+	if options.IsCreated {
+		flag |= os.O_CREATE
+	}
+	if options.IsExclusive {
+		flag |= os.O_CREATE | os.O_EXCL
+	}
+	if options.IsTruncated {
+		flag |= os.O_TRUNC
+	}
+	if options.IsAppended {
+		flag |= os.O_APPEND
+	}
+	if options.IsSynchronous {
+		flag |= os.O_SYNC
+	}
+	if options.IsDataSynchronous {
+		flag |= dataSyncFlag
+	}
+	if options.IsSymlinkRejected {
+		flag |= noFollowFlag
+	}
+	return flag, nil
+}
+
+/*
+open allows you to access a file on the file system using the exact open
+flags provided.
+*/
+func (shared *fileInstanceType) open(fileName string, flag int, perm os.FileMode) error {
+	file, err := shared.getFileSystem().backend.OpenFile(fileName, flag, perm)
 	if err != nil {
 		return err
 	}
 	shared.fileDescriptor = file
+	shared.fileName = fileName
+	shared.flag = flag
+	shared.perm = perm
 	return err
 }
 
 /*
-Close allows you to close a file which has already been opened.
+checkOpen allows you to verify that a file instance currently has a file
+open before an operation is performed on it.
 */
-func (shared *fileInstanceType) Close() {
+func (shared *fileInstanceType) checkOpen(operation string) error {
 	if shared.fileDescriptor == nil {
-		panic("There is no open file to close.")
+		return &OpError{Op: operation, Err: ErrNotOpen}
 	}
-	shared.fileDescriptor.Close()
+	return nil
+}
+
+/*
+Close allows you to close a file which has already been opened.
+*/
+func (shared *fileInstanceType) Close() error {
+	if err := shared.checkOpen("close"); err != nil {
+		return err
+	}
+	err := shared.fileDescriptor.Close()
+	shared.fileDescriptor = nil
+	return err
 }
 
 /*
 WriteBytes allows you to add an arbitrary number of bytes to an open file.
 */
 func (shared *fileInstanceType) WriteBytes(bytes []byte) error {
-	if shared.fileDescriptor == nil {
-		panic("There is no open file for writing bytes to.")
+	if err := shared.checkOpen("write"); err != nil {
+		return err
 	}
 	_, err := shared.fileDescriptor.Write(bytes)
 	return err
@@ -69,8 +226,8 @@ WriteLine allows you to add string data to an open file as a line. A newline
 identifier will automatically be added to your string.
 */
 func (shared *fileInstanceType) WriteLine(lineToWrite string) error {
-	if shared.fileDescriptor == nil {
-		panic("There is no open file for writing lines to.")
+	if err := shared.checkOpen("write"); err != nil {
+		return err
 	}
 	err := shared.WriteString(lineToWrite + "\n")
 	return err
@@ -80,19 +237,95 @@ func (shared *fileInstanceType) WriteLine(lineToWrite string) error {
 WriteString allows you to add string data to an open file.
 */
 func (shared *fileInstanceType) WriteString(stringToWrite string) error {
-	if shared.fileDescriptor == nil {
-		panic("There is no open file for writing strings to.")
+	if err := shared.checkOpen("write"); err != nil {
+		return err
 	}
 	_, err := shared.fileDescriptor.WriteString(stringToWrite)
 	return err
 }
 
+/*
+Read allows you to read bytes from the current offset of an open file.
+*/
+func (shared *fileInstanceType) Read(buffer []byte) (int, error) {
+	if err := shared.checkOpen("read"); err != nil {
+		return 0, err
+	}
+	return shared.fileDescriptor.Read(buffer)
+}
+
+/*
+ReadAt allows you to read bytes from a specific offset of an open file
+without changing the current offset.
+*/
+func (shared *fileInstanceType) ReadAt(buffer []byte, offset int64) (int, error) {
+	if err := shared.checkOpen("read"); err != nil {
+		return 0, err
+	}
+	return shared.fileDescriptor.ReadAt(buffer, offset)
+}
+
+/*
+WriteAt allows you to write bytes at a specific offset of an open file
+without changing the current offset. Files opened in append mode do not
+support positional writes.
+*/
+func (shared *fileInstanceType) WriteAt(buffer []byte, offset int64) (int, error) {
+	if err := shared.checkOpen("write"); err != nil {
+		return 0, err
+	}
+	return shared.fileDescriptor.WriteAt(buffer, offset)
+}
+
+/*
+Seek allows you to change the current offset of an open file. The whence
+value is interpreted the same way as 'io.Seeker'.
+*/
+func (shared *fileInstanceType) Seek(offset int64, whence int) (int64, error) {
+	if err := shared.checkOpen("seek"); err != nil {
+		return 0, err
+	}
+	return shared.fileDescriptor.Seek(offset, whence)
+}
+
+/*
+Truncate allows you to change the size of an open file. If the file is
+extended, the new space is filled with zero bytes.
+*/
+func (shared *fileInstanceType) Truncate(size int64) error {
+	if err := shared.checkOpen("truncate"); err != nil {
+		return err
+	}
+	return shared.fileDescriptor.Truncate(size)
+}
+
+/*
+Stat allows you to obtain the attributes of an open file.
+*/
+func (shared *fileInstanceType) Stat() (os.FileInfo, error) {
+	if err := shared.checkOpen("stat"); err != nil {
+		return nil, err
+	}
+	return shared.fileDescriptor.Stat()
+}
+
+/*
+Sync allows you to flush all pending writes of an open file to stable
+storage.
+*/
+func (shared *fileInstanceType) Sync() error {
+	if err := shared.checkOpen("sync"); err != nil {
+		return err
+	}
+	return shared.fileDescriptor.Sync()
+}
+
 /*
 GetFileContents allows you to get the entire file contents.
 */
 func (shared *fileInstanceType) GetFileContents() ([]byte, error) {
-	if shared.fileDescriptor == nil {
-		panic("There is no open file for reading with.")
+	if err := shared.checkOpen("read"); err != nil {
+		return nil, err
 	}
 	fileInfo, err := shared.fileDescriptor.Stat()
 	if err != nil {
@@ -111,6 +344,9 @@ func (shared *fileInstanceType) GetFileContents() ([]byte, error) {
 GetFirstLine allows you to get the first line from a text file.
 */
 func (shared *fileInstanceType) GetFirstLine() ([]byte, error) {
+	if err := shared.checkOpen("read"); err != nil {
+		return nil, err
+	}
 	fileInfo, err := shared.fileDescriptor.Stat()
 	if err != nil {
 		return nil, err
@@ -132,13 +368,16 @@ func (shared *fileInstanceType) GetFirstLine() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return firstLine[:len(firstLine)-1], err // Remove trailing delimiter "\n"
+	return bytes.TrimSuffix(firstLine, []byte("\n")), nil // Remove trailing delimiter "\n"
 }
 
 /*
 RemoveFirstLine allows you to remove the first line from a text file.
 */
 func (shared *fileInstanceType) RemoveFirstLine() error {
+	if err := shared.checkOpen("write"); err != nil {
+		return err
+	}
 	fileInfo, err := shared.fileDescriptor.Stat()
 	if err != nil {
 		return err
@@ -188,7 +427,7 @@ regenerated.
 func GetDefaultCacheDirectory() (string, error) {
 	userHomeDir, err := os.UserHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("could not get user home directory: %v", err)
+		return "", fmt.Errorf("could not get user home directory: %w", err)
 	}
 	switch runtime.GOOS {
 	case "linux":
@@ -202,63 +441,88 @@ func GetDefaultCacheDirectory() (string, error) {
 }
 
 /*
-IsFileContainsText allows you to check if a file contains a matching string or not. Since this method loads the entire
-contents of a file into memory, it should only be used for smaller files.
+readFile allows you to read the entire contents of a file from the backend.
 */
-func IsFileContainsText(filename string, regexMatcher string) (bool, error) {
-	fileContents, err := ioutil.ReadFile(filename)
+func (shared *fileSystemType) readFile(fileName string) ([]byte, error) {
+	file, err := shared.backend.OpenFile(fileName, os.O_RDONLY, 0)
 	if err != nil {
-		return false, err
-	}
-	regex := regexp.MustCompile(regexMatcher)
-	match := regex.Find(fileContents)
-	if len(match) > 0 {
-		return true, err
+		return nil, err
 	}
-	return false, err
+	defer file.Close()
+	var buffer bytes.Buffer
+	_, err = io.Copy(&buffer, file)
+	return buffer.Bytes(), err
 }
 
 /*
-FindReplaceInFile allows you to find and replace text from within a file. Since this method loads the entire
-contents of a file into memory, it should only be used for smaller files.
+writeFile allows you to write data to a file on the backend, creating it
+with the permissions provided if it does not already exist.
 */
-func FindReplaceInFile(filename string, regexMatcher string, replacementValue string) error {
-	fileContents, err := ioutil.ReadFile(filename)
+func (shared *fileSystemType) writeFile(fileName string, data []byte, perm os.FileMode) error {
+	file, err := shared.backend.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
 	if err != nil {
 		return err
 	}
-	fileContentString := string(fileContents)
-	lines := strings.Split(fileContentString, "\n")
-	regex := regexp.MustCompile(regexMatcher)
-	for i, line := range lines {
-		lines[i] = regex.ReplaceAllString(line, replacementValue)
-	}
-	newContents := []byte(strings.Join(lines, "\n"))
-	err = ioutil.WriteFile(filename, newContents, 0)
-	if err != nil {
-		return err
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
 	}
-	return nil
+	return err
 }
 
 /*
-GetLastLineFromFile allows you to obtain the last line from a file.
+IsFileContainsText allows you to check if a file contains a matching string
+or not. The pattern is matched against the whole file, so matches can span
+several lines, but the file is streamed and reading stops at the first
+match.
 */
-func GetLastLineFromFile(fileName string) (string, error) {
-	fileContents, err := GetFileContents(fileName)
+func (shared *fileSystemType) IsFileContainsText(filename string, regexMatcher string) (bool, error) {
+	return shared.IsFileContainsTextWithOptions(filename, regexMatcher, ContainsTextOptions{IsMultiLine: true})
+}
+
+/*
+FindReplaceInFile allows you to find and replace text from within a file.
+The pattern is matched against one line at a time, and the file is streamed
+through a temporary file which atomically replaces the original, so it can
+be used on files of any size.
+*/
+func (shared *fileSystemType) FindReplaceInFile(filename string, regexMatcher string, replacementValue string) error {
+	_, err := shared.FindReplaceInFileWithOptions(filename, regexMatcher, replacementValue, FindReplaceOptions{})
+	return err
+}
+
+/*
+GetLastLineFromFile allows you to obtain the last line from a file. The file
+is read backwards from the end, so only the last line is ever loaded into
+memory. Trailing newlines are ignored, so the last line which is not empty
+is returned. A file with no such line has an empty last line. Lines of up
+to 1 GiB can be read.
+*/
+func (shared *fileSystemType) GetLastLineFromFile(fileName string) (string, error) {
+	scanner, err := shared.GetReverseLineScannerFromFile(fileName, ReverseLineScannerOptions{MaximumLineLength: maximumEditedLineLength})
 	if err != nil {
 		return "", err
 	}
-	arrayOfLines := strings.Split(string(fileContents), "\n")
-	return arrayOfLines[len(arrayOfLines)-1], err
+	defer scanner.Close()
+	for scanner.Scan() {
+		if lastLine := scanner.GetText(); lastLine != "" {
+			return lastLine, nil
+		}
+	}
+	return "", scanner.GetError()
 }
 
 /*
-GetFileContents allows you to get the entire contents of a file.
+GetFileContents allows you to get the entire contents of a file. In
+addition, the following information should be noted:
+
+- The file is opened read-only so that read-only backends can be used. As
+a result, an error is returned if the file does not exist, rather than an
+empty file being created for you.
 */
-func GetFileContents(fileName string) ([]byte, error) {
-	file := GetFileInstance()
-	err := file.Open(fileName, 0)
+func (shared *fileSystemType) GetFileContents(fileName string) ([]byte, error) {
+	file := shared.GetFileInstance()
+	err := file.open(fileName, os.O_RDONLY, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -273,8 +537,8 @@ func GetFileContents(fileName string) ([]byte, error) {
 /*
 RemoveFirstLineFromFile allows you to remove the first line from a file.
 */
-func RemoveFirstLineFromFile(fileName string) error {
-	file := GetFileInstance()
+func (shared *fileSystemType) RemoveFirstLineFromFile(fileName string) error {
+	file := shared.GetFileInstance()
 	err := file.Open(fileName, 0)
 	if err != nil {
 		return err
@@ -289,33 +553,12 @@ func RemoveFirstLineFromFile(fileName string) error {
 
 /*
 *
-DownloadFile allows you to download a file from the internet to your local.com file
-system.
+DownloadFile allows you to download a file from the internet to your local
+file system. The file is written to a '.part' file first, so an interrupted
+download can be resumed by calling this method again.
 */
-func DownloadFile(url string, filepath string, header http.Header) error {
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return err
-	}
-	if header == nil {
-		// Here we provide a fake 'user-agent' value so that our request looks like it's from a browser.
-		req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Fedora; Linux x86_64; rv:52.0) Gecko/20100101 Firefox/52.0")
-	} else {
-		req.Header = header
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	out, err := os.Create(filepath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-	_, err = io.Copy(out, resp.Body)
-	return err
+func (shared *fileSystemType) DownloadFile(url string, filepath string, header http.Header) error {
+	return shared.DownloadFileWithOptions(context.Background(), url, filepath, DownloadOptions{Header: header})
 }
 
 /*
@@ -324,26 +567,43 @@ CopyFile allows you to copy a file from one source location to a target
 destination location. In the event the operation could not be completed,
 an error is returned to the user.
 */
-func CopyFile(sourceFile string, destinationFile string) error {
-	sourceFileStat, err := os.Stat(sourceFile)
+func (shared *fileSystemType) CopyFile(sourceFile string, destinationFile string) error {
+	return shared.CopyFileWithOptions(sourceFile, destinationFile, CopyOptions{})
+}
+
+/*
+CopyOptions allows you to control how a file is copied. If you pass in a
+bytes per second value of '0', the copy is not rate limited.
+*/
+type CopyOptions struct {
+	BytesPerSecond int64
+}
+
+/*
+CopyFileWithOptions allows you to copy a file from one source location to a
+target destination location using the options provided, such as limiting
+the rate at which data is copied.
+*/
+func (shared *fileSystemType) CopyFileWithOptions(sourceFile string, destinationFile string, options CopyOptions) error {
+	sourceFileStat, err := shared.backend.Stat(sourceFile)
 	if err != nil {
 		return err
 	}
 	if !sourceFileStat.Mode().IsRegular() {
-		return fmt.Errorf("%s is not a regular file.", sourceFile)
+		return &OpError{Op: "copy", Path: sourceFile, NewPath: destinationFile, Err: ErrNotRegularFile}
 	}
-	source, err := os.Open(sourceFile)
-	defer source.Close()
+	source, err := shared.backend.OpenFile(sourceFile, os.O_RDONLY, 0)
 	if err != nil {
 		return err
 	}
+	defer source.Close()
 
-	destination, err := os.Create(destinationFile)
+	destination, err := shared.backend.OpenFile(destinationFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
 		return err
 	}
 	defer destination.Close()
-	_, err = io.Copy(destination, source)
+	_, err = io.Copy(destination, GetThrottledReader(source, options.BytesPerSecond))
 	return err
 }
 
@@ -358,12 +618,12 @@ with the permission attributes provided.
 - If you pass in a permissions value of '0', the default value of 744 will
 be used instead.
 */
-func WriteBytesToFile(fileName string, bytesToWrite []byte, permissions int) error {
+func (shared *fileSystemType) WriteBytesToFile(fileName string, bytesToWrite []byte, permissions int) error {
 	if permissions == 0 {
 		permissions = 0744
 	}
 	perm := os.FileMode(uint32(permissions))
-	err := ioutil.WriteFile(fileName, bytesToWrite, perm)
+	err := shared.writeFile(fileName, bytesToWrite, perm)
 	return err
 }
 
@@ -372,10 +632,10 @@ func WriteBytesToFile(fileName string, bytesToWrite []byte, permissions int) err
 GetFileContentsAsBytes allows you to get the contents of a file as a byte
 array.
 */
-func GetFileContentsAsBytes(fileName string) ([]byte, error) {
+func (shared *fileSystemType) GetFileContentsAsBytes(fileName string) ([]byte, error) {
 	var fileContents []byte
 	var err error
-	fileContents, err = ioutil.ReadFile(fileName)
+	fileContents, err = shared.readFile(fileName)
 	if err != nil {
 		return fileContents, err
 	}
@@ -393,12 +653,15 @@ with the permission attributes provided.
 - If you pass in a permissions value of '0', the default value of 744 will
 be used instead.
 */
-func AppendLineToFile(fileName string, lineToWrite string, permissions int) error {
+func (shared *fileSystemType) AppendLineToFile(fileName string, lineToWrite string, permissions int) error {
 	if permissions == 0 {
 		permissions = 0744
 	}
 	perm := os.FileMode(uint32(permissions))
-	file, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, perm)
+	file, err := shared.backend.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, perm)
+	if err != nil {
+		return err
+	}
 	defer file.Close()
 	file.WriteString(lineToWrite)
 	return err
@@ -409,8 +672,8 @@ func AppendLineToFile(fileName string, lineToWrite string, permissions int) erro
 GetListOfFiles allows you to obtain a list of files that match a given regular
 expression.
 */
-func GetListOfFiles(directoryPath string, regexMatcher string) ([]string, error) {
-	return GetListOfDirectoryContents(directoryPath, []string{regexMatcher}, true, false)
+func (shared *fileSystemType) GetListOfFiles(directoryPath string, regexMatcher string) ([]string, error) {
+	return shared.GetListOfDirectoryContents(directoryPath, []string{regexMatcher}, true, false)
 }
 
 /*
@@ -418,20 +681,18 @@ func GetListOfFiles(directoryPath string, regexMatcher string) ([]string, error)
 GetListOfDirectories allows you to obtain a list of files that match a given
 regular expression.
 */
-func GetListOfDirectories(directoryPath string, regexMatcher string) ([]string, error) {
-	return GetListOfDirectoryContents(directoryPath, []string{regexMatcher}, false, true)
+func (shared *fileSystemType) GetListOfDirectories(directoryPath string, regexMatcher string) ([]string, error) {
+	return shared.GetListOfDirectoryContents(directoryPath, []string{regexMatcher}, false, true)
 }
 
 /*
 IsDirectory allows you to check if a disk entry is a directory or not.
 */
-func IsDirectory(directoryPath string) bool {
-	file, err := os.Open(directoryPath)
-	defer file.Close()
+func (shared *fileSystemType) IsDirectory(directoryPath string) bool {
+	fileInfo, err := shared.backend.Stat(directoryPath)
 	if err != nil {
 		return false
 	}
-	fileInfo, err := file.Stat()
 	if fileInfo.IsDir() {
 		return true
 	}
@@ -443,16 +704,23 @@ func IsDirectory(directoryPath string) bool {
 GetListOfDirectoryContents allows you to obtain a list of files and directories
 that match a given regular expression.
 */
-func GetListOfDirectoryContents(directoryPath string, regexMatchers []string, isFilesIncluded bool, isDirectoriesIncluded bool) ([]string, error) {
+func (shared *fileSystemType) GetListOfDirectoryContents(directoryPath string, regexMatchers []string, isFilesIncluded bool, isDirectoriesIncluded bool) ([]string, error) {
 	var fileList []string
 	bareDirectoryPath := GetBareDirectoryPath(directoryPath)
-	files, err := ioutil.ReadDir(bareDirectoryPath)
+	var regexes []*regexp.Regexp
+	for _, currentRegex := range regexMatchers {
+		regex, err := compilePattern("list", directoryPath, currentRegex)
+		if err != nil {
+			return fileList, err
+		}
+		regexes = append(regexes, regex)
+	}
+	files, err := shared.backend.ReadDir(bareDirectoryPath)
 	if err != nil {
 		return fileList, err
 	}
 	for _, file := range files {
-		for _, currentRegex := range regexMatchers {
-			regex := regexp.MustCompile(currentRegex)
+		for _, regex := range regexes {
 			match := regex.FindStringSubmatch(file.Name())
 			if len(match) > 0 {
 				if file.IsDir() && isDirectoriesIncluded {
@@ -475,20 +743,20 @@ FindMatchingContent allows you to find matching content from a given directory
 path. Both shallow and recursive searches are supported and results are
 returned as a fully qualified path.
 */
-func FindMatchingContent(directoryPath string, regexMatchers []string, isFilesIncluded bool, isDirectoriesIncluded bool, isRecursive bool) ([]string, error) {
+func (shared *fileSystemType) FindMatchingContent(directoryPath string, regexMatchers []string, isFilesIncluded bool, isDirectoriesIncluded bool, isRecursive bool) ([]string, error) {
 	var err error
 	var listOfContents []string
 	if isRecursive {
-		err = filepath.Walk(directoryPath,
+		err = shared.walk(directoryPath,
 			func(path string, fileInfo os.FileInfo, err error) error {
 				if err != nil {
 					return err
 				}
-				if !IsDirectory(path) {
+				if !shared.IsDirectory(path) {
 					return nil
 				}
 				normalizedPath := GetNormalizedDirectoryPath(path)
-				matchingContents, err := GetListOfDirectoryContents(normalizedPath, regexMatchers, isFilesIncluded, isDirectoriesIncluded)
+				matchingContents, err := shared.GetListOfDirectoryContents(normalizedPath, regexMatchers, isFilesIncluded, isDirectoriesIncluded)
 				if err != nil {
 					return err
 				}
@@ -497,7 +765,7 @@ func FindMatchingContent(directoryPath string, regexMatchers []string, isFilesIn
 				return nil
 			})
 	} else {
-		matchingContent, err := GetListOfDirectoryContents(directoryPath, regexMatchers, isFilesIncluded, isDirectoriesIncluded)
+		matchingContent, err := shared.GetListOfDirectoryContents(directoryPath, regexMatchers, isFilesIncluded, isDirectoriesIncluded)
 		if err != nil {
 			return listOfContents, err
 		}
@@ -507,6 +775,49 @@ func FindMatchingContent(directoryPath string, regexMatchers []string, isFilesIn
 	return listOfContents, err
 }
 
+/*
+walk allows you to traverse a directory tree on the backend. It behaves the
+same way as 'filepath.Walk', calling the walk function for the root and
+every entry beneath it in lexical order without following symbolic links.
+*/
+func (shared *fileSystemType) walk(root string, walkFunction filepath.WalkFunc) error {
+	fileInfo, err := shared.backend.Lstat(root)
+	if err != nil {
+		err = walkFunction(root, nil, err)
+	} else {
+		err = shared.walkEntry(root, fileInfo, walkFunction)
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+/*
+walkEntry allows you to recursively descend through a single directory
+entry as part of a walk.
+*/
+func (shared *fileSystemType) walkEntry(path string, fileInfo os.FileInfo, walkFunction filepath.WalkFunc) error {
+	if !fileInfo.IsDir() {
+		return walkFunction(path, fileInfo, nil)
+	}
+	entries, err := shared.backend.ReadDir(path)
+	err1 := walkFunction(path, fileInfo, err)
+	if err != nil || err1 != nil {
+		return err1
+	}
+	for _, entry := range entries {
+		entryPath := filepath.Join(path, entry.Name())
+		err = shared.walkEntry(entryPath, entry, walkFunction)
+		if err != nil {
+			if !entry.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 /*
 *
 addPrefixToStrings allows you to append a prefix to an array of strings.
@@ -550,12 +861,12 @@ func GetBareDirectoryPath(directoryPath string) string {
 *
 IsDirectoryEmpty allows you to detect if a directory is empty or not.
 */
-func IsDirectoryEmpty(directoryName string) (bool, error) {
-	file, err := os.Open(directoryName)
-	defer file.Close()
+func (shared *fileSystemType) IsDirectoryEmpty(directoryName string) (bool, error) {
+	file, err := shared.backend.OpenFile(directoryName, os.O_RDONLY, 0)
 	if err != nil {
 		return false, err
 	}
+	defer file.Close()
 	_, err = file.Readdirnames(1)
 	if err == io.EOF {
 		return true, nil
@@ -567,9 +878,9 @@ func IsDirectoryEmpty(directoryName string) (bool, error) {
 *
 GetFileSize allows you to obtain the size of a specified file in bytes.
 */
-func GetFileSize(fileName string) (int64, error) {
+func (shared *fileSystemType) GetFileSize(fileName string) (int64, error) {
 	var fileSize int64
-	file, err := os.Stat(fileName)
+	file, err := shared.backend.Stat(fileName)
 	if err != nil {
 		return fileSize, err
 	}
@@ -614,7 +925,7 @@ that a file with the same name already exists, it will be overwritten. Here we
 explicitly do the delete so we don't depend on the 'os.Rename' behaviour of
 overwriting files which may be environment dependant.
 */
-func RenameFile(sourceFileName string, targetFileName string) error {
+func (shared *fileSystemType) RenameFile(sourceFileName string, targetFileName string) error {
 	var err error
 	bareSourceFileName := GetBareDirectoryPath(sourceFileName)
 	bareTargetFileName := GetBareDirectoryPath(targetFileName)
@@ -624,18 +935,18 @@ func RenameFile(sourceFileName string, targetFileName string) error {
 	// Since Windows is case insensitive we check if the names are identical, we
 	// give the file a temporary name before we request to rename it properly.
 	if runtime.GOOS == "windows" && strings.ToLower(bareSourceFileName) == strings.ToLower(bareTargetFileName) {
-		err = os.Rename(bareSourceFileName, bareTargetFileName+".tmp")
+		err = shared.backend.Rename(bareSourceFileName, bareTargetFileName+".tmp")
 		if err != nil {
 			return err
 		}
-		err = os.Rename(bareTargetFileName+".tmp", bareTargetFileName)
+		err = shared.backend.Rename(bareTargetFileName+".tmp", bareTargetFileName)
 		return err
 	}
 	// This needs to be here to avoid deleting target files on Windows before a case-insensitive check is done.
-	if IsFileExists(bareTargetFileName) {
-		DeleteFile(bareTargetFileName)
+	if shared.IsFileExists(bareTargetFileName) {
+		shared.DeleteFile(bareTargetFileName)
 	}
-	err = os.Rename(bareSourceFileName, bareTargetFileName)
+	err = shared.backend.Rename(bareSourceFileName, bareTargetFileName)
 	return err
 }
 
@@ -644,16 +955,16 @@ func RenameFile(sourceFileName string, targetFileName string) error {
 IsDirectoryExists allows you to check if a directory exists or not on the file
 system.
 */
-func IsDirectoryExists(directoryPath string) bool {
-	return isDiskEntryExists(directoryPath)
+func (shared *fileSystemType) IsDirectoryExists(directoryPath string) bool {
+	return shared.isDiskEntryExists(directoryPath)
 }
 
 /*
 *
 IsFileExists allows you to check if a file exists or not on the file system.
 */
-func IsFileExists(filePath string) bool {
-	return isDiskEntryExists(filePath)
+func (shared *fileSystemType) IsFileExists(filePath string) bool {
+	return shared.isDiskEntryExists(filePath)
 }
 
 /*
@@ -661,8 +972,8 @@ func IsFileExists(filePath string) bool {
 isDiskEntryExists allows you to check if a valid disk entry exists on the
 file system.
 */
-func isDiskEntryExists(path string) bool {
-	_, err := os.Stat(path)
+func (shared *fileSystemType) isDiskEntryExists(path string) bool {
+	_, err := shared.backend.Stat(path)
 	if os.IsNotExist(err) {
 		return false
 	}
@@ -681,8 +992,8 @@ func GetAbsolutePath(filePath string) (string, error) {
 *
 DeleteFile allows you to delete a file on the file system.
 */
-func DeleteFile(fileName string) error {
-	err := os.Remove(fileName)
+func (shared *fileSystemType) DeleteFile(fileName string) error {
+	err := shared.backend.Remove(fileName)
 	return err
 }
 
@@ -691,25 +1002,119 @@ func DeleteFile(fileName string) error {
 DeleteFilesMatchingPattern allows you to delete files matching a specific
 pattern. Pattern syntax is the same as the 'Match' command.
 */
-func DeleteFilesMatchingPattern(fileName string) error {
-	files, err := filepath.Glob(fileName)
+func (shared *fileSystemType) DeleteFilesMatchingPattern(fileName string) error {
+	files, err := shared.glob(fileName)
 	if err != nil {
 		return err
 	}
 	for _, f := range files {
-		if err := os.Remove(f); err != nil {
+		if err := shared.backend.Remove(f); err != nil {
 			return err
 		}
 	}
 	return err
 }
 
+/*
+glob allows you to obtain the names of all backend entries matching a
+pattern. It behaves the same way as 'filepath.Glob'.
+*/
+func (shared *fileSystemType) glob(pattern string) ([]string, error) {
+	_, err := filepath.Match(pattern, "")
+	if err != nil {
+		return nil, err
+	}
+	if !isGlobPattern(pattern) {
+		if _, err = shared.backend.Lstat(pattern); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+	directory, baseName := filepath.Split(pattern)
+	directory = cleanGlobPath(directory)
+	if !isGlobPattern(directory) {
+		return shared.globDirectory(directory, baseName, nil)
+	}
+	if directory == pattern {
+		return nil, filepath.ErrBadPattern
+	}
+	directories, err := shared.glob(directory)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, currentDirectory := range directories {
+		matches, err = shared.globDirectory(currentDirectory, baseName, matches)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+/*
+globDirectory allows you to append the entries of a single directory which
+match a pattern to an existing list of matches.
+*/
+func (shared *fileSystemType) globDirectory(directory string, pattern string, matches []string) ([]string, error) {
+	fileInfo, err := shared.backend.Stat(directory)
+	if err != nil || !fileInfo.IsDir() {
+		return matches, nil
+	}
+	entries, err := shared.backend.ReadDir(directory)
+	if err != nil {
+		return matches, nil
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		isMatched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return matches, err
+		}
+		if isMatched {
+			matches = append(matches, filepath.Join(directory, name))
+		}
+	}
+	return matches, nil
+}
+
+/*
+cleanGlobPath allows you to prepare a directory portion of a glob pattern
+for matching.
+*/
+func cleanGlobPath(path string) string {
+	switch path {
+	case "":
+		return "."
+	case string(filepath.Separator):
+		return path
+	default:
+		return path[0 : len(path)-1]
+	}
+}
+
+/*
+isGlobPattern allows you to check if a path contains any of the special
+characters recognized by 'filepath.Match'.
+*/
+func isGlobPattern(path string) bool {
+	magicChars := `*?[`
+	if runtime.GOOS != "windows" {
+		magicChars = `*?[\`
+	}
+	return strings.ContainsAny(path, magicChars)
+}
+
 /*
 DeleteDirectory allows you to recursively remove a directory from the file
 system.
 */
-func DeleteDirectory(pathName string) error {
-	err := os.RemoveAll(pathName)
+func (shared *fileSystemType) DeleteDirectory(pathName string) error {
+	err := shared.backend.RemoveAll(pathName)
 	return err
 }
 
@@ -719,56 +1124,60 @@ MoveFile allows you to move a file from one location to another. This method
 is an alias which simply performs a rename command, which is capable of doing
 the same action.
 */
-func MoveFile(sourceFile string, destinationFile string) error {
+func (shared *fileSystemType) MoveFile(sourceFile string, destinationFile string) error {
 	// Since windows is case-insensitive, it is valid if the user wants to rename / move a file to the
 	// same location, since he is just trying to change case. So we only error out if this edge-case is not detected.
-	if runtime.GOOS != "windows" && sourceFile != destinationFile && IsFileExists(destinationFile) {
-		return errors.New(fmt.Sprintf("Cannot move '%s' to the destination location since '%s' already exists .", sourceFile, destinationFile))
+	if runtime.GOOS != "windows" && sourceFile != destinationFile && shared.IsFileExists(destinationFile) {
+		return &OpError{Op: "move", Path: sourceFile, NewPath: destinationFile, Err: ErrDestinationExists}
 	}
-	if runtime.GOOS == "windows" && strings.ToLower(sourceFile) != strings.ToLower(destinationFile) && IsFileExists(destinationFile) {
-		return errors.New(fmt.Sprintf("Cannot move '%s' to the destination location since '%s' already exists .", sourceFile, destinationFile))
+	if runtime.GOOS == "windows" && strings.ToLower(sourceFile) != strings.ToLower(destinationFile) && shared.IsFileExists(destinationFile) {
+		return &OpError{Op: "move", Path: sourceFile, NewPath: destinationFile, Err: ErrDestinationExists}
 	}
 	now := time.Now()
 	uniqueId := fmt.Sprintf("%d", now.Unix())
 	uniqueFileName := destinationFile + "_" + uniqueId
-	err := RenameFile(sourceFile, uniqueFileName)
+	err := shared.RenameFile(sourceFile, uniqueFileName)
 	if err != nil {
 		return err
 	}
-	err = RenameFile(uniqueFileName, destinationFile)
+	err = shared.RenameFile(uniqueFileName, destinationFile)
 	return err
 }
 
-func MoveDirectories(sourceDir string, destinationDir string) error {
+/*
+MoveDirectories allows you to move a directory from one location to
+another.
+*/
+func (shared *fileSystemType) MoveDirectories(sourceDir string, destinationDir string) error {
 	normalizedSourceDirectory := strings.TrimRight(sourceDir, "\\/")
 	normalizedDestinationDirectory := strings.TrimRight(destinationDir, "\\/")
-	if runtime.GOOS != "windows" && sourceDir != destinationDir && IsDirectoryExists(destinationDir) {
-		return errors.New(fmt.Sprintf("Cannot move '%s' to the destination location since '%s' already exists.", sourceDir, destinationDir))
+	if runtime.GOOS != "windows" && sourceDir != destinationDir && shared.IsDirectoryExists(destinationDir) {
+		return &OpError{Op: "move", Path: sourceDir, NewPath: destinationDir, Err: ErrDestinationExists}
 	}
-	if runtime.GOOS == "windows" && strings.ToLower(sourceDir) != strings.ToLower(destinationDir) && IsDirectoryExists(destinationDir) {
-		return errors.New(fmt.Sprintf("Cannot move '%s' to the destination location since '%s' already exists.", sourceDir, destinationDir))
+	if runtime.GOOS == "windows" && strings.ToLower(sourceDir) != strings.ToLower(destinationDir) && shared.IsDirectoryExists(destinationDir) {
+		return &OpError{Op: "move", Path: sourceDir, NewPath: destinationDir, Err: ErrDestinationExists}
 	}
 
 	now := time.Now()
 	uniqueId := fmt.Sprintf("%d", now.Unix())
 	uniqueDirName := normalizedDestinationDirectory + "_" + uniqueId
-	err := os.Rename(normalizedSourceDirectory, uniqueDirName)
+	err := shared.backend.Rename(normalizedSourceDirectory, uniqueDirName)
 	if err != nil {
 		return err
 	}
-	err = os.Rename(uniqueDirName, normalizedDestinationDirectory)
+	err = shared.backend.Rename(uniqueDirName, normalizedDestinationDirectory)
 	return err
 }
 
 /*
 CreateDirectory allows you to create a directory on your local file system.
 */
-func CreateDirectory(directoryPath string, permissions uint32) error {
+func (shared *fileSystemType) CreateDirectory(directoryPath string, permissions uint32) error {
 	if permissions == 0 {
 		permissions = 0744
 	}
 	perm := os.FileMode(permissions)
-	err := os.MkdirAll(directoryPath, perm)
+	err := shared.backend.MkdirAll(directoryPath, perm)
 	return err
 }
 
@@ -819,9 +1228,9 @@ func GetFileNameFromPath(fullyQualifiedFileName string) string {
 /*
 IsFile allows you to check if an item on the file system is a file or not.
 */
-func IsFile(path string) (bool, error) {
+func (shared *fileSystemType) IsFile(path string) (bool, error) {
 	var isFile bool
-	fi, err := os.Stat(path)
+	fi, err := shared.backend.Stat(path)
 	if err != nil {
 		return isFile, err
 	}
@@ -1,8 +1,11 @@
 package filesystem
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"net/http"
+	"os"
 	"strings"
 	"testing"
 )
@@ -236,6 +239,10 @@ func TestGetFileContents(test *testing.T) {
 		assert.NoErrorf(test, err, "An error was not expected when reading a file.")
 	}
 	assert.Equalf(test, "First written line.\nSecond written line.\nThird written line.", string(fileContents), "The text file was expected to be a size it wasn't.")
+	DeleteFile(filename)
+	_, err = GetFileContents(filename)
+	assert.Truef(test, os.IsNotExist(err), "Reading a missing file was expected to report that it does not exist.")
+	assert.Falsef(test, IsFileExists(filename), "Reading a missing file was not expected to create it.")
 }
 func TestPopLineFromStack(test *testing.T) {
 	var file fileInstanceType
@@ -521,4 +528,66 @@ func TestIsFile(test *testing.T) {
 	assert.Equalf(test, expectedValue, obtainedValue, "The value returned for checking if a path was a file or not was not as expected!")
 	err = DeleteFile(sourceDirectory)
 	assert.NoErrorf(test, err, "No error was expected to occur when trying to delete a directory!")
-}
\ No newline at end of file
+}
+
+func TestOpenWithOptions(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	filename := "/file.txt"
+	file := fileSystem.GetFileInstance()
+	err := file.OpenWithOptions(filename, OpenOptions{IsReadOnly: true})
+	assert.Errorf(test, err, "Opening a missing file read-only was expected to fail.")
+	err = file.OpenWithOptions(filename, OpenOptions{IsExclusive: true, Permissions: 0644})
+	assert.NoErrorf(test, err, "An error was not expected when exclusively creating a file.")
+	_, err = file.WriteAt([]byte("0123456789"), 0)
+	assert.NoErrorf(test, err, "An error was not expected when writing at an offset.")
+	_, err = file.WriteAt([]byte("AB"), 4)
+	assert.NoErrorf(test, err, "An error was not expected when writing at an offset.")
+	position, err := file.Seek(-2, io.SeekEnd)
+	assert.NoErrorf(test, err, "An error was not expected when seeking.")
+	assert.Equalf(test, int64(8), position, "The seek position was not as expected.")
+	buffer := make([]byte, 2)
+	_, err = file.Read(buffer)
+	assert.NoErrorf(test, err, "An error was not expected when reading.")
+	assert.Equalf(test, "89", string(buffer), "The bytes read were not as expected.")
+	err = file.Truncate(6)
+	assert.NoErrorf(test, err, "An error was not expected when truncating.")
+	fileInfo, err := file.Stat()
+	assert.NoErrorf(test, err, "An error was not expected when obtaining file attributes.")
+	assert.Equalf(test, int64(6), fileInfo.Size(), "The truncated file size was not as expected.")
+	file.Close()
+	err = file.OpenWithOptions(filename, OpenOptions{IsExclusive: true})
+	assert.Truef(test, errors.Is(err, os.ErrExist), "Exclusively creating an existing file was expected to fail.")
+	err = file.OpenWithOptions(filename, OpenOptions{IsReadOnly: true})
+	assert.NoErrorf(test, err, "An error was not expected when opening a file read-only.")
+	_, err = file.ReadAt(buffer, 4)
+	assert.NoErrorf(test, err, "An error was not expected when reading at an offset.")
+	assert.Equalf(test, "AB", string(buffer), "The bytes read were not as expected.")
+	err = file.WriteString("more")
+	assert.Errorf(test, err, "Writing to a file opened read-only was expected to fail.")
+	file.Close()
+	err = file.OpenWithOptions(filename, OpenOptions{IsWriteOnly: true, IsTruncated: true})
+	assert.NoErrorf(test, err, "An error was not expected when truncating a file on open.")
+	file.Close()
+	fileSize, _ := fileSystem.GetFileSize(filename)
+	assert.Equalf(test, int64(0), fileSize, "The file was expected to be truncated when opened.")
+	err = file.OpenWithOptions(filename, OpenOptions{IsReadOnly: true, IsTruncated: true})
+	assert.Truef(test, errors.Is(err, ErrInvalidOptions), "Contradicting options were expected to be rejected.")
+}
+
+func TestOpenWithOptionsRejectsSymlinks(test *testing.T) {
+	filename := "/tmp/open_target.txt"
+	linkName := "/tmp/open_link.txt"
+	DeleteFile(linkName)
+	err := WriteBytesToFile(filename, []byte("sample_string"), 0666)
+	assert.NoErrorf(test, err, "An error was not expected when creating a sample file!")
+	err = os.Symlink(filename, linkName)
+	assert.NoErrorf(test, err, "An error was not expected when creating a symbolic link.")
+	file := GetFileInstance()
+	err = file.OpenWithOptions(linkName, OpenOptions{IsReadOnly: true, IsSymlinkRejected: true})
+	assert.Truef(test, errors.Is(err, ErrIsSymlink), "Opening a symbolic link was expected to be rejected.")
+	err = file.OpenWithOptions(filename, OpenOptions{IsSynchronous: true, IsDataSynchronous: true, IsSymlinkRejected: true})
+	assert.NoErrorf(test, err, "An error was not expected when opening a regular file.")
+	file.Close()
+	DeleteFile(linkName)
+	DeleteFile(filename)
+}
@@ -0,0 +1,331 @@
+package filesystem
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+/*
+FindReplaceOptions allows you to control how text is found and replaced
+within a file. In addition, the following information should be noted:
+
+- By default the pattern is matched against one line at a time, without
+its line ending, so the file is streamed and can be of any size. If
+'IsMultiLine' is set, the pattern is matched against the whole file
+instead, so that matches can span several lines. This requires the whole
+file to be loaded into memory. The pattern flags are left alone, so '(?m)'
+and '(?s)' can be used to control how '^', '$' and '.' treat newlines.
+
+- If 'IsLiteral' is set, both the pattern and the replacement are treated
+as plain text, so regular expression metacharacters and '$1' style
+references have no special meaning.
+
+- If 'IsCaseInsensitive' is set, letters match regardless of case.
+
+- If you pass in a maximum number of replacements of '0', every match is
+replaced. Otherwise only the first matches in the file are replaced.
+
+- The permissions of the file are always preserved.
+
+- If 'IsOwnerPreserved' is set, the user and group which own the file are
+preserved too. This usually requires elevated privileges when the file is
+owned by someone else.
+
+- If 'IsTimestampPreserved' is set, the modification time of the file is
+preserved.
+
+- If 'IsDryRun' is set, the file is not written. Instead, a unified diff of
+the changes which would be made is returned along with the match counts.
+*/
+type FindReplaceOptions struct {
+	IsMultiLine          bool
+	IsLiteral            bool
+	IsCaseInsensitive    bool
+	MaximumReplacements  int
+	IsDryRun             bool
+	IsOwnerPreserved     bool
+	IsTimestampPreserved bool
+}
+
+/*
+FindReplaceResult describes the matches replaced by a find and replace, or
+which would be replaced in the case of a dry run. The line match counts are
+keyed by the number of the line each match starts on, beginning at '1', and
+only include lines with at least one match. The diff is only produced for
+dry runs, and is empty if nothing would change.
+*/
+type FindReplaceResult struct {
+	MatchCount      int
+	LineMatchCounts map[int64]int
+	Diff            string
+}
+
+/*
+ContainsTextOptions allows you to control how a file is searched for text.
+In addition, the following information should be noted:
+
+- By default the pattern is matched against one line at a time, without
+its line ending. If 'IsMultiLine' is set, the pattern is matched against
+the whole file so that matches can span several lines. Either way the file
+is streamed and reading stops at the first match.
+
+- If 'IsLiteral' is set, the pattern is treated as plain text.
+
+- If 'IsCaseInsensitive' is set, letters match regardless of case.
+*/
+type ContainsTextOptions struct {
+	IsMultiLine       bool
+	IsLiteral         bool
+	IsCaseInsensitive bool
+}
+
+/*
+errorRecordingReaderType is a reader which remembers the first error other
+than the end of the input, since readers handed to a regular expression
+have their errors silently treated as the end of the input.
+*/
+type errorRecordingReaderType struct {
+	reader io.Reader
+	err    error
+}
+
+func (shared *errorRecordingReaderType) Read(buffer []byte) (int, error) {
+	bytesRead, err := shared.reader.Read(buffer)
+	if err != nil && err != io.EOF && shared.err == nil {
+		shared.err = err
+	}
+	return bytesRead, err
+}
+
+/*
+IsFileContainsTextWithOptions allows you to check if a file contains a
+matching string or not, using the options provided. The file is streamed
+and reading stops as soon as a match is found.
+*/
+func (shared *fileSystemType) IsFileContainsTextWithOptions(fileName string, regexMatcher string, options ContainsTextOptions) (bool, error) {
+	regex, err := compileTextPattern("search", fileName, regexMatcher, options.IsLiteral, options.IsCaseInsensitive)
+	if err != nil {
+		return false, err
+	}
+	file, err := shared.backend.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	if options.IsMultiLine {
+		reader := &errorRecordingReaderType{reader: file}
+		isMatched := regex.MatchReader(bufio.NewReader(reader))
+		return isMatched && reader.err == nil, reader.err
+	}
+	scanner := getLineScanner(file, nil, LineScannerOptions{MaximumLineLength: maximumEditedLineLength})
+	for scanner.Scan() {
+		if regex.Match(scanner.GetLine()) {
+			return true, nil
+		}
+	}
+	return false, scanner.GetError()
+}
+
+/*
+FindReplaceInFileWithOptions allows you to find and replace text from
+within a file, as described by 'FindReplaceInFile', using the options
+provided. Information about the matches found is returned. If no text is
+changed, the file is left untouched.
+*/
+func (shared *fileSystemType) FindReplaceInFileWithOptions(fileName string, regexMatcher string, replacementValue string, options FindReplaceOptions) (FindReplaceResult, error) {
+	var result FindReplaceResult
+	result.LineMatchCounts = make(map[int64]int)
+	regex, err := compileTextPattern("replace", fileName, regexMatcher, options.IsLiteral, options.IsCaseInsensitive)
+	if err != nil {
+		return result, err
+	}
+	var rewriteOptions rewriteOptionsType
+	rewriteOptions.isOwnerPreserved = options.IsOwnerPreserved
+	rewriteOptions.isTimestampPreserved = options.IsTimestampPreserved
+	remainingReplacements := options.MaximumReplacements
+	if remainingReplacements <= 0 {
+		remainingReplacements = -1
+	}
+	if options.IsMultiLine {
+		return shared.findReplaceInWholeFile(fileName, regex, replacementValue, remainingReplacements, options, rewriteOptions)
+	}
+	var diff *unifiedDiffType
+	if options.IsDryRun {
+		diff = getUnifiedDiff(fileName)
+	}
+	replaceLine := func(lineNumber int64, line []byte, isDelimited bool) []byte {
+		text := string(line)
+		isCarriageReturned := strings.HasSuffix(text, "\r")
+		text = strings.TrimSuffix(text, "\r")
+		matches := regex.FindAllStringSubmatchIndex(text, remainingReplacements)
+		newText := text
+		if len(matches) > 0 {
+			if remainingReplacements > 0 {
+				remainingReplacements -= len(matches)
+			}
+			result.MatchCount += len(matches)
+			result.LineMatchCounts[lineNumber] = len(matches)
+			newText = expandMatches(regex, text, matches, replacementValue, options.IsLiteral, 0, len(text))
+		}
+		if diff != nil && newText != text {
+			diff.addChangedLine(text, newText, isDelimited)
+		} else if diff != nil {
+			diff.addUnchangedLine(text, isDelimited)
+		}
+		if newText == text {
+			return line
+		}
+		if isCarriageReturned {
+			newText += "\r"
+		}
+		return []byte(newText)
+	}
+	if options.IsDryRun {
+		file, err := shared.backend.OpenFile(fileName, os.O_RDONLY, 0)
+		if err != nil {
+			return result, err
+		}
+		defer file.Close()
+		scanner := getLineScanner(file, nil, LineScannerOptions{IsCarriageReturnKept: true, MaximumLineLength: maximumEditedLineLength})
+		for scanner.Scan() {
+			replaceLine(scanner.GetLineNumber(), scanner.GetLine(), scanner.isDelimited)
+		}
+		if scanner.GetError() != nil {
+			return result, &OpError{Op: "replace", Path: fileName, Err: scanner.GetError()}
+		}
+		result.Diff = diff.getDiff()
+		return result, nil
+	}
+	err = shared.rewriteLines("replace", fileName, rewriteOptions, func(editor *lineEditorType, lineIndex int64, line []byte, isDelimited bool) error {
+		newLine := replaceLine(lineIndex+1, line, isDelimited)
+		if string(newLine) != string(line) {
+			editor.isChanged = true
+		}
+		return editor.copyLine(newLine, isDelimited)
+	}, func(editor *lineEditorType, lineCount int64) error {
+		return nil
+	})
+	return result, err
+}
+
+/*
+findReplaceInWholeFile allows you to find and replace text with the pattern
+matched against the entire contents of a file at once.
+*/
+func (shared *fileSystemType) findReplaceInWholeFile(fileName string, regex *regexp.Regexp, replacementValue string, maximumReplacements int, options FindReplaceOptions, rewriteOptions rewriteOptionsType) (FindReplaceResult, error) {
+	var result FindReplaceResult
+	result.LineMatchCounts = make(map[int64]int)
+	fileContents, err := shared.readFile(fileName)
+	if err != nil {
+		return result, err
+	}
+	text := string(fileContents)
+	matches := regex.FindAllStringSubmatchIndex(text, maximumReplacements)
+	lineNumber := int64(1)
+	lineOffset := 0
+	for _, match := range matches {
+		lineNumber += int64(strings.Count(text[lineOffset:match[0]], "\n"))
+		lineOffset = match[0]
+		result.LineMatchCounts[lineNumber]++
+	}
+	result.MatchCount = len(matches)
+	expand := func(matches [][]int, start int, end int) string {
+		return expandMatches(regex, text, matches, replacementValue, options.IsLiteral, start, end)
+	}
+	if options.IsDryRun {
+		result.Diff = getMultiLineDiff(fileName, text, matches, expand)
+		return result, nil
+	}
+	newText := expand(matches, 0, len(text))
+	if newText == text {
+		return result, nil
+	}
+	err = shared.rewriteFile(fileName, rewriteOptions, func(source File, target File) (bool, error) {
+		_, err := target.WriteString(newText)
+		return true, err
+	})
+	if err != nil {
+		return result, &OpError{Op: "replace", Path: fileName, Err: err}
+	}
+	return result, nil
+}
+
+/*
+getMultiLineDiff allows you to build a unified diff for replacements which
+may span several lines. Every run of lines touched by a match, or by
+matches on neighbouring lines, is shown as a single change.
+*/
+func getMultiLineDiff(fileName string, text string, matches [][]int, expand func(matches [][]int, start int, end int) string) string {
+	diff := getUnifiedDiff(fileName)
+	getLineEnd := func(offset int) int {
+		index := strings.IndexByte(text[offset:], '\n')
+		if index < 0 {
+			return len(text)
+		}
+		return offset + index
+	}
+	position := 0
+	for index := 0; index < len(matches); {
+		regionStart := strings.LastIndexByte(text[:matches[index][0]], '\n') + 1
+		regionEnd := getLineEnd(matches[index][1])
+		lastIndex := index
+		for lastIndex+1 < len(matches) && matches[lastIndex+1][0] <= regionEnd {
+			lastIndex++
+			if lineEnd := getLineEnd(matches[lastIndex][1]); lineEnd > regionEnd {
+				regionEnd = lineEnd
+			}
+		}
+		diff.addUnchangedText(text[position:regionStart])
+		oldRegion := text[regionStart:regionEnd]
+		newRegion := expand(matches[index:lastIndex+1], regionStart, regionEnd)
+		isDelimited := regionEnd < len(text)
+		if newRegion != oldRegion {
+			diff.addChangedLine(oldRegion, newRegion, isDelimited)
+		} else {
+			diff.addUnchangedText(text[regionStart:minimumInt(regionEnd+1, len(text))])
+		}
+		position = minimumInt(regionEnd+1, len(text))
+		index = lastIndex + 1
+	}
+	diff.addUnchangedText(text[position:])
+	return diff.getDiff()
+}
+
+/*
+expandMatches allows you to obtain the text between the start and end
+offsets provided with every match within it replaced. Unless the
+replacement is literal, capture group references in it are expanded.
+*/
+func expandMatches(regex *regexp.Regexp, text string, matches [][]int, replacement string, isLiteral bool, start int, end int) string {
+	var builder strings.Builder
+	position := start
+	for _, match := range matches {
+		builder.WriteString(text[position:match[0]])
+		if isLiteral {
+			builder.WriteString(replacement)
+		} else {
+			builder.Write(regex.ExpandString(nil, replacement, text, match))
+		}
+		position = match[1]
+	}
+	builder.WriteString(text[position:end])
+	return builder.String()
+}
+
+/*
+compileTextPattern allows you to compile a pattern supplied by the caller,
+optionally treating it as plain text or ignoring case.
+*/
+func compileTextPattern(operation string, path string, pattern string, isLiteral bool, isCaseInsensitive bool) (*regexp.Regexp, error) {
+	expression := pattern
+	if isLiteral {
+		expression = regexp.QuoteMeta(pattern)
+	}
+	if isCaseInsensitive {
+		expression = "(?i)" + expression
+	}
+	return compilePattern(operation, path, expression)
+}
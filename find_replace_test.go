@@ -0,0 +1,120 @@
+package filesystem
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFindReplaceInFileDryRun(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileContents := "alpha beta\r\ngamma\r\nbeta beta\r\n"
+	fileSystem.WriteBytesToFile("/file.txt", []byte(fileContents), 0)
+	result, err := fileSystem.FindReplaceInFileWithOptions("/file.txt", "beta", "delta", FindReplaceOptions{IsDryRun: true})
+	assert.NoErrorf(test, err, "An error was not expected when performing a dry run.")
+	assert.Equalf(test, 3, result.MatchCount, "The number of matches was not as expected.")
+	assert.Equalf(test, map[int64]int{1: 1, 3: 2}, result.LineMatchCounts, "The matches per line were not as expected.")
+	expectedDiff := "--- /file.txt\n+++ /file.txt\n@@ -1,3 +1,3 @@\n-alpha beta\n+alpha delta\n gamma\n-beta beta\n+delta delta\n"
+	assert.Equalf(test, expectedDiff, result.Diff, "The diff was not as expected.")
+	unchangedContents, _ := fileSystem.GetFileContentsAsBytes("/file.txt")
+	assert.Equalf(test, fileContents, string(unchangedContents), "A dry run was not expected to change the file.")
+	_, err = fileSystem.FindReplaceInFileWithOptions("/file.txt", "(", "", FindReplaceOptions{IsDryRun: true})
+	assert.Truef(test, errors.Is(err, ErrInvalidPattern), "An invalid pattern was expected to be rejected.")
+}
+
+func TestFindReplaceInFilePreservesAttributes(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/file.txt", []byte("alpha beta\r\ngamma"), 0640)
+	fileSystem.backend.Chown("/file.txt", 1000, 1000)
+	modificationTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fileSystem.backend.Chtimes("/file.txt", modificationTime, modificationTime)
+	originalFileInfo, _ := fileSystem.backend.Stat("/file.txt")
+	options := FindReplaceOptions{IsOwnerPreserved: true, IsTimestampPreserved: true}
+	result, err := fileSystem.FindReplaceInFileWithOptions("/file.txt", "a$", "A", options)
+	assert.NoErrorf(test, err, "An error was not expected when replacing text.")
+	assert.Equalf(test, 2, result.MatchCount, "The number of matches was not as expected.")
+	assert.Emptyf(test, result.Diff, "A diff was only expected for dry runs.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/file.txt")
+	assert.Equalf(test, "alpha betA\r\ngammA", string(fileContents), "The line endings of the file were expected to be kept.")
+	fileInfo, _ := fileSystem.backend.Stat("/file.txt")
+	assert.Falsef(test, isSameFile(originalFileInfo, fileInfo), "The file was expected to be replaced rather than rewritten in place.")
+	uid, gid, _ := getFileOwner(fileInfo)
+	assert.Equalf(test, []int{1000, 1000}, []int{uid, gid}, "The owner of the file was expected to be kept.")
+	assert.Equalf(test, 0640, int(fileInfo.Mode().Perm()), "The permissions of the file were expected to be kept.")
+	assert.Truef(test, modificationTime.Equal(fileInfo.ModTime()), "The modification time of the file was expected to be kept.")
+	err = fileSystem.FindReplaceInFile("/file.txt", "missing", "")
+	assert.NoErrorf(test, err, "An error was not expected when nothing matches.")
+	unchangedFileInfo, _ := fileSystem.backend.Stat("/file.txt")
+	assert.Truef(test, isSameFile(fileInfo, unchangedFileInfo), "A file was not expected to be replaced when nothing matches.")
+}
+
+func TestFindReplaceInFileModes(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/file.txt", []byte("Price: $1.00 (USD)\nprice: $2.00\nPRICE: $3.00\n"), 0)
+	options := FindReplaceOptions{IsLiteral: true, IsCaseInsensitive: true, MaximumReplacements: 2}
+	result, err := fileSystem.FindReplaceInFileWithOptions("/file.txt", "price: $", "cost: $1", options)
+	assert.NoErrorf(test, err, "An error was not expected when replacing literal text.")
+	assert.Equalf(test, map[int64]int{1: 1, 2: 1}, result.LineMatchCounts, "Only the maximum number of replacements were expected.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/file.txt")
+	assert.Equalf(test, "cost: $11.00 (USD)\ncost: $12.00\nPRICE: $3.00\n", string(fileContents), "The literal text was not replaced as expected.")
+	fileSystem.WriteBytesToFile("/block.txt", []byte("keep\n<start>\nold\n<end>\nkeep\nkeep\nkeep\nkeep\nkeep <start>x<end>"), 0)
+	options = FindReplaceOptions{IsMultiLine: true, IsDryRun: true}
+	result, err = fileSystem.FindReplaceInFileWithOptions("/block.txt", `(?s)<start>.*?<end>`, "<new>", options)
+	assert.NoErrorf(test, err, "An error was not expected when performing a multi-line dry run.")
+	assert.Equalf(test, map[int64]int{2: 1, 9: 1}, result.LineMatchCounts, "Matches were expected to be counted on the line they start.")
+	expectedDiff := "--- /block.txt\n+++ /block.txt\n@@ -1,9 +1,7 @@\n keep\n-<start>\n-old\n-<end>\n+<new>\n keep\n keep\n keep\n keep\n" +
+		"-keep <start>x<end>\n\\ No newline at end of file\n+keep <new>\n\\ No newline at end of file\n"
+	assert.Equalf(test, expectedDiff, result.Diff, "The multi-line diff was not as expected.")
+	options.IsDryRun = false
+	_, err = fileSystem.FindReplaceInFileWithOptions("/block.txt", `(?s)<start>.*?<end>`, "<new>", options)
+	assert.NoErrorf(test, err, "An error was not expected when replacing across lines.")
+	fileContents, _ = fileSystem.GetFileContentsAsBytes("/block.txt")
+	assert.Equalf(test, "keep\n<new>\nkeep\nkeep\nkeep\nkeep\nkeep <new>", string(fileContents), "Text spanning several lines was not replaced as expected.")
+}
+
+func TestIsFileContainsTextWithOptions(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/file.txt", []byte("First line (a)\r\nSecond line\r\n"), 0)
+	testCases := []struct {
+		pattern        string
+		options        ContainsTextOptions
+		expectedResult bool
+	}{
+		{`line \(a\)$`, ContainsTextOptions{}, true},
+		{`line (a)`, ContainsTextOptions{IsLiteral: true}, true},
+		{`line (a)`, ContainsTextOptions{}, false},
+		{`SECOND`, ContainsTextOptions{IsCaseInsensitive: true}, true},
+		{`SECOND`, ContainsTextOptions{}, false},
+		{`\(a\)\r\nSecond`, ContainsTextOptions{}, false},
+		{`\(a\)\r\nSecond`, ContainsTextOptions{IsMultiLine: true}, true},
+	}
+	for _, testCase := range testCases {
+		isFound, err := fileSystem.IsFileContainsTextWithOptions("/file.txt", testCase.pattern, testCase.options)
+		assert.NoErrorf(test, err, "An error was not expected when searching for '%s'.", testCase.pattern)
+		assert.Equalf(test, testCase.expectedResult, isFound, "The search for '%s' with %+v was not as expected.", testCase.pattern, testCase.options)
+	}
+	_, err := fileSystem.IsFileContainsTextWithOptions("/missing.txt", "a", ContainsTextOptions{})
+	assert.Truef(test, errors.Is(err, os.ErrNotExist), "Searching a missing file was expected to fail.")
+}
+
+func TestFindReplaceInFileThroughSymlink(test *testing.T) {
+	fileName := "/tmp/find_replace_target.txt"
+	linkName := "/tmp/find_replace_link.txt"
+	os.Remove(fileName)
+	os.Remove(linkName)
+	WriteBytesToFile(fileName, []byte("hello world"), 0640)
+	err := os.Symlink(fileName, linkName)
+	assert.NoErrorf(test, err, "An error was not expected when creating a symbolic link.")
+	err = FindReplaceInFile(linkName, "world", "there")
+	assert.NoErrorf(test, err, "An error was not expected when replacing text through a symbolic link.")
+	fileInfo, _ := os.Lstat(linkName)
+	assert.Truef(test, fileInfo.Mode()&os.ModeSymlink != 0, "The symbolic link was expected to remain a link.")
+	fileContents, _ := GetFileContentsAsBytes(fileName)
+	assert.Equalf(test, "hello there", string(fileContents), "The target of the symbolic link was expected to be modified.")
+	fileInfo, _ = os.Stat(fileName)
+	assert.Equalf(test, os.FileMode(0640), fileInfo.Mode().Perm(), "The permissions of the target were expected to be kept.")
+	os.Remove(linkName)
+	os.Remove(fileName)
+}
@@ -0,0 +1,278 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"time"
+)
+
+/*
+defaultFollowPollInterval is how often a followed file is checked for new
+lines when no poll interval is configured.
+*/
+const defaultFollowPollInterval = 250 * time.Millisecond
+
+/*
+FollowOptions allows you to control how a file is followed. In addition,
+the following information should be noted:
+
+- By default following starts from the start offset provided, which is
+'0' unless a previously saved 'NextOffset' is supplied. If the file is now
+smaller than the start offset, it is assumed to have been truncated and
+following starts from the beginning instead.
+
+- If 'IsStartedAtEnd' is set, only lines appended after following begins
+are reported and the start offset is ignored.
+
+- If you pass in a poll interval of '0', a default of 250 milliseconds is
+used.
+
+- If you pass in a maximum line length of '0', a default of 1 MiB is used.
+*/
+type FollowOptions struct {
+	IsStartedAtEnd    bool
+	StartOffset       int64
+	PollInterval      time.Duration
+	MaximumLineLength int
+}
+
+/*
+FollowedLine is a single line reported while following a file. The next
+offset can be saved and supplied as a start offset later on in order to
+resume following without missing or repeating any lines.
+*/
+type FollowedLine struct {
+	Text       string
+	Offset     int64
+	NextOffset int64
+}
+
+type followerType struct {
+	fileSystem *fileSystemType
+	fileName   string
+	options    FollowOptions
+	file       File
+	fileInfo   os.FileInfo
+	offset     int64
+}
+
+/*
+Follow allows you to receive every line appended to a file as it is
+written, in the same way as 'tail -F'. The callback is called once for each
+complete line and following continues until the context is cancelled, at
+which point nil is returned. In addition, the following information should
+be noted:
+
+- A line is only reported once its newline has been written, so partially
+written lines are never seen.
+
+- If the file is renamed and recreated, any remaining lines in the old file
+are reported before following switches to the new file. A final line in
+the old file which was never terminated is reported as well.
+
+- If the file shrinks, as happens when it is truncated in place, following
+restarts from the beginning of the file.
+
+- If the file does not exist yet, or is missing during rotation, following
+waits for it to appear.
+
+- If the callback returns an error, following stops and that error is
+returned.
+*/
+func (shared *fileSystemType) Follow(ctx context.Context, fileName string, options FollowOptions, callback func(FollowedLine) error) error {
+	follower, err := shared.getFollower(fileName, options)
+	if err != nil {
+		return err
+	}
+	return follower.run(ctx, callback)
+}
+
+/*
+GetFollowChannel allows you to receive every line appended to a file on a
+channel, as described by 'Follow'. The starting position is established
+before this method returns, so lines written afterwards are never missed.
+The line channel is closed once following stops. If following stops because
+of an error rather than the context being cancelled, the error is sent on
+the error channel first.
+*/
+func (shared *fileSystemType) GetFollowChannel(ctx context.Context, fileName string, options FollowOptions) (<-chan FollowedLine, <-chan error) {
+	lines := make(chan FollowedLine)
+	errs := make(chan error, 1)
+	follower, err := shared.getFollower(fileName, options)
+	if err != nil {
+		errs <- err
+		close(errs)
+		close(lines)
+		return lines, errs
+	}
+	go func() {
+		defer close(lines)
+		defer close(errs)
+		err := follower.run(ctx, func(line FollowedLine) error {
+			select {
+			case lines <- line:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			errs <- err
+		}
+	}()
+	return lines, errs
+}
+
+/*
+getFollower allows you to create a follower positioned at the offset where
+following should begin.
+*/
+func (shared *fileSystemType) getFollower(fileName string, options FollowOptions) (*followerType, error) {
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultFollowPollInterval
+	}
+	var follower followerType
+	follower.fileSystem = shared
+	follower.fileName = fileName
+	follower.options = options
+	follower.offset = options.StartOffset
+	if options.IsStartedAtEnd {
+		err := follower.open()
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			follower.offset = follower.fileInfo.Size()
+		}
+	}
+	return &follower, nil
+}
+
+/*
+run allows you to poll the followed file until the context is cancelled or
+an error occurs.
+*/
+func (shared *followerType) run(ctx context.Context, callback func(FollowedLine) error) error {
+	defer shared.close()
+	ticker := time.NewTicker(shared.options.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := shared.poll(callback); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+poll allows you to report any new lines in the followed file, switching to
+a new file if the original has been rotated away.
+*/
+func (shared *followerType) poll(callback func(FollowedLine) error) error {
+	if shared.file == nil {
+		if err := shared.open(); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+	}
+	currentFileInfo, err := shared.fileSystem.backend.Stat(shared.fileName)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	isRotated := err == nil && !isSameFile(currentFileInfo, shared.fileInfo)
+	openFileInfo, err := shared.file.Stat()
+	if err != nil {
+		return err
+	}
+	if openFileInfo.Size() < shared.offset {
+		shared.offset = 0
+	}
+	if err := shared.emitLines(callback, isRotated); err != nil {
+		return err
+	}
+	if !isRotated {
+		return nil
+	}
+	shared.close()
+	shared.offset = 0
+	return shared.poll(callback)
+}
+
+/*
+emitLines allows you to report every complete line after the current
+offset. When the final flag is set, a last line without a newline is
+reported too.
+*/
+func (shared *followerType) emitLines(callback func(FollowedLine) error, isFinal bool) error {
+	scanner := getLineScanner(shared.file, nil, LineScannerOptions{MaximumLineLength: shared.options.MaximumLineLength, StartOffset: shared.offset})
+	for scanner.Scan() {
+		if !scanner.isDelimited && !isFinal {
+			break
+		}
+		var line FollowedLine
+		line.Text = scanner.GetText()
+		line.Offset = scanner.GetOffset()
+		line.NextOffset = scanner.GetNextOffset()
+		if err := callback(line); err != nil {
+			return err
+		}
+		shared.offset = line.NextOffset
+	}
+	return scanner.GetError()
+}
+
+/*
+open allows you to open the followed file. A start offset beyond the end of
+the file is reset to the beginning, since the file must have been truncated
+or replaced.
+*/
+func (shared *followerType) open() error {
+	file, err := shared.fileSystem.backend.OpenFile(shared.fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	shared.file = file
+	shared.fileInfo = fileInfo
+	if shared.offset > fileInfo.Size() {
+		shared.offset = 0
+	}
+	return nil
+}
+
+/*
+close allows you to close the followed file, if it is open.
+*/
+func (shared *followerType) close() {
+	if shared.file != nil {
+		shared.file.Close()
+		shared.file = nil
+	}
+}
+
+/*
+isSameFile allows you to check if two file information records describe the
+same file. In addition to 'os.SameFile', records from backends which
+identify files by a pointer in 'Sys' are compared by that pointer.
+*/
+func isSameFile(firstFileInfo os.FileInfo, secondFileInfo os.FileInfo) bool {
+	if os.SameFile(firstFileInfo, secondFileInfo) {
+		return true
+	}
+	firstSys := firstFileInfo.Sys()
+	if firstSys == nil || reflect.ValueOf(firstSys).Kind() != reflect.Ptr {
+		return false
+	}
+	return firstSys == secondFileInfo.Sys()
+}
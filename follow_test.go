@@ -0,0 +1,81 @@
+package filesystem
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+/*
+receiveFollowedLines allows you to wait for a number of lines to arrive on
+a follow channel, failing the test if they take too long.
+*/
+func receiveFollowedLines(test *testing.T, lines <-chan FollowedLine, count int) []string {
+	var receivedLines []string
+	for len(receivedLines) < count {
+		select {
+		case line := <-lines:
+			receivedLines = append(receivedLines, line.Text)
+		case <-time.After(2 * time.Second):
+			assert.Failf(test, "Timed out waiting for followed lines.", "Only received %q.", receivedLines)
+			return receivedLines
+		}
+	}
+	return receivedLines
+}
+
+func TestFollowRotationAndTruncation(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/app.log", []byte("Existing line.\n"), 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines, errs := fileSystem.GetFollowChannel(ctx, "/app.log", FollowOptions{IsStartedAtEnd: true, PollInterval: time.Millisecond})
+	fileSystem.AppendLineToFile("/app.log", "First ", 0)
+	fileSystem.AppendLineToFile("/app.log", "line.\n", 0)
+	assert.Equalf(test, []string{"First line."}, receiveFollowedLines(test, lines, 1), "Only complete lines appended after following began were expected.")
+	fileSystem.AppendLineToFile("/app.log", "Unterminated line.", 0)
+	fileSystem.RenameFile("/app.log", "/app.log.1")
+	fileSystem.WriteBytesToFile("/app.log", []byte("Line in new file.\n"), 0)
+	assert.Equalf(test, []string{"Unterminated line.", "Line in new file."}, receiveFollowedLines(test, lines, 2), "The rest of the rotated file was expected before the new file.")
+	fileSystem.WriteBytesToFile("/app.log", []byte("Truncated.\n"), 0)
+	assert.Equalf(test, []string{"Truncated."}, receiveFollowedLines(test, lines, 1), "Following was expected to restart after the file was truncated.")
+	cancel()
+	_, isOpen := <-lines
+	assert.Falsef(test, isOpen, "The line channel was expected to close once the context was cancelled.")
+	assert.NoErrorf(test, <-errs, "An error was not expected when following was cancelled.")
+}
+
+func TestFollowFromSavedOffset(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/app.log", []byte("Seen.\nNot seen yet.\n"), 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var followedLines []FollowedLine
+	err := fileSystem.Follow(ctx, "/app.log", FollowOptions{StartOffset: 6, PollInterval: time.Millisecond}, func(line FollowedLine) error {
+		followedLines = append(followedLines, line)
+		cancel()
+		return nil
+	})
+	assert.NoErrorf(test, err, "An error was not expected when following a file.")
+	assert.Equalf(test, []FollowedLine{{Text: "Not seen yet.", Offset: 6, NextOffset: 20}}, followedLines, "Following was expected to resume from the saved offset.")
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	lines, _ := fileSystem.GetFollowChannel(ctx, "/missing.log", FollowOptions{PollInterval: time.Millisecond})
+	fileSystem.WriteBytesToFile("/missing.log", []byte("Created later.\n"), 0)
+	assert.Equalf(test, []string{"Created later."}, receiveFollowedLines(test, lines, 1), "Following was expected to wait for a missing file to appear.")
+}
+
+func TestFollowRotationOnDisk(test *testing.T) {
+	fileName := "/tmp/follow.log"
+	DeleteFile(fileName)
+	DeleteFile(fileName + ".1")
+	WriteBytesToFile(fileName, []byte("Existing line.\n"), 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines, _ := GetFollowChannel(ctx, fileName, FollowOptions{PollInterval: time.Millisecond})
+	assert.Equalf(test, []string{"Existing line."}, receiveFollowedLines(test, lines, 1), "Existing lines were expected when following from the start.")
+	RenameFile(fileName, fileName+".1")
+	WriteBytesToFile(fileName, []byte("Line in new file.\n"), 0)
+	assert.Equalf(test, []string{"Line in new file."}, receiveFollowedLines(test, lines, 1), "Following was expected to switch to the recreated file.")
+}
@@ -1,6 +1,6 @@
 module github.com/supercom32/filesystem
 
-go 1.15
+go 1.16
 
 require (
 	github.com/kr/pretty v0.3.1 // indirect
@@ -0,0 +1,435 @@
+package filesystem
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type ioFSType struct {
+	fileSystem    *fileSystemType
+	directoryPath string
+}
+
+type ioFSFileType struct {
+	File
+	ioFS            *ioFSType
+	name            string
+	directoryOffset int
+}
+
+type ioFSDirectoryEntryType struct {
+	fileInfo os.FileInfo
+}
+
+type ioFSBackendType struct {
+	fileSystem fs.FS
+}
+
+type ioFSBackendFileType struct {
+	fileSystem fs.FS
+	name       string
+	file       fs.File
+	offset     int64
+}
+
+/*
+GetIoFS allows you to expose a directory of a file system instance as a
+standard 'io/fs' file system. The returned value implements 'fs.FS',
+'fs.ReadDirFS', 'fs.ReadFileFS' and 'fs.StatFS', so it can be used with
+functions such as 'http.FS', 'template.ParseFS' or 'fs.WalkDir'.
+*/
+func (shared *fileSystemType) GetIoFS(directoryPath string) fs.FS {
+	var ioFS ioFSType
+	ioFS.fileSystem = shared
+	ioFS.directoryPath = directoryPath
+	return &ioFS
+}
+
+/*
+getBackendPath allows you to convert an 'io/fs' path into a path on the
+backend, validating it in the process.
+*/
+func (shared *ioFSType) getBackendPath(operation string, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: operation, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(shared.directoryPath, filepath.FromSlash(name)), nil
+}
+
+/*
+translateError allows you to report backend errors using the 'io/fs' path
+the caller supplied.
+*/
+func (shared *ioFSType) translateError(err error, operation string, name string) error {
+	if pathError, isPathError := err.(*os.PathError); isPathError {
+		return &fs.PathError{Op: operation, Path: name, Err: pathError.Err}
+	}
+	return err
+}
+
+func (shared *ioFSType) Open(name string) (fs.File, error) {
+	backendPath, err := shared.getBackendPath("open", name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := shared.fileSystem.backend.OpenFile(backendPath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, shared.translateError(err, "open", name)
+	}
+	return &ioFSFileType{File: file, ioFS: shared, name: name}, nil
+}
+
+func (shared *ioFSType) ReadDir(name string) ([]fs.DirEntry, error) {
+	backendPath, err := shared.getBackendPath("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := shared.fileSystem.backend.ReadDir(backendPath)
+	if err != nil {
+		return nil, shared.translateError(err, "readdir", name)
+	}
+	return getDirectoryEntries(entries), nil
+}
+
+func (shared *ioFSType) ReadFile(name string) ([]byte, error) {
+	backendPath, err := shared.getBackendPath("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	fileContents, err := shared.fileSystem.readFile(backendPath)
+	if err != nil {
+		return nil, shared.translateError(err, "readfile", name)
+	}
+	return fileContents, nil
+}
+
+func (shared *ioFSType) Stat(name string) (fs.FileInfo, error) {
+	backendPath, err := shared.getBackendPath("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := shared.fileSystem.backend.Stat(backendPath)
+	if err != nil {
+		return nil, shared.translateError(err, "stat", name)
+	}
+	return fileInfo, nil
+}
+
+/*
+getDirectoryEntries allows you to convert a list of file attributes into a
+list of 'io/fs' directory entries.
+*/
+func getDirectoryEntries(entries []os.FileInfo) []fs.DirEntry {
+	directoryEntries := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		directoryEntries = append(directoryEntries, &ioFSDirectoryEntryType{fileInfo: entry})
+	}
+	return directoryEntries
+}
+
+func (shared *ioFSFileType) ReadDir(count int) ([]fs.DirEntry, error) {
+	entries, err := shared.ioFS.ReadDir(shared.name)
+	if err != nil {
+		return nil, err
+	}
+	remainingEntries := entries[minimumInt(shared.directoryOffset, len(entries)):]
+	if count <= 0 {
+		shared.directoryOffset += len(remainingEntries)
+		return remainingEntries, nil
+	}
+	if len(remainingEntries) == 0 {
+		return nil, io.EOF
+	}
+	remainingEntries = remainingEntries[:minimumInt(count, len(remainingEntries))]
+	shared.directoryOffset += len(remainingEntries)
+	return remainingEntries, nil
+}
+
+func (shared *ioFSDirectoryEntryType) Name() string {
+	return shared.fileInfo.Name()
+}
+
+func (shared *ioFSDirectoryEntryType) IsDir() bool {
+	return shared.fileInfo.IsDir()
+}
+
+func (shared *ioFSDirectoryEntryType) Type() fs.FileMode {
+	return shared.fileInfo.Mode().Type()
+}
+
+func (shared *ioFSDirectoryEntryType) Info() (fs.FileInfo, error) {
+	return shared.fileInfo, nil
+}
+
+/*
+GetIoFSBackend allows you to use any standard 'io/fs' file system, such as
+'embed.FS' or 'zip.Reader', as a read-only backend. In addition, the
+following information should be noted:
+
+- Paths may be provided in either relative or absolute form, and are all
+resolved from the root of the 'io/fs' file system.
+
+- Any operation which would modify the file system fails with a permission
+error.
+*/
+func GetIoFSBackend(fileSystem fs.FS) Backend {
+	var backend ioFSBackendType
+	backend.fileSystem = fileSystem
+	return &backend
+}
+
+/*
+getIoFSPath allows you to convert a backend path into a valid 'io/fs' path.
+*/
+func getIoFSPath(operation string, name string) (string, error) {
+	ioFSPath := path.Clean(strings.TrimLeft(filepath.ToSlash(name), "/"))
+	if ioFSPath == "" || !fs.ValidPath(ioFSPath) {
+		return "", &os.PathError{Op: operation, Path: name, Err: os.ErrNotExist}
+	}
+	return ioFSPath, nil
+}
+
+/*
+translateError allows you to report 'io/fs' errors using the path the
+caller supplied to the backend.
+*/
+func (shared *ioFSBackendType) translateError(err error, operation string, name string) error {
+	if pathError, isPathError := err.(*fs.PathError); isPathError {
+		return &os.PathError{Op: operation, Path: name, Err: pathError.Err}
+	}
+	return err
+}
+
+/*
+getReadOnlyError allows you to obtain the error returned by every operation
+which would modify a read-only backend.
+*/
+func getReadOnlyError(operation string, name string) error {
+	return &os.PathError{Op: operation, Path: name, Err: os.ErrPermission}
+}
+
+func (shared *ioFSBackendType) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, getReadOnlyError("open", name)
+	}
+	ioFSPath, err := getIoFSPath("open", name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := shared.fileSystem.Open(ioFSPath)
+	if err != nil {
+		return nil, shared.translateError(err, "open", name)
+	}
+	return &ioFSBackendFileType{fileSystem: shared.fileSystem, name: name, file: file}, nil
+}
+
+func (shared *ioFSBackendType) Stat(name string) (os.FileInfo, error) {
+	ioFSPath, err := getIoFSPath("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := fs.Stat(shared.fileSystem, ioFSPath)
+	if err != nil {
+		return nil, shared.translateError(err, "stat", name)
+	}
+	return fileInfo, nil
+}
+
+func (shared *ioFSBackendType) Lstat(name string) (os.FileInfo, error) {
+	return shared.Stat(name)
+}
+
+func (shared *ioFSBackendType) ReadDir(name string) ([]os.FileInfo, error) {
+	ioFSPath, err := getIoFSPath("open", name)
+	if err != nil {
+		return nil, err
+	}
+	directoryEntries, err := fs.ReadDir(shared.fileSystem, ioFSPath)
+	if err != nil {
+		return nil, shared.translateError(err, "open", name)
+	}
+	entries := make([]os.FileInfo, 0, len(directoryEntries))
+	for _, directoryEntry := range directoryEntries {
+		fileInfo, err := directoryEntry.Info()
+		if err != nil {
+			return nil, shared.translateError(err, "open", name)
+		}
+		entries = append(entries, fileInfo)
+	}
+	return entries, nil
+}
+
+func (shared *ioFSBackendType) Rename(oldName string, newName string) error {
+	return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: os.ErrPermission}
+}
+
+func (shared *ioFSBackendType) Remove(name string) error {
+	return getReadOnlyError("remove", name)
+}
+
+func (shared *ioFSBackendType) RemoveAll(name string) error {
+	return getReadOnlyError("unlinkat", name)
+}
+
+func (shared *ioFSBackendType) Mkdir(name string, perm os.FileMode) error {
+	return getReadOnlyError("mkdir", name)
+}
+
+func (shared *ioFSBackendType) MkdirAll(name string, perm os.FileMode) error {
+	return getReadOnlyError("mkdir", name)
+}
+
+func (shared *ioFSBackendType) Chmod(name string, mode os.FileMode) error {
+	return getReadOnlyError("chmod", name)
+}
+
+func (shared *ioFSBackendType) Chown(name string, uid int, gid int) error {
+	return getReadOnlyError("chown", name)
+}
+
+func (shared *ioFSBackendType) Chtimes(name string, accessTime time.Time, modificationTime time.Time) error {
+	return getReadOnlyError("chtimes", name)
+}
+
+func (shared *ioFSBackendType) Symlink(oldName string, newName string) error {
+	return &os.LinkError{Op: "symlink", Old: oldName, New: newName, Err: os.ErrPermission}
+}
+
+func (shared *ioFSBackendType) Readlink(name string) (string, error) {
+	return "", &os.PathError{Op: "readlink", Path: name, Err: syscall.EINVAL}
+}
+
+func (shared *ioFSBackendFileType) Name() string {
+	return shared.name
+}
+
+func (shared *ioFSBackendFileType) Read(buffer []byte) (int, error) {
+	bytesRead, err := shared.file.Read(buffer)
+	shared.offset += int64(bytesRead)
+	return bytesRead, err
+}
+
+/*
+ReadAt allows you to read from a specific offset of the file. Files which
+do not support random access natively are reopened and read sequentially
+up to the requested offset.
+*/
+func (shared *ioFSBackendFileType) ReadAt(buffer []byte, offset int64) (int, error) {
+	if readerAt, isReaderAt := shared.file.(io.ReaderAt); isReaderAt {
+		return readerAt.ReadAt(buffer, offset)
+	}
+	ioFSPath, err := getIoFSPath("read", shared.name)
+	if err != nil {
+		return 0, err
+	}
+	file, err := shared.fileSystem.Open(ioFSPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	_, err = io.CopyN(ioutil.Discard, file, offset)
+	if err != nil {
+		return 0, err
+	}
+	bytesRead, err := io.ReadFull(file, buffer)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return bytesRead, err
+}
+
+/*
+Seek allows you to change the read offset of the file. Files which do not
+support seeking natively are reopened and read sequentially up to the
+requested offset.
+*/
+func (shared *ioFSBackendFileType) Seek(offset int64, whence int) (int64, error) {
+	if seeker, isSeeker := shared.file.(io.Seeker); isSeeker {
+		newOffset, err := seeker.Seek(offset, whence)
+		if err == nil {
+			shared.offset = newOffset
+		}
+		return newOffset, err
+	}
+	newOffset := offset
+	switch whence {
+	case io.SeekCurrent:
+		newOffset += shared.offset
+	case io.SeekEnd:
+		fileInfo, err := shared.file.Stat()
+		if err != nil {
+			return 0, err
+		}
+		newOffset += fileInfo.Size()
+	}
+	if newOffset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: shared.name, Err: syscall.EINVAL}
+	}
+	if newOffset < shared.offset {
+		ioFSPath, err := getIoFSPath("seek", shared.name)
+		if err != nil {
+			return 0, err
+		}
+		file, err := shared.fileSystem.Open(ioFSPath)
+		if err != nil {
+			return 0, err
+		}
+		shared.file.Close()
+		shared.file = file
+		shared.offset = 0
+	}
+	bytesSkipped, err := io.CopyN(ioutil.Discard, shared.file, newOffset-shared.offset)
+	shared.offset += bytesSkipped
+	if err != nil && err != io.EOF {
+		return shared.offset, err
+	}
+	return newOffset, nil
+}
+
+func (shared *ioFSBackendFileType) Write(buffer []byte) (int, error) {
+	return 0, getReadOnlyError("write", shared.name)
+}
+
+func (shared *ioFSBackendFileType) WriteAt(buffer []byte, offset int64) (int, error) {
+	return 0, getReadOnlyError("write", shared.name)
+}
+
+func (shared *ioFSBackendFileType) WriteString(stringToWrite string) (int, error) {
+	return 0, getReadOnlyError("write", shared.name)
+}
+
+func (shared *ioFSBackendFileType) Truncate(size int64) error {
+	return getReadOnlyError("truncate", shared.name)
+}
+
+func (shared *ioFSBackendFileType) Close() error {
+	return shared.file.Close()
+}
+
+func (shared *ioFSBackendFileType) Stat() (os.FileInfo, error) {
+	return shared.file.Stat()
+}
+
+func (shared *ioFSBackendFileType) Sync() error {
+	return nil
+}
+
+func (shared *ioFSBackendFileType) Readdirnames(count int) ([]string, error) {
+	directoryFile, isDirectoryFile := shared.file.(fs.ReadDirFile)
+	if !isDirectoryFile {
+		return nil, &os.PathError{Op: "readdirent", Path: shared.name, Err: syscall.ENOTDIR}
+	}
+	directoryEntries, err := directoryFile.ReadDir(count)
+	var names []string
+	for _, directoryEntry := range directoryEntries {
+		names = append(names, directoryEntry.Name())
+	}
+	return names, err
+}
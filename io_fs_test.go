@@ -0,0 +1,69 @@
+package filesystem
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGetIoFS(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.CreateDirectory("/site/css", 0)
+	fileSystem.WriteBytesToFile("/site/index.html", []byte("<html></html>"), 0644)
+	fileSystem.WriteBytesToFile("/site/css/style.css", []byte("body {}"), 0644)
+	ioFS := fileSystem.GetIoFS("/site")
+	err := fstest.TestFS(ioFS, "index.html", "css/style.css")
+	assert.NoErrorf(test, err, "The io/fs adapter was expected to behave like a standard file system.")
+	var walkedPaths []string
+	err = fs.WalkDir(ioFS, ".", func(path string, directoryEntry fs.DirEntry, err error) error {
+		walkedPaths = append(walkedPaths, path)
+		return err
+	})
+	assert.NoErrorf(test, err, "An error was not expected when walking the file system.")
+	assert.Equalf(test, []string{".", "css", "css/style.css", "index.html"}, walkedPaths, "The walked paths were not as expected.")
+	_, err = fs.ReadFile(ioFS, "../outside.txt")
+	assert.Errorf(test, err, "Invalid io/fs paths were expected to be rejected.")
+}
+
+func TestGetIoFSBackend(test *testing.T) {
+	mapFS := fstest.MapFS{
+		"config/app.conf":   {Data: []byte("mode=production\nport=80\n")},
+		"config/other.conf": {Data: []byte("mode=testing\n")},
+		"readme.txt":        {Data: []byte("This is a readme.")},
+	}
+	fileSystem := GetFileSystem(GetIoFSBackend(mapFS))
+	obtainedValue, err := fileSystem.GetListOfDirectoryContents("/config/", []string{"\\.conf$"}, true, false)
+	assert.NoErrorf(test, err, "An error was not expected when listing a directory.")
+	assert.Equalf(test, []string{"app.conf", "other.conf"}, obtainedValue, "The directory listing was not as expected.")
+	obtainedValue, err = fileSystem.FindMatchingContent(".", []string{"\\.conf$"}, true, false, true)
+	assert.NoErrorf(test, err, "An error was not expected when searching a directory.")
+	assert.Equalf(test, []string{"config/app.conf", "config/other.conf"}, obtainedValue, "The matching content was not as expected.")
+	isMatched, err := fileSystem.IsFileContainsText("config/app.conf", "port=\\d+")
+	assert.NoErrorf(test, err, "An error was not expected when searching a file.")
+	assert.Truef(test, isMatched, "The file was expected to contain the text.")
+	fileContents, err := fileSystem.GetFileContents("config/app.conf")
+	assert.NoErrorf(test, err, "An error was not expected when reading a file.")
+	assert.Equalf(test, "mode=production\nport=80", string(fileContents), "The file contents were not as expected.")
+	err = fileSystem.WriteBytesToFile("readme.txt", []byte("changed"), 0)
+	assert.Truef(test, os.IsPermission(err), "Writing to a read-only backend was expected to be denied.")
+	err = fileSystem.DeleteFile("readme.txt")
+	assert.Truef(test, os.IsPermission(err), "Deleting from a read-only backend was expected to be denied.")
+}
+
+func TestGetIoFSBackendFromZip(test *testing.T) {
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	fileWriter, _ := zipWriter.Create("docs/notes.txt")
+	fileWriter.Write([]byte("First line.\nSecond line.\n"))
+	zipWriter.Close()
+	zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	assert.NoErrorf(test, err, "An error was not expected when reading a zip archive.")
+	fileSystem := GetFileSystem(GetIoFSBackend(zipReader))
+	fileContents, err := fileSystem.GetFileContents("docs/notes.txt")
+	assert.NoErrorf(test, err, "An error was not expected when reading a file from a zip archive.")
+	assert.Equalf(test, "First line.\nSecond line.", string(fileContents), "The file contents were not as expected.")
+}
@@ -0,0 +1,468 @@
+package filesystem
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+/*
+maximumEditedLineLength is the longest line, in bytes, which the line
+editing methods will accept. Lines are held in memory one at a time, so this
+is far more generous than the default used when scanning.
+*/
+const maximumEditedLineLength = 1024 * 1024 * 1024
+
+type lineEditorType struct {
+	writer       *bufio.Writer
+	lineEnding   string
+	isTerminated bool
+	isChanged    bool
+}
+
+/*
+writeLine allows you to write a new line using the line ending of the file
+being edited. If the line written before it was never terminated, it is
+terminated first.
+*/
+func (shared *lineEditorType) writeLine(line string) error {
+	if !shared.isTerminated {
+		if _, err := shared.writer.WriteString(shared.lineEnding); err != nil {
+			return err
+		}
+	}
+	_, err := shared.writer.WriteString(line + shared.lineEnding)
+	shared.isTerminated = true
+	shared.isChanged = true
+	return err
+}
+
+/*
+copyLine allows you to write a line from the original file unchanged,
+including its original line ending.
+*/
+func (shared *lineEditorType) copyLine(line []byte, isDelimited bool) error {
+	if _, err := shared.writer.Write(line); err != nil {
+		return err
+	}
+	shared.isTerminated = isDelimited
+	if isDelimited {
+		return shared.writer.WriteByte('\n')
+	}
+	return nil
+}
+
+/*
+createTemporaryFile allows you to create a new, empty file in the same
+directory as the file provided, so that it can later be renamed over it.
+*/
+func createTemporaryFile(backend Backend, fileName string, perm os.FileMode) (File, string, error) {
+	directoryPath, baseName := filepath.Split(fileName)
+	for attempt := 0; ; attempt++ {
+		suffix := strconv.FormatInt(time.Now().UnixNano(), 36) + strconv.Itoa(attempt)
+		temporaryFileName := filepath.Join(directoryPath, "."+baseName+"."+suffix+".tmp")
+		file, err := backend.OpenFile(temporaryFileName, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if err == nil || !os.IsExist(err) || attempt >= 100 {
+			return file, temporaryFileName, err
+		}
+	}
+}
+
+/*
+resolveFinalLink allows you to follow a final symbolic link, and any links
+it leads to, so that the file which is really being edited can be replaced.
+A path which is not a link, or which does not exist, is returned unchanged.
+*/
+func resolveFinalLink(backend Backend, fileName string) (string, error) {
+	for linkCount := 0; linkCount <= maximumSymlinkDepth; linkCount++ {
+		fileInfo, err := backend.Lstat(fileName)
+		if err != nil || fileInfo.Mode()&os.ModeSymlink == 0 {
+			return fileName, nil
+		}
+		linkTarget, err := backend.Readlink(fileName)
+		if err != nil {
+			return "", err
+		}
+		if !isAbsoluteUserPath(linkTarget) {
+			linkTarget = filepath.Join(filepath.Dir(fileName), linkTarget)
+		}
+		fileName = linkTarget
+	}
+	return "", &os.PathError{Op: "open", Path: fileName, Err: syscall.ELOOP}
+}
+
+/*
+overwriteFile allows you to copy the contents of one file over those of
+another in place, keeping the identity of the file being overwritten.
+*/
+func overwriteFile(backend Backend, sourceFileName string, targetFileName string) error {
+	source, err := backend.OpenFile(sourceFileName, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := backend.OpenFile(targetFileName, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(target, source)
+	if err == nil {
+		err = target.Sync()
+	}
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+/*
+rewriteLines allows you to stream every line of a file through an edit
+function into a temporary file, which then atomically replaces the original.
+The edit function is given each line with its delimiter removed but any
+carriage return kept, and the finish function is called once every line has
+been seen. The original file is only replaced if the editor was marked as
+changed. The permissions of the original file are preserved.
+*/
+func (shared *fileSystemType) rewriteLines(operation string, fileName string, options rewriteOptionsType, edit func(editor *lineEditorType, lineIndex int64, line []byte, isDelimited bool) error, finish func(editor *lineEditorType, lineCount int64) error) error {
+	err := shared.rewriteFile(fileName, options, func(source File, target File) (bool, error) {
+		var editor lineEditorType
+		editor.writer = bufio.NewWriter(target)
+		editor.lineEnding = "\n"
+		editor.isTerminated = true
+		scanner := getLineScanner(source, nil, LineScannerOptions{IsCarriageReturnKept: true, MaximumLineLength: maximumEditedLineLength})
+		var lineIndex int64
+		for ; scanner.Scan(); lineIndex++ {
+			line := scanner.GetLine()
+			if lineIndex == 0 && strings.HasSuffix(string(line), "\r") {
+				editor.lineEnding = "\r\n"
+			}
+			if err := edit(&editor, lineIndex, line, scanner.isDelimited); err != nil {
+				return false, err
+			}
+		}
+		if scanner.GetError() != nil {
+			return false, scanner.GetError()
+		}
+		if err := finish(&editor, lineIndex); err != nil {
+			return false, err
+		}
+		return editor.isChanged, editor.writer.Flush()
+	})
+	if err != nil {
+		if _, isOpError := err.(*OpError); !isOpError {
+			err = &OpError{Op: operation, Path: fileName, Err: err}
+		}
+	}
+	return err
+}
+
+/*
+rewriteOptionsType allows you to control which attributes of the original
+file are carried over when a file is rewritten, in addition to its
+permissions.
+*/
+type rewriteOptionsType struct {
+	isOwnerPreserved     bool
+	isTimestampPreserved bool
+}
+
+/*
+rewriteFile allows you to replace the contents of a file by copying it into
+a temporary file in the same directory and renaming the result over the
+original. The original file is left untouched if anything goes wrong or
+the rewrite reports that nothing changed, and the permissions of the
+original file are preserved. In addition, the following information should
+be noted:
+
+- If the file is a symbolic link, the file it points to is rewritten and
+the link is left in place.
+
+- If the file has more than one hard link, the result is copied back over
+the original instead of being renamed, so that every link sees the change.
+This copy is not atomic.
+*/
+func (shared *fileSystemType) rewriteFile(fileName string, options rewriteOptionsType, rewrite func(source File, target File) (bool, error)) error {
+	fileName, err := resolveFinalLink(shared.backend, fileName)
+	if err != nil {
+		return err
+	}
+	source, err := shared.backend.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	fileInfo, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	if !fileInfo.Mode().IsRegular() {
+		return ErrNotRegularFile
+	}
+	target, temporaryFileName, err := createTemporaryFile(shared.backend, fileName, fileInfo.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	isChanged, err := rewrite(source, target)
+	if err == nil && !isChanged {
+		target.Close()
+		return shared.backend.Remove(temporaryFileName)
+	}
+	if err == nil {
+		err = target.Sync()
+	}
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && getSystemLinkCount(fileInfo) > 1 {
+		err = overwriteFile(shared.backend, temporaryFileName, fileName)
+		if err == nil && options.isTimestampPreserved {
+			err = shared.backend.Chtimes(fileName, time.Now(), fileInfo.ModTime())
+		}
+		shared.backend.Remove(temporaryFileName)
+		return err
+	}
+	if err == nil {
+		err = shared.backend.Chmod(temporaryFileName, fileInfo.Mode())
+	}
+	if uid, gid, isOwnerKnown := getFileOwner(fileInfo); err == nil && options.isOwnerPreserved && isOwnerKnown {
+		err = shared.backend.Chown(temporaryFileName, uid, gid)
+	}
+	if err == nil && options.isTimestampPreserved {
+		err = shared.backend.Chtimes(temporaryFileName, time.Now(), fileInfo.ModTime())
+	}
+	if err == nil {
+		err = shared.backend.Rename(temporaryFileName, fileName)
+	}
+	if err != nil {
+		shared.backend.Remove(temporaryFileName)
+		return err
+	}
+	syncDirectory(shared.backend, filepath.Dir(fileName))
+	return nil
+}
+
+/*
+getFileOwner allows you to obtain the user and group which own a file, if
+the backend it belongs to reports them.
+*/
+func getFileOwner(fileInfo os.FileInfo) (int, int, bool) {
+	if node, isNode := fileInfo.Sys().(*memoryNodeType); isNode {
+		return node.uid, node.gid, true
+	}
+	return getSystemFileOwner(fileInfo)
+}
+
+/*
+InsertLineIntoFile allows you to insert a line into a file so that it ends
+up at the zero-based line index provided. An index equal to the number of
+lines in the file appends the line to the end. Since the file is streamed
+through a temporary file, this works for files larger than memory.
+*/
+func (shared *fileSystemType) InsertLineIntoFile(fileName string, lineIndex int64, lineToInsert string) error {
+	return shared.rewriteLines("insert", fileName, rewriteOptionsType{}, func(editor *lineEditorType, currentIndex int64, line []byte, isDelimited bool) error {
+		if currentIndex == lineIndex {
+			if err := editor.writeLine(lineToInsert); err != nil {
+				return err
+			}
+		}
+		return editor.copyLine(line, isDelimited)
+	}, func(editor *lineEditorType, lineCount int64) error {
+		if lineIndex < 0 || lineIndex > lineCount {
+			return ErrLineOutOfRange
+		}
+		if lineIndex == lineCount {
+			return editor.writeLine(lineToInsert)
+		}
+		return nil
+	})
+}
+
+/*
+ReplaceLineInFile allows you to replace the line at the zero-based line
+index provided with a new line. Since the file is streamed through a
+temporary file, this works for files larger than memory.
+*/
+func (shared *fileSystemType) ReplaceLineInFile(fileName string, lineIndex int64, replacementLine string) error {
+	return shared.rewriteLines("replace", fileName, rewriteOptionsType{}, func(editor *lineEditorType, currentIndex int64, line []byte, isDelimited bool) error {
+		if currentIndex != lineIndex {
+			return editor.copyLine(line, isDelimited)
+		}
+		editor.isChanged = true
+		if isDelimited {
+			return editor.writeLine(replacementLine)
+		}
+		return editor.copyLine([]byte(replacementLine), false)
+	}, func(editor *lineEditorType, lineCount int64) error {
+		if lineIndex < 0 || lineIndex >= lineCount {
+			return ErrLineOutOfRange
+		}
+		return nil
+	})
+}
+
+/*
+DeleteLinesFromFile allows you to delete a range of lines from a file,
+starting at the zero-based line index provided. If fewer lines remain than
+requested, every line from the start index onwards is deleted. Since the
+file is streamed through a temporary file, this works for files larger than
+memory.
+*/
+func (shared *fileSystemType) DeleteLinesFromFile(fileName string, startIndex int64, count int64) error {
+	return shared.rewriteLines("delete", fileName, rewriteOptionsType{}, func(editor *lineEditorType, currentIndex int64, line []byte, isDelimited bool) error {
+		if currentIndex >= startIndex && currentIndex-startIndex < count {
+			editor.isChanged = true
+			return nil
+		}
+		return editor.copyLine(line, isDelimited)
+	}, func(editor *lineEditorType, lineCount int64) error {
+		if startIndex < 0 || count < 0 || startIndex >= lineCount {
+			return ErrLineOutOfRange
+		}
+		return nil
+	})
+}
+
+/*
+AppendLinesToFile allows you to append several lines to the end of a file,
+each terminated with a newline. If the file does not end with a newline,
+one is added first so that the existing last line is kept intact. If you
+pass in a permissions value of '0', the default value of 744 will be used
+instead.
+*/
+func (shared *fileSystemType) AppendLinesToFile(fileName string, linesToAppend []string, permissions int) error {
+	if permissions == 0 {
+		permissions = 0744
+	}
+	file, err := shared.backend.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, os.FileMode(uint32(permissions)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = appendLines(file, linesToAppend)
+	if err != nil {
+		return &OpError{Op: "append", Path: fileName, Err: err}
+	}
+	return nil
+}
+
+/*
+appendLines allows you to append lines to a file opened for appending,
+terminating the existing last line first if needed.
+*/
+func appendLines(file File, linesToAppend []string) error {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	var builder strings.Builder
+	if fileInfo.Size() > 0 {
+		lastByte := make([]byte, 1)
+		if _, err := file.ReadAt(lastByte, fileInfo.Size()-1); err != nil {
+			return err
+		}
+		if lastByte[0] != '\n' {
+			builder.WriteString("\n")
+		}
+	}
+	for _, line := range linesToAppend {
+		builder.WriteString(line + "\n")
+	}
+	if _, err := file.WriteString(builder.String()); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+/*
+InsertLineAt allows you to insert a line into an open file so that it ends
+up at the zero-based line index provided. The file is rewritten through a
+temporary file and then reopened, after which the file offset is at the
+start of the file.
+*/
+func (shared *fileInstanceType) InsertLineAt(lineIndex int64, lineToInsert string) error {
+	return shared.rewrite("insert", func(fileSystem *fileSystemType) error {
+		return fileSystem.InsertLineIntoFile(shared.fileName, lineIndex, lineToInsert)
+	})
+}
+
+/*
+ReplaceLineAt allows you to replace the line of an open file at the
+zero-based line index provided. The file is rewritten through a temporary
+file and then reopened, after which the file offset is at the start of the
+file.
+*/
+func (shared *fileInstanceType) ReplaceLineAt(lineIndex int64, replacementLine string) error {
+	return shared.rewrite("replace", func(fileSystem *fileSystemType) error {
+		return fileSystem.ReplaceLineInFile(shared.fileName, lineIndex, replacementLine)
+	})
+}
+
+/*
+DeleteLines allows you to delete a range of lines from an open file,
+starting at the zero-based line index provided. The file is rewritten
+through a temporary file and then reopened, after which the file offset is
+at the start of the file.
+*/
+func (shared *fileInstanceType) DeleteLines(startIndex int64, count int64) error {
+	return shared.rewrite("delete", func(fileSystem *fileSystemType) error {
+		return fileSystem.DeleteLinesFromFile(shared.fileName, startIndex, count)
+	})
+}
+
+/*
+AppendLines allows you to append several lines to the end of an open file,
+each terminated with a newline. Unlike the other line editing methods, the
+lines are appended in place.
+*/
+func (shared *fileInstanceType) AppendLines(linesToAppend []string) error {
+	if err := shared.checkWritable("append"); err != nil {
+		return err
+	}
+	if _, err := shared.fileDescriptor.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	err := appendLines(shared.fileDescriptor, linesToAppend)
+	if err != nil {
+		return &OpError{Op: "append", Path: shared.fileName, Err: err}
+	}
+	return nil
+}
+
+/*
+checkWritable allows you to verify that a file instance has a file open
+for writing.
+*/
+func (shared *fileInstanceType) checkWritable(operation string) error {
+	if err := shared.checkOpen(operation); err != nil {
+		return err
+	}
+	if shared.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return &OpError{Op: operation, Path: shared.fileName, Err: os.ErrPermission}
+	}
+	return nil
+}
+
+/*
+rewrite allows you to apply a path based edit to the file a file instance
+has open. Pending writes are synchronized first, and afterwards the file is
+reopened so that the instance refers to the edited file.
+*/
+func (shared *fileInstanceType) rewrite(operation string, edit func(fileSystem *fileSystemType) error) error {
+	if err := shared.checkWritable(operation); err != nil {
+		return err
+	}
+	if err := shared.fileDescriptor.Sync(); err != nil {
+		return err
+	}
+	if err := edit(shared.getFileSystem()); err != nil {
+		return err
+	}
+	shared.fileDescriptor.Close()
+	shared.fileDescriptor = nil
+	return shared.open(shared.fileName, shared.flag&^(os.O_CREATE|os.O_EXCL|os.O_TRUNC), shared.perm)
+}
@@ -0,0 +1,99 @@
+package filesystem
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestLineEditingInFile(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/config.txt", []byte("Line 0\r\nLine 1\r\nLine 2"), 0)
+	err := fileSystem.InsertLineIntoFile("/config.txt", 1, "Inserted")
+	assert.NoErrorf(test, err, "An error was not expected when inserting a line.")
+	err = fileSystem.ReplaceLineInFile("/config.txt", 0, "Replaced")
+	assert.NoErrorf(test, err, "An error was not expected when replacing a line.")
+	err = fileSystem.InsertLineIntoFile("/config.txt", 4, "Last")
+	assert.NoErrorf(test, err, "An error was not expected when inserting a line at the end.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/config.txt")
+	assert.Equalf(test, "Replaced\r\nInserted\r\nLine 1\r\nLine 2\r\nLast\r\n", string(fileContents), "The line endings of the file were expected to be kept.")
+	err = fileSystem.DeleteLinesFromFile("/config.txt", 1, 2)
+	assert.NoErrorf(test, err, "An error was not expected when deleting lines.")
+	fileContents, _ = fileSystem.GetFileContentsAsBytes("/config.txt")
+	assert.Equalf(test, "Replaced\r\nLine 2\r\nLast\r\n", string(fileContents), "The range of lines was not deleted as expected.")
+	err = fileSystem.ReplaceLineInFile("/config.txt", 3, "Missing")
+	assert.Truef(test, errors.Is(err, ErrLineOutOfRange), "Replacing a line past the end was expected to return ErrLineOutOfRange.")
+	err = fileSystem.DeleteLinesFromFile("/config.txt", 3, 1)
+	assert.Truef(test, errors.Is(err, ErrLineOutOfRange), "Deleting lines past the end was expected to return ErrLineOutOfRange.")
+	fileContents, _ = fileSystem.GetFileContentsAsBytes("/config.txt")
+	assert.Equalf(test, "Replaced\r\nLine 2\r\nLast\r\n", string(fileContents), "A failed edit was expected to leave the file untouched.")
+	fileSystem.WriteBytesToFile("/list.txt", []byte("Unterminated"), 0)
+	err = fileSystem.AppendLinesToFile("/list.txt", []string{"First", "Second"}, 0)
+	assert.NoErrorf(test, err, "An error was not expected when appending lines.")
+	fileContents, _ = fileSystem.GetFileContentsAsBytes("/list.txt")
+	assert.Equalf(test, "Unterminated\nFirst\nSecond\n", string(fileContents), "The existing last line was expected to be terminated before appending.")
+}
+
+func TestLineEditingOnFileInstance(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	file := fileSystem.GetFileInstance()
+	file.Open("/file.txt", 0)
+	file.AppendLines([]string{"First", "Third"})
+	err := file.InsertLineAt(1, "Second")
+	assert.NoErrorf(test, err, "An error was not expected when inserting a line.")
+	err = file.WriteLine("Fourth")
+	assert.NoErrorf(test, err, "The file instance was expected to remain usable after an edit.")
+	err = file.DeleteLines(0, 1)
+	assert.NoErrorf(test, err, "An error was not expected when deleting a line.")
+	err = file.ReplaceLineAt(2, "Last")
+	assert.NoErrorf(test, err, "An error was not expected when replacing a line.")
+	fileContents, _ := file.GetFileContents()
+	assert.Equalf(test, "Second\nThird\nLast", string(fileContents), "The edited file was not as expected.")
+	file.Close()
+	file.OpenWithOptions("/file.txt", OpenOptions{IsReadOnly: true})
+	err = file.InsertLineAt(0, "Rejected")
+	assert.Truef(test, errors.Is(err, os.ErrPermission), "Editing a file opened read-only was expected to fail.")
+	file.Close()
+}
+
+func TestLineEditingKeepsPermissions(test *testing.T) {
+	fileName := "/tmp/line_editor.txt"
+	DeleteFile(fileName)
+	WriteBytesToFile(fileName, []byte("First\nSecond\n"), 0600)
+	err := InsertLineIntoFile(fileName, 0, "Zeroth")
+	assert.NoErrorf(test, err, "An error was not expected when inserting a line.")
+	fileInfo, _ := os.Stat(fileName)
+	assert.Equalf(test, os.FileMode(0600), fileInfo.Mode().Perm(), "The permissions of the file were expected to be kept.")
+	matches, _ := GetListOfFiles("/tmp", `^\.line_editor\.txt\..*\.tmp$`)
+	assert.Emptyf(test, matches, "No temporary files were expected to be left behind.")
+}
+
+func TestLineEditingThroughLinks(test *testing.T) {
+	fileName := "/tmp/line_editor_target.txt"
+	linkName := "/tmp/line_editor_link.txt"
+	hardLinkName := "/tmp/line_editor_hard_link.txt"
+	for _, name := range []string{fileName, linkName, hardLinkName} {
+		os.Remove(name)
+	}
+	WriteBytesToFile(fileName, []byte("First\nSecond\n"), 0644)
+	err := os.Symlink("line_editor_target.txt", linkName)
+	assert.NoErrorf(test, err, "An error was not expected when creating a symbolic link.")
+	err = InsertLineIntoFile(linkName, 0, "Zeroth")
+	assert.NoErrorf(test, err, "An error was not expected when inserting a line through a symbolic link.")
+	fileInfo, _ := os.Lstat(linkName)
+	assert.Truef(test, fileInfo.Mode()&os.ModeSymlink != 0, "The symbolic link was expected to remain a link.")
+	fileContents, _ := GetFileContentsAsBytes(fileName)
+	assert.Equalf(test, "Zeroth\nFirst\nSecond\n", string(fileContents), "The target of the symbolic link was expected to be edited.")
+	err = os.Link(fileName, hardLinkName)
+	assert.NoErrorf(test, err, "An error was not expected when creating a hard link.")
+	err = DeleteLinesFromFile(fileName, 0, 1)
+	assert.NoErrorf(test, err, "An error was not expected when deleting a line from a hard linked file.")
+	fileContents, _ = GetFileContentsAsBytes(hardLinkName)
+	assert.Equalf(test, "First\nSecond\n", string(fileContents), "Every hard link was expected to see the edit.")
+	matches, _ := GetListOfFiles("/tmp", `^\.line_editor_target\.txt\..*\.tmp$`)
+	assert.Emptyf(test, matches, "No temporary files were expected to be left behind.")
+	for _, name := range []string{fileName, linkName, hardLinkName} {
+		os.Remove(name)
+	}
+}
@@ -0,0 +1,288 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"os"
+)
+
+/*
+defaultMaximumLineLength is the longest line, in bytes, a line scanner will
+accept when no maximum is configured.
+*/
+const defaultMaximumLineLength = 1024 * 1024
+
+/*
+LineScannerOptions allows you to control how a line scanner splits a file
+into lines. In addition, the following information should be noted:
+
+- If you pass in a maximum line length of '0', a default of 1 MiB is used.
+Lines longer than the maximum stop the scan with 'ErrLineTooLong'.
+
+- If no delimiter is provided, lines are split on "\n".
+
+- When splitting on "\n", a trailing "\r" is removed from every line unless
+'IsCarriageReturnKept' is set.
+
+- The start offset allows a scan to resume from a byte offset previously
+obtained from 'GetNextOffset'.
+*/
+type LineScannerOptions struct {
+	MaximumLineLength    int
+	Delimiter            []byte
+	IsCarriageReturnKept bool
+	StartOffset          int64
+}
+
+type lineScannerType struct {
+	reader      *bufio.Reader
+	closer      io.Closer
+	options     LineScannerOptions
+	buffer      []byte
+	line        []byte
+	lineNumber  int64
+	offset      int64
+	nextOffset  int64
+	err         error
+	isDelimited bool
+	isFinished  bool
+}
+
+/*
+getLineScanner allows you to create a line scanner which reads from a
+random access source starting at the offset configured in the options.
+*/
+func getLineScanner(source io.ReaderAt, closer io.Closer, options LineScannerOptions) *lineScannerType {
+	if options.MaximumLineLength <= 0 {
+		options.MaximumLineLength = defaultMaximumLineLength
+	}
+	if len(options.Delimiter) == 0 {
+		options.Delimiter = []byte("\n")
+	}
+	var scanner lineScannerType
+	sectionReader := io.NewSectionReader(source, options.StartOffset, math.MaxInt64-options.StartOffset)
+	scanner.reader = bufio.NewReaderSize(sectionReader, 64*1024)
+	scanner.closer = closer
+	scanner.options = options
+	scanner.offset = options.StartOffset
+	scanner.nextOffset = options.StartOffset
+	return &scanner
+}
+
+/*
+GetLineScanner allows you to stream the lines of an open file one at a time
+without loading the whole file into memory. Scanning uses positional reads,
+so the current offset of the file instance is not affected.
+*/
+func (shared *fileInstanceType) GetLineScanner(options LineScannerOptions) (*lineScannerType, error) {
+	if err := shared.checkOpen("scan"); err != nil {
+		return nil, err
+	}
+	return getLineScanner(shared.fileDescriptor, nil, options), nil
+}
+
+/*
+GetLineScannerFromFile allows you to stream the lines of a file one at a
+time without loading the whole file into memory. The scanner must be closed
+once you are finished with it.
+*/
+func (shared *fileSystemType) GetLineScannerFromFile(fileName string, options LineScannerOptions) (*lineScannerType, error) {
+	file, err := shared.backend.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	return getLineScanner(file, file, options), nil
+}
+
+/*
+Scan allows you to advance the scanner to the next line. False is returned
+once there are no more lines or an error occurs, in which case 'GetError'
+reports what went wrong.
+*/
+func (shared *lineScannerType) Scan() bool {
+	if shared.err != nil || shared.isFinished {
+		return false
+	}
+	delimiter := shared.options.Delimiter
+	lastDelimiterByte := delimiter[len(delimiter)-1]
+	rawLine := shared.buffer[:0]
+	isDelimited := false
+	for {
+		chunk, err := shared.reader.ReadSlice(lastDelimiterByte)
+		rawLine = append(rawLine, chunk...)
+		if err == nil && bytes.HasSuffix(rawLine, delimiter) {
+			isDelimited = true
+			break
+		}
+		if len(rawLine) > shared.options.MaximumLineLength+len(delimiter) {
+			shared.err = &OpError{Op: "scan", Err: ErrLineTooLong}
+			return false
+		}
+		if err == nil || err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		shared.err = err
+		return false
+	}
+	shared.buffer = rawLine
+	if len(rawLine) == 0 {
+		shared.isFinished = true
+		return false
+	}
+	shared.offset = shared.nextOffset
+	shared.nextOffset += int64(len(rawLine))
+	shared.lineNumber++
+	line := rawLine
+	if isDelimited {
+		line = line[:len(line)-len(delimiter)]
+	}
+	if !shared.options.IsCarriageReturnKept && bytes.Equal(delimiter, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+	}
+	if len(line) > shared.options.MaximumLineLength {
+		shared.err = &OpError{Op: "scan", Err: ErrLineTooLong}
+		return false
+	}
+	shared.line = line
+	shared.isDelimited = isDelimited
+	return true
+}
+
+/*
+GetLine allows you to obtain the current line without its delimiter. The
+returned slice is only valid until the next call to 'Scan'.
+*/
+func (shared *lineScannerType) GetLine() []byte {
+	return shared.line
+}
+
+/*
+GetText allows you to obtain the current line as a string.
+*/
+func (shared *lineScannerType) GetText() string {
+	return string(shared.line)
+}
+
+/*
+GetLineNumber allows you to obtain the number of the current line. The
+first line scanned is line number 1.
+*/
+func (shared *lineScannerType) GetLineNumber() int64 {
+	return shared.lineNumber
+}
+
+/*
+GetOffset allows you to obtain the byte offset within the file at which the
+current line starts.
+*/
+func (shared *lineScannerType) GetOffset() int64 {
+	return shared.offset
+}
+
+/*
+GetNextOffset allows you to obtain the byte offset within the file at which
+the next line starts. This value can be used to resume scanning later.
+*/
+func (shared *lineScannerType) GetNextOffset() int64 {
+	return shared.nextOffset
+}
+
+/*
+GetError allows you to obtain the error which stopped the scan, if any.
+*/
+func (shared *lineScannerType) GetError() error {
+	return shared.err
+}
+
+/*
+Close allows you to release the file held by a scanner which was obtained
+from a path. Closing a scanner obtained from a file instance leaves the
+file instance open.
+*/
+func (shared *lineScannerType) Close() error {
+	if shared.closer == nil {
+		return nil
+	}
+	err := shared.closer.Close()
+	shared.closer = nil
+	return err
+}
+
+/*
+GetLines allows you to obtain a range of lines from an open file, starting
+at the zero-based line index provided. Fewer lines are returned if the end
+of the file is reached first.
+*/
+func (shared *fileInstanceType) GetLines(startIndex int64, count int) ([]string, error) {
+	scanner, err := shared.GetLineScanner(LineScannerOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return getLinesFromScanner(scanner, startIndex, count)
+}
+
+/*
+CountLines allows you to count the number of lines in an open file. A final
+line which is not followed by a newline is still counted.
+*/
+func (shared *fileInstanceType) CountLines() (int64, error) {
+	scanner, err := shared.GetLineScanner(LineScannerOptions{})
+	if err != nil {
+		return 0, err
+	}
+	return countLinesFromScanner(scanner)
+}
+
+/*
+GetLinesFromFile allows you to obtain a range of lines from a file, starting
+at the zero-based line index provided, without loading the whole file into
+memory.
+*/
+func (shared *fileSystemType) GetLinesFromFile(fileName string, startIndex int64, count int) ([]string, error) {
+	scanner, err := shared.GetLineScannerFromFile(fileName, LineScannerOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer scanner.Close()
+	return getLinesFromScanner(scanner, startIndex, count)
+}
+
+/*
+CountLinesInFile allows you to count the number of lines in a file without
+loading the whole file into memory.
+*/
+func (shared *fileSystemType) CountLinesInFile(fileName string) (int64, error) {
+	scanner, err := shared.GetLineScannerFromFile(fileName, LineScannerOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer scanner.Close()
+	return countLinesFromScanner(scanner)
+}
+
+/*
+getLinesFromScanner allows you to collect a range of lines from a scanner.
+*/
+func getLinesFromScanner(scanner *lineScannerType, startIndex int64, count int) ([]string, error) {
+	var lines []string
+	for len(lines) < count && scanner.Scan() {
+		if scanner.GetLineNumber() > startIndex {
+			lines = append(lines, scanner.GetText())
+		}
+	}
+	return lines, scanner.GetError()
+}
+
+/*
+countLinesFromScanner allows you to count every remaining line of a scanner.
+*/
+func countLinesFromScanner(scanner *lineScannerType) (int64, error) {
+	for scanner.Scan() {
+	}
+	return scanner.GetLineNumber(), scanner.GetError()
+}
@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestLineScannerOffsets(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/file.txt", []byte("First line.\r\nSecond line.\n\nLast line"), 0)
+	scanner, err := fileSystem.GetLineScannerFromFile("/file.txt", LineScannerOptions{})
+	assert.NoErrorf(test, err, "An error was not expected when creating a line scanner.")
+	defer scanner.Close()
+	var lines []string
+	var offsets []int64
+	for scanner.Scan() {
+		lines = append(lines, scanner.GetText())
+		offsets = append(offsets, scanner.GetOffset())
+	}
+	assert.NoErrorf(test, scanner.GetError(), "An error was not expected when scanning lines.")
+	assert.Equalf(test, []string{"First line.", "Second line.", "", "Last line"}, lines, "The scanned lines were not as expected.")
+	assert.Equalf(test, []int64{0, 13, 26, 27}, offsets, "The line offsets were not as expected.")
+	assert.Equalf(test, int64(4), scanner.GetLineNumber(), "The final line number was not as expected.")
+	assert.Equalf(test, int64(36), scanner.GetNextOffset(), "The next offset was expected to be the end of the file.")
+}
+
+func TestLineScannerOptions(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/records.txt", []byte("alpha||beta\r||gamma||"), 0)
+	scanner, _ := fileSystem.GetLineScannerFromFile("/records.txt", LineScannerOptions{Delimiter: []byte("||")})
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.GetText())
+	}
+	scanner.Close()
+	assert.Equalf(test, []string{"alpha", "beta\r", "gamma"}, lines, "Lines were expected to be split on the custom delimiter.")
+	scanner, _ = fileSystem.GetLineScannerFromFile("/records.txt", LineScannerOptions{Delimiter: []byte("||"), StartOffset: 7})
+	assert.Truef(test, scanner.Scan(), "A line was expected when resuming from an offset.")
+	assert.Equalf(test, "beta\r", scanner.GetText(), "Scanning was expected to resume from the offset provided.")
+	scanner.Close()
+	fileSystem.WriteBytesToFile("/long.txt", []byte(strings.Repeat("x", 100)+"\nshort\n"), 0)
+	scanner, _ = fileSystem.GetLineScannerFromFile("/long.txt", LineScannerOptions{MaximumLineLength: 50})
+	assert.Falsef(test, scanner.Scan(), "A line longer than the maximum was expected to stop the scan.")
+	assert.Truef(test, errors.Is(scanner.GetError(), ErrLineTooLong), "A line longer than the maximum was expected to return ErrLineTooLong.")
+	scanner.Close()
+}
+
+func TestFileInstanceLineHelpers(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	file := fileSystem.GetFileInstance()
+	err := file.Open("/file.txt", 0)
+	assert.NoErrorf(test, err, "An error was not expected when opening a file.")
+	for _, line := range []string{"Line 0", "Line 1", "Line 2", "Line 3", "Line 4"} {
+		file.WriteLine(line)
+	}
+	lines, err := file.GetLines(1, 2)
+	assert.NoErrorf(test, err, "An error was not expected when getting lines.")
+	assert.Equalf(test, []string{"Line 1", "Line 2"}, lines, "The range of lines was not as expected.")
+	lines, err = file.GetLines(4, 10)
+	assert.NoErrorf(test, err, "An error was not expected when getting lines.")
+	assert.Equalf(test, []string{"Line 4"}, lines, "Only the remaining lines were expected to be returned.")
+	lineCount, err := file.CountLines()
+	assert.NoErrorf(test, err, "An error was not expected when counting lines.")
+	assert.Equalf(test, int64(5), lineCount, "The number of lines was not as expected.")
+	lines, err = file.GetLastNLines(3)
+	assert.NoErrorf(test, err, "An error was not expected when getting the last lines.")
+	assert.Equalf(test, []string{"Line 2", "Line 3", "Line 4"}, lines, "The last lines were not as expected.")
+	file.Close()
+	lines, err = fileSystem.GetLinesFromFile("/file.txt", 0, 1)
+	assert.NoErrorf(test, err, "An error was not expected when getting lines from a file.")
+	assert.Equalf(test, []string{"Line 0"}, lines, "The first line was not as expected.")
+	lineCount, err = fileSystem.CountLinesInFile("/file.txt")
+	assert.NoErrorf(test, err, "An error was not expected when counting lines in a file.")
+	assert.Equalf(test, int64(5), lineCount, "The number of lines was not as expected.")
+}
@@ -0,0 +1,802 @@
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+/*
+maximumSymlinkDepth is the number of symbolic links which can be followed
+while resolving a single path before the lookup is abandoned.
+*/
+const maximumSymlinkDepth = 40
+
+type memoryNodeType struct {
+	mode             os.FileMode
+	modificationTime time.Time
+	data             []byte
+	children         map[string]*memoryNodeType
+	linkTarget       string
+	uid              int
+	gid              int
+}
+
+type memoryBackendType struct {
+	mutex sync.RWMutex
+	root  *memoryNodeType
+}
+
+type memoryFileType struct {
+	backend         *memoryBackendType
+	node            *memoryNodeType
+	name            string
+	flag            int
+	offset          int64
+	directoryOffset int
+	isClosed        bool
+}
+
+type memoryFileInfoType struct {
+	name             string
+	size             int64
+	mode             os.FileMode
+	modificationTime time.Time
+	node             *memoryNodeType
+}
+
+/*
+GetMemoryBackend allows you to obtain a backend which keeps all files and
+directories in memory. Every backend obtained this way starts out empty and
+is completely independent from the local file system and from other memory
+backends, which makes it ideal for hermetic tests and ephemeral data. All
+relative paths are resolved against the root directory of the backend.
+*/
+func GetMemoryBackend() Backend {
+	var backend memoryBackendType
+	backend.root = newMemoryDirectoryNode(os.ModePerm)
+	return &backend
+}
+
+/*
+newMemoryDirectoryNode allows you to create a new, empty directory node.
+*/
+func newMemoryDirectoryNode(perm os.FileMode) *memoryNodeType {
+	var node memoryNodeType
+	node.mode = os.ModeDir | (perm & os.ModePerm)
+	node.modificationTime = time.Now()
+	node.children = make(map[string]*memoryNodeType)
+	return &node
+}
+
+/*
+splitMemoryPath allows you to break a path into its individual components.
+Empty and '.' components are discarded while '..' components are kept so
+they can be evaluated during symbolic link resolution.
+*/
+func splitMemoryPath(path string) []string {
+	var components []string
+	for _, component := range strings.Split(filepath.ToSlash(path), "/") {
+		if component == "" || component == "." {
+			continue
+		}
+		components = append(components, component)
+	}
+	return components
+}
+
+/*
+lookupComponents allows you to find the node located at an already resolved
+list of path components. Nil is returned if no such node exists.
+*/
+func (shared *memoryBackendType) lookupComponents(components []string) *memoryNodeType {
+	node := shared.root
+	for _, component := range components {
+		if !node.mode.IsDir() {
+			return nil
+		}
+		child, isFound := node.children[component]
+		if !isFound {
+			return nil
+		}
+		node = child
+	}
+	return node
+}
+
+/*
+resolvePath allows you to convert a path into a list of components which
+contains no symbolic links, except optionally for the final component.
+Components which do not exist are passed through as-is.
+*/
+func (shared *memoryBackendType) resolvePath(name string, isFinalLinkFollowed bool) ([]string, error) {
+	components := splitMemoryPath(name)
+	var resolved []string
+	linkCount := 0
+	for index := 0; index < len(components); index++ {
+		component := components[index]
+		if component == ".." {
+			if len(resolved) > 0 {
+				resolved = resolved[:len(resolved)-1]
+			}
+			continue
+		}
+		candidate := append(append([]string{}, resolved...), component)
+		node := shared.lookupComponents(candidate)
+		isLastComponent := index == len(components)-1
+		if node != nil && node.mode&os.ModeSymlink != 0 && (!isLastComponent || isFinalLinkFollowed) {
+			linkCount++
+			if linkCount > maximumSymlinkDepth {
+				return nil, syscall.ELOOP
+			}
+			remainingComponents := components[index+1:]
+			if strings.HasPrefix(filepath.ToSlash(node.linkTarget), "/") {
+				resolved = nil
+			}
+			components = append(splitMemoryPath(node.linkTarget), remainingComponents...)
+			index = -1
+			continue
+		}
+		resolved = candidate
+	}
+	return resolved, nil
+}
+
+/*
+lookup allows you to find the node for a given path along with the node of
+its parent directory and its base name.
+*/
+func (shared *memoryBackendType) lookup(name string, isFinalLinkFollowed bool) (*memoryNodeType, *memoryNodeType, string, error) {
+	components, err := shared.resolvePath(name, isFinalLinkFollowed)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if len(components) == 0 {
+		return shared.root, nil, "/", nil
+	}
+	parent := shared.lookupComponents(components[:len(components)-1])
+	if parent == nil {
+		return nil, nil, "", os.ErrNotExist
+	}
+	if !parent.mode.IsDir() {
+		return nil, nil, "", syscall.ENOTDIR
+	}
+	baseName := components[len(components)-1]
+	return parent.children[baseName], parent, baseName, nil
+}
+
+/*
+getFileInfo allows you to take a snapshot of a node's attributes.
+*/
+func (shared *memoryNodeType) getFileInfo(name string) os.FileInfo {
+	var fileInfo memoryFileInfoType
+	fileInfo.name = name
+	fileInfo.mode = shared.mode
+	fileInfo.modificationTime = shared.modificationTime
+	fileInfo.node = shared
+	if shared.mode&os.ModeSymlink != 0 {
+		fileInfo.size = int64(len(shared.linkTarget))
+	} else {
+		fileInfo.size = int64(len(shared.data))
+	}
+	return &fileInfo
+}
+
+func (shared *memoryBackendType) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	isExclusive := flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0
+	node, parent, baseName, err := shared.lookup(name, !isExclusive)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	accessMode := flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	isWritable := accessMode == os.O_WRONLY || accessMode == os.O_RDWR
+	if node != nil {
+		if isExclusive {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+		}
+		if node.mode.IsDir() && isWritable {
+			return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+		}
+		if !node.mode.IsDir() {
+			if accessMode != os.O_WRONLY && node.mode&0400 == 0 {
+				return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+			}
+			if isWritable && node.mode&0200 == 0 {
+				return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+			}
+		}
+		if flag&os.O_TRUNC != 0 && isWritable {
+			node.data = nil
+			node.modificationTime = time.Now()
+		}
+	} else {
+		if flag&os.O_CREATE == 0 {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		node = &memoryNodeType{mode: perm & os.ModePerm, modificationTime: time.Now()}
+		parent.children[baseName] = node
+		parent.modificationTime = node.modificationTime
+	}
+	var file memoryFileType
+	file.backend = shared
+	file.node = node
+	file.name = name
+	file.flag = flag
+	return &file, nil
+}
+
+func (shared *memoryBackendType) Stat(name string) (os.FileInfo, error) {
+	shared.mutex.RLock()
+	defer shared.mutex.RUnlock()
+	node, _, baseName, err := shared.lookup(name, true)
+	if err == nil && node == nil {
+		err = os.ErrNotExist
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return node.getFileInfo(baseName), nil
+}
+
+func (shared *memoryBackendType) Lstat(name string) (os.FileInfo, error) {
+	shared.mutex.RLock()
+	defer shared.mutex.RUnlock()
+	node, _, baseName, err := shared.lookup(name, false)
+	if err == nil && node == nil {
+		err = os.ErrNotExist
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	return node.getFileInfo(baseName), nil
+}
+
+func (shared *memoryBackendType) ReadDir(name string) ([]os.FileInfo, error) {
+	shared.mutex.RLock()
+	defer shared.mutex.RUnlock()
+	node, _, _, err := shared.lookup(name, true)
+	if err == nil && node == nil {
+		err = os.ErrNotExist
+	}
+	if err == nil && !node.mode.IsDir() {
+		err = syscall.ENOTDIR
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return node.getDirectoryEntries(), nil
+}
+
+/*
+getDirectoryEntries allows you to obtain the attributes of every child of
+a directory node, sorted by name.
+*/
+func (shared *memoryNodeType) getDirectoryEntries() []os.FileInfo {
+	var names []string
+	for childName := range shared.children {
+		names = append(names, childName)
+	}
+	sort.Strings(names)
+	entries := make([]os.FileInfo, 0, len(names))
+	for _, childName := range names {
+		entries = append(entries, shared.children[childName].getFileInfo(childName))
+	}
+	return entries
+}
+
+func (shared *memoryBackendType) Rename(oldName string, newName string) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	sourceComponents, err := shared.resolvePath(oldName, false)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+	}
+	targetComponents, err := shared.resolvePath(newName, false)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+	}
+	sourceNode, sourceParent, sourceBaseName, err := shared.lookup(oldName, false)
+	if err == nil && sourceNode == nil {
+		err = os.ErrNotExist
+	}
+	if err == nil && sourceParent == nil {
+		err = syscall.EBUSY
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+	}
+	targetNode, targetParent, targetBaseName, err := shared.lookup(newName, false)
+	if err == nil && targetParent == nil {
+		err = syscall.EBUSY
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+	}
+	if targetNode == sourceNode {
+		return nil
+	}
+	if sourceNode.mode.IsDir() && isPathComponentPrefix(sourceComponents, targetComponents) {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: syscall.EINVAL}
+	}
+	if targetNode != nil {
+		if targetNode.mode.IsDir() && !sourceNode.mode.IsDir() {
+			return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: syscall.EISDIR}
+		}
+		if !targetNode.mode.IsDir() && sourceNode.mode.IsDir() {
+			return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: syscall.ENOTDIR}
+		}
+		if targetNode.mode.IsDir() && len(targetNode.children) > 0 {
+			return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: syscall.ENOTEMPTY}
+		}
+	}
+	delete(sourceParent.children, sourceBaseName)
+	targetParent.children[targetBaseName] = sourceNode
+	now := time.Now()
+	sourceParent.modificationTime = now
+	targetParent.modificationTime = now
+	return nil
+}
+
+/*
+isPathComponentPrefix allows you to check if one list of path components
+is the same as, or an ancestor of, another.
+*/
+func isPathComponentPrefix(prefix []string, components []string) bool {
+	if len(prefix) > len(components) {
+		return false
+	}
+	for index := range prefix {
+		if prefix[index] != components[index] {
+			return false
+		}
+	}
+	return true
+}
+
+func (shared *memoryBackendType) Remove(name string) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	node, parent, baseName, err := shared.lookup(name, false)
+	if err == nil && node == nil {
+		err = os.ErrNotExist
+	}
+	if err == nil && parent == nil {
+		err = syscall.EBUSY
+	}
+	if err == nil && node.mode.IsDir() && len(node.children) > 0 {
+		err = syscall.ENOTEMPTY
+	}
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: err}
+	}
+	delete(parent.children, baseName)
+	parent.modificationTime = time.Now()
+	return nil
+}
+
+func (shared *memoryBackendType) RemoveAll(name string) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	node, parent, baseName, err := shared.lookup(name, false)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return &os.PathError{Op: "unlinkat", Path: name, Err: err}
+	}
+	if node == nil {
+		return nil
+	}
+	if parent == nil {
+		return &os.PathError{Op: "unlinkat", Path: name, Err: syscall.EBUSY}
+	}
+	delete(parent.children, baseName)
+	parent.modificationTime = time.Now()
+	return nil
+}
+
+func (shared *memoryBackendType) Mkdir(name string, perm os.FileMode) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	node, parent, baseName, err := shared.lookup(name, false)
+	if err == nil && node != nil {
+		err = os.ErrExist
+	}
+	if err != nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	parent.children[baseName] = newMemoryDirectoryNode(perm)
+	parent.modificationTime = time.Now()
+	return nil
+}
+
+func (shared *memoryBackendType) MkdirAll(name string, perm os.FileMode) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	components, err := shared.resolvePath(name, true)
+	if err != nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	node := shared.root
+	for _, component := range components {
+		child, isFound := node.children[component]
+		if !isFound {
+			child = newMemoryDirectoryNode(perm)
+			node.children[component] = child
+			node.modificationTime = child.modificationTime
+		}
+		if !child.mode.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+		}
+		node = child
+	}
+	return nil
+}
+
+/*
+getExistingNode allows you to obtain the node at a path while holding the
+backend lock, returning a path error if it does not exist.
+*/
+func (shared *memoryBackendType) getExistingNode(operation string, name string, isFinalLinkFollowed bool) (*memoryNodeType, error) {
+	node, _, _, err := shared.lookup(name, isFinalLinkFollowed)
+	if err == nil && node == nil {
+		err = os.ErrNotExist
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: operation, Path: name, Err: err}
+	}
+	return node, nil
+}
+
+func (shared *memoryBackendType) Chmod(name string, mode os.FileMode) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	node, err := shared.getExistingNode("chmod", name, true)
+	if err != nil {
+		return err
+	}
+	node.mode = (node.mode &^ os.ModePerm) | (mode & os.ModePerm)
+	return nil
+}
+
+func (shared *memoryBackendType) Chown(name string, uid int, gid int) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	node, err := shared.getExistingNode("chown", name, true)
+	if err != nil {
+		return err
+	}
+	if uid != -1 {
+		node.uid = uid
+	}
+	if gid != -1 {
+		node.gid = gid
+	}
+	return nil
+}
+
+func (shared *memoryBackendType) Chtimes(name string, accessTime time.Time, modificationTime time.Time) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	node, err := shared.getExistingNode("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	node.modificationTime = modificationTime
+	return nil
+}
+
+func (shared *memoryBackendType) Symlink(oldName string, newName string) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	node, parent, baseName, err := shared.lookup(newName, false)
+	if err == nil && node != nil {
+		err = os.ErrExist
+	}
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldName, New: newName, Err: err}
+	}
+	var link memoryNodeType
+	link.mode = os.ModeSymlink | os.ModePerm
+	link.modificationTime = time.Now()
+	link.linkTarget = oldName
+	parent.children[baseName] = &link
+	parent.modificationTime = link.modificationTime
+	return nil
+}
+
+func (shared *memoryBackendType) Readlink(name string) (string, error) {
+	shared.mutex.RLock()
+	defer shared.mutex.RUnlock()
+	node, err := shared.getExistingNode("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if node.mode&os.ModeSymlink == 0 {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: syscall.EINVAL}
+	}
+	return node.linkTarget, nil
+}
+
+/*
+checkOpen allows you to verify that a memory file handle has not been
+closed yet.
+*/
+func (shared *memoryFileType) checkOpen(operation string) error {
+	if shared.isClosed {
+		return &os.PathError{Op: operation, Path: shared.name, Err: os.ErrClosed}
+	}
+	return nil
+}
+
+/*
+checkReadable allows you to verify that a memory file handle may be read
+from.
+*/
+func (shared *memoryFileType) checkReadable(operation string) error {
+	if err := shared.checkOpen(operation); err != nil {
+		return err
+	}
+	if shared.flag&(os.O_RDONLY|os.O_WRONLY|os.O_RDWR) == os.O_WRONLY {
+		return &os.PathError{Op: operation, Path: shared.name, Err: syscall.EBADF}
+	}
+	if shared.node.mode.IsDir() {
+		return &os.PathError{Op: operation, Path: shared.name, Err: syscall.EISDIR}
+	}
+	return nil
+}
+
+/*
+checkWritable allows you to verify that a memory file handle may be written
+to.
+*/
+func (shared *memoryFileType) checkWritable(operation string) error {
+	if err := shared.checkOpen(operation); err != nil {
+		return err
+	}
+	if shared.flag&(os.O_RDONLY|os.O_WRONLY|os.O_RDWR) == os.O_RDONLY {
+		return &os.PathError{Op: operation, Path: shared.name, Err: syscall.EBADF}
+	}
+	return nil
+}
+
+func (shared *memoryFileType) Name() string {
+	return shared.name
+}
+
+func (shared *memoryFileType) Read(buffer []byte) (int, error) {
+	shared.backend.mutex.Lock()
+	defer shared.backend.mutex.Unlock()
+	if err := shared.checkReadable("read"); err != nil {
+		return 0, err
+	}
+	if shared.offset >= int64(len(shared.node.data)) {
+		if len(buffer) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	bytesRead := copy(buffer, shared.node.data[shared.offset:])
+	shared.offset += int64(bytesRead)
+	return bytesRead, nil
+}
+
+func (shared *memoryFileType) ReadAt(buffer []byte, offset int64) (int, error) {
+	shared.backend.mutex.RLock()
+	defer shared.backend.mutex.RUnlock()
+	if err := shared.checkReadable("read"); err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "readat", Path: shared.name, Err: errors.New("negative offset")}
+	}
+	if offset >= int64(len(shared.node.data)) {
+		if len(buffer) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	bytesRead := copy(buffer, shared.node.data[offset:])
+	if bytesRead < len(buffer) {
+		return bytesRead, io.EOF
+	}
+	return bytesRead, nil
+}
+
+func (shared *memoryFileType) Write(buffer []byte) (int, error) {
+	shared.backend.mutex.Lock()
+	defer shared.backend.mutex.Unlock()
+	if err := shared.checkWritable("write"); err != nil {
+		return 0, err
+	}
+	if shared.flag&os.O_APPEND != 0 {
+		shared.offset = int64(len(shared.node.data))
+	}
+	shared.writeAt(buffer, shared.offset)
+	shared.offset += int64(len(buffer))
+	return len(buffer), nil
+}
+
+func (shared *memoryFileType) WriteAt(buffer []byte, offset int64) (int, error) {
+	shared.backend.mutex.Lock()
+	defer shared.backend.mutex.Unlock()
+	if err := shared.checkWritable("write"); err != nil {
+		return 0, err
+	}
+	if shared.flag&os.O_APPEND != 0 {
+		return 0, errors.New("os: invalid use of WriteAt on file opened with O_APPEND")
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "writeat", Path: shared.name, Err: errors.New("negative offset")}
+	}
+	shared.writeAt(buffer, offset)
+	return len(buffer), nil
+}
+
+/*
+writeAt allows you to place bytes at a given offset of the underlying node,
+growing it as required. The backend lock must already be held.
+*/
+func (shared *memoryFileType) writeAt(buffer []byte, offset int64) {
+	endOffset := offset + int64(len(buffer))
+	if endOffset > int64(len(shared.node.data)) {
+		if endOffset > int64(cap(shared.node.data)) {
+			grownData := make([]byte, endOffset, endOffset*2)
+			copy(grownData, shared.node.data)
+			shared.node.data = grownData
+		} else {
+			previousLength := len(shared.node.data)
+			shared.node.data = shared.node.data[:endOffset]
+			for index := previousLength; index < int(offset); index++ {
+				shared.node.data[index] = 0
+			}
+		}
+	}
+	copy(shared.node.data[offset:], buffer)
+	shared.node.modificationTime = time.Now()
+}
+
+func (shared *memoryFileType) WriteString(stringToWrite string) (int, error) {
+	return shared.Write([]byte(stringToWrite))
+}
+
+func (shared *memoryFileType) Seek(offset int64, whence int) (int64, error) {
+	shared.backend.mutex.Lock()
+	defer shared.backend.mutex.Unlock()
+	if err := shared.checkOpen("seek"); err != nil {
+		return 0, err
+	}
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = shared.offset + offset
+	case io.SeekEnd:
+		newOffset = int64(len(shared.node.data)) + offset
+	default:
+		return 0, &os.PathError{Op: "seek", Path: shared.name, Err: syscall.EINVAL}
+	}
+	if newOffset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: shared.name, Err: syscall.EINVAL}
+	}
+	shared.offset = newOffset
+	return newOffset, nil
+}
+
+func (shared *memoryFileType) Close() error {
+	shared.backend.mutex.Lock()
+	defer shared.backend.mutex.Unlock()
+	if err := shared.checkOpen("close"); err != nil {
+		return err
+	}
+	shared.isClosed = true
+	return nil
+}
+
+func (shared *memoryFileType) Stat() (os.FileInfo, error) {
+	shared.backend.mutex.RLock()
+	defer shared.backend.mutex.RUnlock()
+	if err := shared.checkOpen("stat"); err != nil {
+		return nil, err
+	}
+	return shared.node.getFileInfo(filepath.Base(shared.name)), nil
+}
+
+func (shared *memoryFileType) Sync() error {
+	shared.backend.mutex.RLock()
+	defer shared.backend.mutex.RUnlock()
+	return shared.checkOpen("sync")
+}
+
+func (shared *memoryFileType) Truncate(size int64) error {
+	shared.backend.mutex.Lock()
+	defer shared.backend.mutex.Unlock()
+	if err := shared.checkWritable("truncate"); err != nil {
+		return err
+	}
+	if size < 0 {
+		return &os.PathError{Op: "truncate", Path: shared.name, Err: syscall.EINVAL}
+	}
+	if size <= int64(len(shared.node.data)) {
+		shared.node.data = shared.node.data[:size]
+		shared.node.modificationTime = time.Now()
+		return nil
+	}
+	shared.writeAt(make([]byte, size-int64(len(shared.node.data))), int64(len(shared.node.data)))
+	return nil
+}
+
+func (shared *memoryFileType) Readdirnames(count int) ([]string, error) {
+	// The write lock is taken because the directory offset of the handle
+	// is updated, in the same way that 'Read' updates its offset.
+	shared.backend.mutex.Lock()
+	defer shared.backend.mutex.Unlock()
+	if err := shared.checkOpen("readdirent"); err != nil {
+		return nil, err
+	}
+	if !shared.node.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdirent", Path: shared.name, Err: syscall.ENOTDIR}
+	}
+	var names []string
+	for _, entry := range shared.node.getDirectoryEntries() {
+		names = append(names, entry.Name())
+	}
+	remainingNames := names[minimumInt(shared.directoryOffset, len(names)):]
+	if count <= 0 {
+		shared.directoryOffset += len(remainingNames)
+		return remainingNames, nil
+	}
+	if len(remainingNames) == 0 {
+		return nil, io.EOF
+	}
+	remainingNames = remainingNames[:minimumInt(count, len(remainingNames))]
+	shared.directoryOffset += len(remainingNames)
+	return remainingNames, nil
+}
+
+/*
+minimumInt allows you to obtain the smaller of two integers.
+*/
+func minimumInt(firstValue int, secondValue int) int {
+	if firstValue < secondValue {
+		return firstValue
+	}
+	return secondValue
+}
+
+func (shared *memoryFileInfoType) Name() string {
+	return shared.name
+}
+
+func (shared *memoryFileInfoType) Size() int64 {
+	return shared.size
+}
+
+func (shared *memoryFileInfoType) Mode() os.FileMode {
+	return shared.mode
+}
+
+func (shared *memoryFileInfoType) ModTime() time.Time {
+	return shared.modificationTime
+}
+
+func (shared *memoryFileInfoType) IsDir() bool {
+	return shared.mode.IsDir()
+}
+
+/*
+Sys returns the node backing the entry, which allows two entries to be
+compared to determine if they refer to the same file.
+*/
+func (shared *memoryFileInfoType) Sys() interface{} {
+	return shared.node
+}
@@ -0,0 +1,156 @@
+package filesystem
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMemoryFileInstance(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	filename := "/file.txt"
+	file := fileSystem.GetFileInstance()
+	err := file.Open(filename, 0)
+	assert.NoErrorf(test, err, "An error was not expected when opening a file.")
+	err = file.WriteLine("First written line.")
+	assert.NoErrorf(test, err, "An error was not expected when writing lines to a file.")
+	err = file.WriteLine("Second written line.")
+	assert.NoErrorf(test, err, "An error was not expected when writing lines to a file.")
+	file.Close()
+	err = file.Open(filename, 0)
+	assert.NoErrorf(test, err, "An error was not expected when opening a file.")
+	err = file.WriteLine("Third written line.")
+	assert.NoErrorf(test, err, "An error was not expected when writing lines to a file.")
+	fileContents, err := file.GetFileContents()
+	assert.NoErrorf(test, err, "An error was not expected when reading a file.")
+	assert.Equalf(test, "First written line.\nSecond written line.\nThird written line.", string(fileContents), "Lines were expected to be appended to the end of the file.")
+	err = file.RemoveFirstLine()
+	assert.NoErrorf(test, err, "An error was not expected when trying to remove the first line of a file.")
+	firstLine, err := file.GetFirstLine()
+	assert.NoErrorf(test, err, "An error was not expected obtaining the first line of a file.")
+	assert.Equalf(test, "Second written line.", string(firstLine), "The first line read was not as expected.")
+	file.Close()
+	assert.Falsef(test, IsFileExists(filename), "A memory backed file was not expected to exist on disk.")
+}
+
+func TestMemoryFindReplaceInFile(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileName := "/testDocument.txt"
+	fileSystem.AppendLineToFile(fileName, "This is a first test line\n", 0)
+	fileSystem.AppendLineToFile(fileName, "This is a -forth- test line\n", 0)
+	err := fileSystem.FindReplaceInFile(fileName, "-.*-", "REPLACED")
+	assert.NoErrorf(test, err, "An error was not expected when replacing text in a file.")
+	isMatched, err := fileSystem.IsFileContainsText(fileName, "REPLACED")
+	assert.NoErrorf(test, err, "An error was not expected when searching a file.")
+	assert.Truef(test, isMatched, "The word REPLACED was expected to be found in the file.")
+	fileInfo, err := fileSystem.GetBackend().Stat(fileName)
+	assert.NoErrorf(test, err, "An error was not expected when obtaining file attributes.")
+	assert.Equalf(test, os.FileMode(0744), fileInfo.Mode(), "The file permissions were expected to be preserved.")
+}
+
+func TestMemoryMoveDirectories(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	err := fileSystem.CreateDirectory("/source/sub_dir", 0)
+	assert.NoErrorf(test, err, "An error was not expected when creating a directory.")
+	err = fileSystem.WriteBytesToFile("/source/sub_dir/file.txt", []byte("sample_string"), 0666)
+	assert.NoErrorf(test, err, "An error was not expected when creating a sample file!")
+	err = fileSystem.MoveDirectories("/source", "/target/")
+	assert.NoErrorf(test, err, "An error was not expected when moving a directory.")
+	assert.Falsef(test, fileSystem.IsDirectoryExists("/source"), "The source directory was not expected to exist after a move.")
+	fileContents, err := fileSystem.GetFileContentsAsBytes("/target/sub_dir/file.txt")
+	assert.NoErrorf(test, err, "An error was not expected when reading a moved file.")
+	assert.Equalf(test, "sample_string", string(fileContents), "The moved file contents were not as expected.")
+	err = fileSystem.CreateDirectory("/other", 0)
+	assert.NoErrorf(test, err, "An error was not expected when creating a directory.")
+	err = fileSystem.MoveDirectories("/other", "/target")
+	assert.Errorf(test, err, "An error was expected when moving onto an existing directory.")
+}
+
+func TestMemoryRenameSemantics(test *testing.T) {
+	backend := GetMemoryBackend()
+	fileSystem := GetFileSystem(backend)
+	fileSystem.WriteBytesToFile("/a.txt", []byte("a"), 0)
+	fileSystem.WriteBytesToFile("/b.txt", []byte("b"), 0)
+	fileSystem.CreateDirectory("/dir/child", 0)
+	err := backend.Rename("/a.txt", "/b.txt")
+	assert.NoErrorf(test, err, "Renaming a file over another file was expected to replace it.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/b.txt")
+	assert.Equalf(test, "a", string(fileContents), "The renamed file contents were not as expected.")
+	err = backend.Rename("/b.txt", "/dir")
+	assert.Errorf(test, err, "Renaming a file over a directory was expected to fail.")
+	err = backend.Rename("/dir", "/dir/child/nested")
+	assert.Errorf(test, err, "Renaming a directory into itself was expected to fail.")
+	err = backend.Rename("/missing", "/other")
+	assert.Truef(test, os.IsNotExist(err), "Renaming a missing file was expected to report that it does not exist.")
+}
+
+func TestMemoryPermissionsAndSymlinks(test *testing.T) {
+	backend := GetMemoryBackend()
+	fileSystem := GetFileSystem(backend)
+	err := fileSystem.WriteBytesToFile("/readonly.txt", []byte("sample_string"), 0444)
+	assert.NoErrorf(test, err, "An error was not expected when creating a sample file!")
+	err = fileSystem.WriteBytesToFile("/readonly.txt", []byte("new"), 0)
+	assert.Truef(test, os.IsPermission(err), "Writing to a read-only file was expected to be denied.")
+	err = backend.Symlink("/readonly.txt", "/link.txt")
+	assert.NoErrorf(test, err, "An error was not expected when creating a symbolic link.")
+	fileContents, err := fileSystem.GetFileContentsAsBytes("/link.txt")
+	assert.NoErrorf(test, err, "An error was not expected when reading through a symbolic link.")
+	assert.Equalf(test, "sample_string", string(fileContents), "The linked file contents were not as expected.")
+	fileInfo, err := backend.Lstat("/link.txt")
+	assert.NoErrorf(test, err, "An error was not expected when obtaining link attributes.")
+	assert.Truef(test, fileInfo.Mode()&os.ModeSymlink != 0, "The link was expected to be reported as a symbolic link.")
+}
+
+func TestMemoryParallelAccess(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	var waitGroup sync.WaitGroup
+	for index := 0; index < 16; index++ {
+		waitGroup.Add(1)
+		go func(index int) {
+			defer waitGroup.Done()
+			fileSystem.AppendLineToFile("/shared.txt", fmt.Sprintf("line %d\n", index), 0)
+			fileSystem.WriteBytesToFile(fmt.Sprintf("/file_%d.txt", index), []byte("sample_string"), 0)
+		}(index)
+	}
+	waitGroup.Wait()
+	fileContents, err := fileSystem.GetFileContentsAsBytes("/shared.txt")
+	assert.NoErrorf(test, err, "An error was not expected when reading a shared file.")
+	assert.Equalf(test, 16, strings.Count(string(fileContents), "\n"), "Every appended line was expected to be present.")
+	files, err := fileSystem.GetListOfFiles("/", "^file_")
+	assert.NoErrorf(test, err, "An error was not expected when listing files.")
+	assert.Equalf(test, 16, len(files), "Every written file was expected to be present.")
+}
+
+func TestMemoryParallelReaddirnames(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.CreateDirectory("/directory", 0)
+	for index := 0; index < 64; index++ {
+		fileSystem.WriteBytesToFile(fmt.Sprintf("/directory/file_%02d.txt", index), nil, 0)
+	}
+	directory, err := fileSystem.GetBackend().OpenFile("/directory", os.O_RDONLY, 0)
+	assert.NoErrorf(test, err, "An error was not expected when opening a directory.")
+	defer directory.Close()
+	var mutex sync.Mutex
+	var waitGroup sync.WaitGroup
+	nameCount := 0
+	for index := 0; index < 8; index++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for {
+				names, err := directory.Readdirnames(1)
+				if err != nil {
+					return
+				}
+				mutex.Lock()
+				nameCount += len(names)
+				mutex.Unlock()
+			}
+		}()
+	}
+	waitGroup.Wait()
+	assert.Equalf(test, 64, nameCount, "Every directory entry was expected to be read exactly once.")
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package filesystem
+
+import (
+	"os"
+)
+
+/*
+dataSyncFlag falls back to full synchronization on platforms which do not
+support synchronizing file data on its own.
+*/
+const dataSyncFlag = os.O_SYNC
+
+/*
+noFollowFlag is not available on this platform, so symbolic links are only
+rejected by checking the path before it is opened.
+*/
+const noFollowFlag = 0
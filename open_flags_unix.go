@@ -0,0 +1,20 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package filesystem
+
+import (
+	"syscall"
+)
+
+/*
+dataSyncFlag is the open flag used to request that only file data, and not
+metadata, is synchronized on every write.
+*/
+const dataSyncFlag = syscall.O_DSYNC
+
+/*
+noFollowFlag is the open flag used to refuse to follow a symbolic link in
+the final element of a path.
+*/
+const noFollowFlag = syscall.O_NOFOLLOW
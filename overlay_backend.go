@@ -0,0 +1,749 @@
+package filesystem
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type overlayBackendType struct {
+	mutex        sync.Mutex
+	base         Backend
+	upper        Backend
+	whiteouts    map[string]bool
+	writtenPaths map[string]bool
+}
+
+/*
+overlayFileInfoType reports the attributes of the entry a symbolic link
+resolved to under the name of the link, as 'os.Stat' does.
+*/
+type overlayFileInfoType struct {
+	os.FileInfo
+	name string
+}
+
+type overlayDirectoryType struct {
+	File
+	backend         *overlayBackendType
+	name            string
+	directoryOffset int
+}
+
+/*
+GetOverlayBackend allows you to obtain a copy-on-write backend which
+combines a read-only base layer with a writable upper layer. In addition,
+the following information should be noted:
+
+- Reads fall through to the base layer for any entry which has not been
+modified.
+
+- All writes, renames and permission changes are performed against a copy
+of the entry in the upper layer. The base layer is never modified until
+'Commit' is called.
+
+- Deleted base entries are hidden using whiteouts, so directory listings
+reflect the merged view of both layers.
+
+- The upper layer should be a separate namespace from the base layer, such
+as a memory backend.
+*/
+func GetOverlayBackend(base Backend, upper Backend) *overlayBackendType {
+	var backend overlayBackendType
+	backend.base = base
+	backend.upper = upper
+	backend.whiteouts = make(map[string]bool)
+	backend.writtenPaths = make(map[string]bool)
+	return &backend
+}
+
+/*
+getOverlayKey allows you to obtain the canonical form of a path used to
+track whiteouts and written entries.
+*/
+func getOverlayKey(name string) string {
+	return filepath.Clean(name)
+}
+
+/*
+isHidden allows you to check if a path, or any of its parents, has been
+deleted from the base layer by a whiteout.
+*/
+func (shared *overlayBackendType) isHidden(name string) bool {
+	path := getOverlayKey(name)
+	for {
+		if shared.whiteouts[path] {
+			return true
+		}
+		parentPath := filepath.Dir(path)
+		if parentPath == path {
+			return false
+		}
+		path = parentPath
+	}
+}
+
+/*
+isInUpper allows you to check if an entry already exists in the upper layer.
+*/
+func (shared *overlayBackendType) isInUpper(name string) bool {
+	_, err := shared.upper.Lstat(name)
+	return err == nil
+}
+
+/*
+lstat allows you to obtain the attributes of an entry from the merged view
+without following a final symbolic link.
+*/
+func (shared *overlayBackendType) lstat(name string) (os.FileInfo, error) {
+	fileInfo, err := shared.upper.Lstat(name)
+	if err == nil {
+		return fileInfo, nil
+	}
+	if shared.isHidden(name) {
+		return nil, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+	}
+	return shared.base.Lstat(name)
+}
+
+/*
+stat allows you to obtain the attributes of an entry from the merged view.
+*/
+func (shared *overlayBackendType) stat(name string) (os.FileInfo, error) {
+	if shared.isInUpper(name) {
+		return shared.upper.Stat(name)
+	}
+	if shared.isHidden(name) {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	return shared.base.Stat(name)
+}
+
+/*
+readlink allows you to obtain the target of a symbolic link from the merged
+view.
+*/
+func (shared *overlayBackendType) readlink(name string) (string, error) {
+	if shared.isInUpper(name) {
+		return shared.upper.Readlink(name)
+	}
+	if shared.isHidden(name) {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: os.ErrNotExist}
+	}
+	return shared.base.Readlink(name)
+}
+
+/*
+resolvePath allows you to follow every symbolic link in a path through the
+merged view, so that a link in either layer can point at an entry in the
+other. Links in parent directories are always followed, while a final link
+is only followed if requested. The resolved path contains no links, so it
+can be passed to either layer without being resolved differently.
+*/
+func (shared *overlayBackendType) resolvePath(operation string, name string, isFinalLinkFollowed bool) (string, error) {
+	resolved := getPathRoot(name)
+	components := splitPathComponents(name)
+	linkCount := 0
+	for len(components) > 0 {
+		component := components[0]
+		components = components[1:]
+		if component == "." {
+			continue
+		}
+		candidate := filepath.Join(resolved, component)
+		if component == ".." || (len(components) == 0 && !isFinalLinkFollowed) {
+			resolved = candidate
+			continue
+		}
+		fileInfo, err := shared.lstat(candidate)
+		if err != nil || fileInfo.Mode()&os.ModeSymlink == 0 {
+			resolved = candidate
+			continue
+		}
+		linkCount++
+		if linkCount > maximumSymlinkDepth {
+			return "", &os.PathError{Op: operation, Path: name, Err: syscall.ELOOP}
+		}
+		linkTarget, err := shared.readlink(candidate)
+		if err != nil {
+			return "", err
+		}
+		if isAbsoluteUserPath(linkTarget) {
+			resolved = getPathRoot(linkTarget)
+		}
+		components = append(splitPathComponents(linkTarget), components...)
+	}
+	if resolved == "" {
+		return ".", nil
+	}
+	return resolved, nil
+}
+
+/*
+getPathRoot allows you to obtain the root an absolute path starts from,
+including any volume name. An empty string is returned for a relative path.
+*/
+func getPathRoot(name string) string {
+	if !isAbsoluteUserPath(name) {
+		return ""
+	}
+	return filepath.VolumeName(name) + string(filepath.Separator)
+}
+
+/*
+splitPathComponents allows you to split a path into its elements, leaving
+out any volume name and empty elements.
+*/
+func splitPathComponents(name string) []string {
+	return strings.FieldsFunc(name[len(filepath.VolumeName(name)):], func(character rune) bool {
+		return character == '/' || character == filepath.Separator
+	})
+}
+
+/*
+recordWrite allows you to remember that an entry was written to the upper
+layer so that it can be committed or discarded later.
+*/
+func (shared *overlayBackendType) recordWrite(name string) {
+	shared.writtenPaths[getOverlayKey(name)] = true
+}
+
+/*
+copyUpParents allows you to make sure every parent directory of a path
+exists in the upper layer, carrying over the attributes of the base layer.
+*/
+func (shared *overlayBackendType) copyUpParents(name string) error {
+	parentPath := filepath.Dir(getOverlayKey(name))
+	if parentPath == getOverlayKey(name) {
+		return nil
+	}
+	return shared.copyUp(parentPath, false)
+}
+
+/*
+copyUp allows you to copy a single entry from the base layer into the upper
+layer so that it can be modified. When the recursive flag is set, the
+entire directory tree beneath the entry is copied as well.
+*/
+func (shared *overlayBackendType) copyUp(name string, isRecursive bool) error {
+	upperInfo, err := shared.upper.Lstat(name)
+	if err == nil && (!isRecursive || !upperInfo.IsDir()) {
+		return nil
+	}
+	fileInfo, err := shared.lstat(name)
+	if err != nil {
+		return err
+	}
+	if upperInfo == nil {
+		err = shared.copyUpParents(name)
+		if err != nil {
+			return err
+		}
+		err = shared.copyEntry(shared.base, shared.upper, name, fileInfo)
+		if err != nil {
+			return err
+		}
+		shared.recordWrite(name)
+	}
+	if !isRecursive || !fileInfo.IsDir() {
+		return nil
+	}
+	entries, err := shared.readDir(name)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err = shared.copyUp(filepath.Join(name, entry.Name()), true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+copyEntry allows you to copy a single file, directory or symbolic link from
+one backend to another while preserving its mode and modification time.
+Directory contents are not copied.
+*/
+func (shared *overlayBackendType) copyEntry(source Backend, target Backend, name string, fileInfo os.FileInfo) error {
+	var err error
+	switch {
+	case fileInfo.Mode()&os.ModeSymlink != 0:
+		var linkTarget string
+		linkTarget, err = source.Readlink(name)
+		if err != nil {
+			return err
+		}
+		err = target.RemoveAll(name)
+		if err != nil {
+			return err
+		}
+		return target.Symlink(linkTarget, name)
+	case fileInfo.IsDir():
+		existingInfo, statErr := target.Lstat(name)
+		if statErr == nil && existingInfo.IsDir() {
+			// Existing directories keep their timestamps and special mode bits (such as the sticky bit on '/tmp').
+			if existingInfo.Mode().Perm() == fileInfo.Mode().Perm() {
+				return nil
+			}
+			specialBits := existingInfo.Mode() & (os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+			return target.Chmod(name, specialBits|fileInfo.Mode().Perm())
+		}
+		err = target.MkdirAll(name, fileInfo.Mode().Perm())
+		if err == nil {
+			err = target.Chmod(name, fileInfo.Mode().Perm())
+		}
+	default:
+		err = copyFileBetweenBackends(source, target, name, fileInfo.Mode().Perm())
+	}
+	if err != nil {
+		return err
+	}
+	return target.Chtimes(name, fileInfo.ModTime(), fileInfo.ModTime())
+}
+
+/*
+copyFileBetweenBackends allows you to copy the contents of a regular file
+from one backend to the same location on another backend.
+*/
+func copyFileBetweenBackends(source Backend, target Backend, name string, perm os.FileMode) error {
+	sourceFile, err := source.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+	targetFile, err := target.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm|0200)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(targetFile, sourceFile)
+	if closeErr := targetFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return target.Chmod(name, perm)
+}
+
+/*
+readDir allows you to obtain the merged contents of a directory from both
+layers, with upper layer entries taking priority over base layer entries.
+*/
+func (shared *overlayBackendType) readDir(name string) ([]os.FileInfo, error) {
+	fileInfo, err := shared.stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !fileInfo.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	entriesByName := make(map[string]os.FileInfo)
+	if !shared.isHidden(name) {
+		baseEntries, err := shared.base.ReadDir(name)
+		if err == nil {
+			for _, entry := range baseEntries {
+				if !shared.isHidden(filepath.Join(name, entry.Name())) {
+					entriesByName[entry.Name()] = entry
+				}
+			}
+		}
+	}
+	upperEntries, err := shared.upper.ReadDir(name)
+	if err == nil {
+		for _, entry := range upperEntries {
+			entriesByName[entry.Name()] = entry
+		}
+	}
+	var names []string
+	for entryName := range entriesByName {
+		names = append(names, entryName)
+	}
+	sort.Strings(names)
+	entries := make([]os.FileInfo, 0, len(names))
+	for _, entryName := range names {
+		entries = append(entries, entriesByName[entryName])
+	}
+	return entries, nil
+}
+
+func (shared *overlayBackendType) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	accessMode := flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	isModifying := accessMode != os.O_RDONLY || flag&(os.O_CREATE|os.O_TRUNC) != 0
+	isExclusive := flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0
+	name, err := shared.resolvePath("open", name, !isExclusive)
+	if err != nil {
+		return nil, err
+	}
+	var file File
+	if !isModifying {
+		if shared.isInUpper(name) {
+			file, err = shared.upper.OpenFile(name, flag, perm)
+		} else if shared.isHidden(name) {
+			err = &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		} else {
+			file, err = shared.base.OpenFile(name, flag, perm)
+		}
+	} else {
+		if _, lstatErr := shared.lstat(name); lstatErr == nil && isExclusive {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+		}
+		_, statErr := shared.stat(name)
+		if statErr == nil {
+			err = shared.copyUp(name, false)
+		} else {
+			err = shared.copyUpParents(name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		file, err = shared.upper.OpenFile(name, flag, perm)
+		if err == nil {
+			shared.recordWrite(name)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := file.Stat()
+	if err == nil && fileInfo.IsDir() {
+		return &overlayDirectoryType{File: file, backend: shared, name: name}, nil
+	}
+	return file, nil
+}
+
+func (shared *overlayBackendType) Stat(name string) (os.FileInfo, error) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	resolvedName, err := shared.resolvePath("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := shared.stat(resolvedName)
+	if err != nil || fileInfo.Name() == filepath.Base(name) {
+		return fileInfo, err
+	}
+	return &overlayFileInfoType{FileInfo: fileInfo, name: filepath.Base(name)}, nil
+}
+
+func (shared *overlayBackendType) Lstat(name string) (os.FileInfo, error) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	resolvedName, err := shared.resolvePath("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return shared.lstat(resolvedName)
+}
+
+func (shared *overlayBackendType) ReadDir(name string) ([]os.FileInfo, error) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	resolvedName, err := shared.resolvePath("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return shared.readDir(resolvedName)
+}
+
+func (shared *overlayBackendType) Rename(oldName string, newName string) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	_, err := shared.lstat(oldName)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: os.ErrNotExist}
+	}
+	err = shared.copyUp(oldName, true)
+	if err != nil {
+		return err
+	}
+	if _, err = shared.lstat(newName); err == nil {
+		err = shared.copyUp(newName, true)
+	} else {
+		err = shared.copyUpParents(newName)
+	}
+	if err != nil {
+		return err
+	}
+	err = shared.upper.Rename(oldName, newName)
+	if err != nil {
+		return err
+	}
+	shared.whiteouts[getOverlayKey(oldName)] = true
+	shared.whiteouts[getOverlayKey(newName)] = true
+	shared.recordWrite(newName)
+	return nil
+}
+
+func (shared *overlayBackendType) Remove(name string) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	fileInfo, err := shared.lstat(name)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if fileInfo.IsDir() {
+		entries, err := shared.readDir(name)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+		}
+	}
+	if shared.isInUpper(name) {
+		err = shared.upper.RemoveAll(name)
+		if err != nil {
+			return err
+		}
+	}
+	shared.whiteouts[getOverlayKey(name)] = true
+	return nil
+}
+
+func (shared *overlayBackendType) RemoveAll(name string) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	err := shared.upper.RemoveAll(name)
+	if err != nil {
+		return err
+	}
+	shared.whiteouts[getOverlayKey(name)] = true
+	return nil
+}
+
+func (shared *overlayBackendType) Mkdir(name string, perm os.FileMode) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	if _, err := shared.lstat(name); err == nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrExist}
+	}
+	err := shared.copyUpParents(name)
+	if err != nil {
+		return err
+	}
+	err = shared.upper.Mkdir(name, perm)
+	if err == nil {
+		shared.recordWrite(name)
+	}
+	return err
+}
+
+func (shared *overlayBackendType) MkdirAll(name string, perm os.FileMode) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	fileInfo, err := shared.stat(name)
+	if err == nil {
+		if fileInfo.IsDir() {
+			return nil
+		}
+		return &os.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	path := getOverlayKey(name)
+	var missingPaths []string
+	for {
+		fileInfo, err = shared.stat(path)
+		if err == nil {
+			if !fileInfo.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: path, Err: syscall.ENOTDIR}
+			}
+			break
+		}
+		missingPaths = append(missingPaths, path)
+		parentPath := filepath.Dir(path)
+		if parentPath == path {
+			break
+		}
+		path = parentPath
+	}
+	err = shared.copyUpParents(missingPaths[len(missingPaths)-1])
+	if err != nil {
+		return err
+	}
+	err = shared.upper.MkdirAll(name, perm)
+	if err != nil {
+		return err
+	}
+	for _, missingPath := range missingPaths {
+		shared.recordWrite(missingPath)
+	}
+	return nil
+}
+
+func (shared *overlayBackendType) Chmod(name string, mode os.FileMode) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	err := shared.copyUp(name, false)
+	if err != nil {
+		return err
+	}
+	return shared.upper.Chmod(name, mode)
+}
+
+func (shared *overlayBackendType) Chown(name string, uid int, gid int) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	err := shared.copyUp(name, false)
+	if err != nil {
+		return err
+	}
+	return shared.upper.Chown(name, uid, gid)
+}
+
+func (shared *overlayBackendType) Chtimes(name string, accessTime time.Time, modificationTime time.Time) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	err := shared.copyUp(name, false)
+	if err != nil {
+		return err
+	}
+	return shared.upper.Chtimes(name, accessTime, modificationTime)
+}
+
+func (shared *overlayBackendType) Symlink(oldName string, newName string) error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	if _, err := shared.lstat(newName); err == nil {
+		return &os.LinkError{Op: "symlink", Old: oldName, New: newName, Err: os.ErrExist}
+	}
+	err := shared.copyUpParents(newName)
+	if err != nil {
+		return err
+	}
+	err = shared.upper.Symlink(oldName, newName)
+	if err == nil {
+		shared.recordWrite(newName)
+	}
+	return err
+}
+
+func (shared *overlayBackendType) Readlink(name string) (string, error) {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	return shared.readlink(name)
+}
+
+/*
+Commit allows you to apply every change made through the overlay to the
+base layer. Deleted entries are removed from the base layer first, after
+which all written entries are copied down. Once complete, the upper layer
+is discarded.
+*/
+func (shared *overlayBackendType) Commit() error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	for _, name := range getSortedKeys(shared.whiteouts) {
+		err := shared.base.RemoveAll(name)
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range getSortedKeys(shared.writtenPaths) {
+		err := shared.commitEntry(name)
+		if err != nil {
+			return err
+		}
+	}
+	return shared.discard()
+}
+
+/*
+commitEntry allows you to copy an entry, and everything beneath it, from
+the upper layer down into the base layer.
+*/
+func (shared *overlayBackendType) commitEntry(name string) error {
+	fileInfo, err := shared.upper.Lstat(name)
+	if err != nil {
+		// The entry was renamed or removed after being written.
+		return nil
+	}
+	err = shared.copyEntry(shared.upper, shared.base, name, fileInfo)
+	if err != nil || !fileInfo.IsDir() {
+		return err
+	}
+	entries, err := shared.upper.ReadDir(name)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err = shared.commitEntry(filepath.Join(name, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Discard allows you to throw away every change made through the overlay,
+leaving the base layer exactly as it was.
+*/
+func (shared *overlayBackendType) Discard() error {
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	return shared.discard()
+}
+
+/*
+discard allows you to remove every entry written to the upper layer and
+forget all whiteouts. The overlay lock must already be held.
+*/
+func (shared *overlayBackendType) discard() error {
+	writtenPaths := getSortedKeys(shared.writtenPaths)
+	for index := len(writtenPaths) - 1; index >= 0; index-- {
+		err := shared.upper.RemoveAll(writtenPaths[index])
+		if err != nil {
+			return err
+		}
+	}
+	shared.whiteouts = make(map[string]bool)
+	shared.writtenPaths = make(map[string]bool)
+	return nil
+}
+
+/*
+getSortedKeys allows you to obtain the keys of a set in lexical order, which
+guarantees parent paths are always listed before their children.
+*/
+func getSortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (shared *overlayDirectoryType) Readdirnames(count int) ([]string, error) {
+	entries, err := shared.backend.ReadDir(shared.name)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	remainingNames := names[minimumInt(shared.directoryOffset, len(names)):]
+	if count <= 0 {
+		shared.directoryOffset += len(remainingNames)
+		return remainingNames, nil
+	}
+	if len(remainingNames) == 0 {
+		return nil, io.EOF
+	}
+	remainingNames = remainingNames[:minimumInt(count, len(remainingNames))]
+	shared.directoryOffset += len(remainingNames)
+	return remainingNames, nil
+}
+
+func (shared *overlayFileInfoType) Name() string {
+	return shared.name
+}
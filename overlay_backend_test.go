@@ -0,0 +1,150 @@
+package filesystem
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func getSampleOverlay(test *testing.T) (*fileSystemType, *overlayBackendType, *fileSystemType) {
+	baseFileSystem := GetFileSystem(GetMemoryBackend())
+	err := baseFileSystem.CreateDirectory("/config/sub_dir", 0)
+	assert.NoErrorf(test, err, "An error was not expected when creating a directory.")
+	err = baseFileSystem.WriteBytesToFile("/config/app.conf", []byte("mode=production"), 0644)
+	assert.NoErrorf(test, err, "An error was not expected when creating a sample file!")
+	err = baseFileSystem.WriteBytesToFile("/config/old.conf", []byte("legacy"), 0644)
+	assert.NoErrorf(test, err, "An error was not expected when creating a sample file!")
+	overlay := GetOverlayBackend(baseFileSystem.GetBackend(), GetMemoryBackend())
+	return baseFileSystem, overlay, GetFileSystem(overlay)
+}
+
+func TestOverlayWritesLandInUpperLayer(test *testing.T) {
+	baseFileSystem, _, fileSystem := getSampleOverlay(test)
+	err := fileSystem.WriteBytesToFile("/config/app.conf", []byte("mode=testing"), 0)
+	assert.NoErrorf(test, err, "An error was not expected when writing through the overlay.")
+	err = fileSystem.AppendLineToFile("/config/new.conf", "created=true", 0)
+	assert.NoErrorf(test, err, "An error was not expected when creating a file through the overlay.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/config/app.conf")
+	assert.Equalf(test, "mode=testing", string(fileContents), "The overlay was expected to return the modified contents.")
+	fileContents, _ = baseFileSystem.GetFileContentsAsBytes("/config/app.conf")
+	assert.Equalf(test, "mode=production", string(fileContents), "The base layer was not expected to be modified.")
+	assert.Falsef(test, baseFileSystem.IsFileExists("/config/new.conf"), "New files were not expected to appear in the base layer.")
+}
+
+func TestOverlayMergesDirectoryListings(test *testing.T) {
+	_, _, fileSystem := getSampleOverlay(test)
+	err := fileSystem.DeleteFile("/config/old.conf")
+	assert.NoErrorf(test, err, "An error was not expected when deleting through the overlay.")
+	err = fileSystem.WriteBytesToFile("/config/new.conf", []byte("created=true"), 0)
+	assert.NoErrorf(test, err, "An error was not expected when creating a file through the overlay.")
+	obtainedValue, err := fileSystem.GetListOfDirectoryContents("/config", []string{".*"}, true, true)
+	assert.NoErrorf(test, err, "An error was not expected when listing a merged directory.")
+	assert.Equalf(test, []string{"app.conf", "new.conf", "sub_dir/"}, obtainedValue, "The merged directory listing was not as expected.")
+	assert.Falsef(test, fileSystem.IsFileExists("/config/old.conf"), "A deleted file was not expected to be visible.")
+	isEmpty, err := fileSystem.IsDirectoryEmpty("/config")
+	assert.NoErrorf(test, err, "An error was not expected when checking a merged directory.")
+	assert.Falsef(test, isEmpty, "The merged directory was not expected to be empty.")
+}
+
+func TestOverlayRenameAndDeleteDirectory(test *testing.T) {
+	baseFileSystem, _, fileSystem := getSampleOverlay(test)
+	err := fileSystem.MoveDirectories("/config", "/renamed")
+	assert.NoErrorf(test, err, "An error was not expected when renaming a directory through the overlay.")
+	assert.Falsef(test, fileSystem.IsDirectoryExists("/config"), "The renamed directory was not expected to remain visible.")
+	fileContents, err := fileSystem.GetFileContentsAsBytes("/renamed/app.conf")
+	assert.NoErrorf(test, err, "An error was not expected when reading a renamed file.")
+	assert.Equalf(test, "mode=production", string(fileContents), "The renamed file contents were not as expected.")
+	err = fileSystem.DeleteDirectory("/renamed")
+	assert.NoErrorf(test, err, "An error was not expected when deleting a directory through the overlay.")
+	assert.Falsef(test, fileSystem.IsDirectoryExists("/renamed"), "The deleted directory was not expected to be visible.")
+	assert.Truef(test, baseFileSystem.IsDirectoryExists("/config/sub_dir"), "The base layer was not expected to be modified.")
+}
+
+func TestOverlayCommitAndDiscard(test *testing.T) {
+	baseFileSystem, overlay, fileSystem := getSampleOverlay(test)
+	fileSystem.WriteBytesToFile("/config/app.conf", []byte("mode=testing"), 0)
+	fileSystem.DeleteFile("/config/old.conf")
+	err := overlay.Discard()
+	assert.NoErrorf(test, err, "An error was not expected when discarding changes.")
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/config/app.conf")
+	assert.Equalf(test, "mode=production", string(fileContents), "Discarded changes were not expected to be visible.")
+	assert.Truef(test, fileSystem.IsFileExists("/config/old.conf"), "Discarded deletions were not expected to hide files.")
+
+	fileSystem.WriteBytesToFile("/config/app.conf", []byte("mode=testing"), 0)
+	fileSystem.DeleteFile("/config/old.conf")
+	fileSystem.CreateDirectory("/config/sub_dir/nested", 0)
+	err = overlay.Commit()
+	assert.NoErrorf(test, err, "An error was not expected when committing changes.")
+	fileContents, _ = baseFileSystem.GetFileContentsAsBytes("/config/app.conf")
+	assert.Equalf(test, "mode=testing", string(fileContents), "Committed changes were expected to reach the base layer.")
+	assert.Falsef(test, baseFileSystem.IsFileExists("/config/old.conf"), "Committed deletions were expected to reach the base layer.")
+	assert.Truef(test, baseFileSystem.IsDirectoryExists("/config/sub_dir/nested"), "Committed directories were expected to reach the base layer.")
+}
+
+func TestOverlayWriteThroughSymlink(test *testing.T) {
+	baseFileSystem, overlay, fileSystem := getSampleOverlay(test)
+	err := baseFileSystem.GetBackend().Symlink("app.conf", "/config/link.conf")
+	assert.NoErrorf(test, err, "An error was not expected when creating a symbolic link.")
+	file, err := overlay.OpenFile("/config/link.conf", os.O_WRONLY|os.O_TRUNC, 0)
+	assert.NoErrorf(test, err, "An error was not expected when opening a symbolic link for writing.")
+	file.Write([]byte("mode=linked"))
+	file.Close()
+	fileContents, _ := fileSystem.GetFileContentsAsBytes("/config/app.conf")
+	assert.Equalf(test, "mode=linked", string(fileContents), "A write through a symbolic link was expected to modify its target.")
+	fileInfo, err := overlay.Lstat("/config/link.conf")
+	assert.NoErrorf(test, err, "An error was not expected when inspecting a symbolic link.")
+	assert.Truef(test, fileInfo.Mode()&os.ModeSymlink != 0, "The symbolic link was expected to remain a link.")
+	fileContents, _ = baseFileSystem.GetFileContentsAsBytes("/config/app.conf")
+	assert.Equalf(test, "mode=production", string(fileContents), "The base layer was not expected to be modified.")
+}
+
+func TestOverlayReadThroughSymlink(test *testing.T) {
+	baseFileSystem, overlay, fileSystem := getSampleOverlay(test)
+	baseFileSystem.GetBackend().Symlink("app.conf", "/config/link.conf")
+	baseFileSystem.GetBackend().Symlink("/config", "/settings")
+	err := fileSystem.WriteBytesToFile("/config/app.conf", []byte("mode=development"), 0)
+	assert.NoErrorf(test, err, "An error was not expected when modifying a file in the upper layer.")
+	fileContents, err := fileSystem.GetFileContentsAsBytes("/config/link.conf")
+	assert.NoErrorf(test, err, "An error was not expected when reading through a symbolic link.")
+	assert.Equalf(test, "mode=development", string(fileContents), "A base layer link was expected to read the modified target.")
+	fileContents, err = fileSystem.GetFileContentsAsBytes("/settings/link.conf")
+	assert.NoErrorf(test, err, "An error was not expected when reading through a linked directory.")
+	assert.Equalf(test, "mode=development", string(fileContents), "A linked directory was expected to resolve through the merged view.")
+	fileInfos, err := overlay.ReadDir("/settings")
+	assert.NoErrorf(test, err, "An error was not expected when listing a linked directory.")
+	assert.Equalf(test, 4, len(fileInfos), "The linked directory was expected to list the merged entries.")
+	err = overlay.Symlink("/config/app.conf", "/upper_link.conf")
+	assert.NoErrorf(test, err, "An error was not expected when creating a symbolic link in the upper layer.")
+	fileInfo, err := overlay.Stat("/upper_link.conf")
+	assert.NoErrorf(test, err, "An error was not expected when obtaining the attributes of a link to a base layer file.")
+	assert.Equalf(test, "upper_link.conf", fileInfo.Name(), "The attributes were expected to be reported under the name of the link.")
+	assert.Equalf(test, int64(len("mode=development")), fileInfo.Size(), "The attributes were expected to be those of the link target.")
+	fileInfo, err = overlay.Lstat("/settings/link.conf")
+	assert.NoErrorf(test, err, "An error was not expected when inspecting a link inside a linked directory.")
+	assert.Truef(test, fileInfo.Mode()&os.ModeSymlink != 0, "A final symbolic link was not expected to be followed by Lstat.")
+	overlay.Symlink("/loop_b", "/loop_a")
+	overlay.Symlink("/loop_a", "/loop_b")
+	_, err = overlay.Stat("/loop_a")
+	assert.Errorf(test, err, "An error was expected when resolving a symbolic link loop.")
+}
+
+func TestOverlayOnLocalFileSystem(test *testing.T) {
+	directory := "/tmp/overlay_test"
+	DeleteDirectory(directory)
+	err := CreateDirectory(directory, 0)
+	assert.NoErrorf(test, err, "An error was not expected when creating a directory.")
+	err = WriteBytesToFile(directory+"/file.txt", []byte("original"), 0666)
+	assert.NoErrorf(test, err, "An error was not expected when creating a sample file!")
+	overlay := GetOverlayBackend(GetOsBackend(), GetMemoryBackend())
+	fileSystem := GetFileSystem(overlay)
+	err = fileSystem.FindReplaceInFile(directory+"/file.txt", "original", "modified")
+	assert.NoErrorf(test, err, "An error was not expected when replacing text through the overlay.")
+	fileContents, _ := GetFileContentsAsBytes(directory + "/file.txt")
+	assert.Equalf(test, "original", string(fileContents), "The local file was not expected to be modified before a commit.")
+	err = overlay.Commit()
+	assert.NoErrorf(test, err, "An error was not expected when committing changes.")
+	fileContents, _ = GetFileContentsAsBytes(directory + "/file.txt")
+	assert.Equalf(test, "modified", string(fileContents), "The local file was expected to be modified after a commit.")
+	err = DeleteDirectory(directory)
+	assert.NoErrorf(test, err, "An error was not expected when deleting a directory.")
+}
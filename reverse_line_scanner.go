@@ -0,0 +1,268 @@
+package filesystem
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+/*
+reverseBlockSize is the number of bytes a reverse line scanner reads from
+the end of a file at a time.
+*/
+const reverseBlockSize = 64 * 1024
+
+/*
+ReverseLineScannerOptions allows you to control how a reverse line scanner
+splits a file into lines. In addition, the following information should be
+noted:
+
+- If you pass in a maximum line length of '0', a default of 1 MiB is used.
+Lines longer than the maximum stop the scan with 'ErrLineTooLong'.
+
+- A trailing "\r" is removed from every line unless 'IsCarriageReturnKept'
+is set.
+*/
+type ReverseLineScannerOptions struct {
+	MaximumLineLength    int
+	IsCarriageReturnKept bool
+}
+
+type reverseLineScannerType struct {
+	source     io.ReaderAt
+	closer     io.Closer
+	options    ReverseLineScannerOptions
+	position   int64
+	pending    []byte
+	line       []byte
+	offset     int64
+	err        error
+	isFinished bool
+}
+
+/*
+getReverseLineScanner allows you to create a reverse line scanner which
+reads a random access source backwards from the size provided. A single
+newline at the very end of the source terminates the last line rather than
+starting a new empty one, which matches how lines are scanned forwards.
+*/
+func getReverseLineScanner(source io.ReaderAt, closer io.Closer, size int64, options ReverseLineScannerOptions) *reverseLineScannerType {
+	if options.MaximumLineLength <= 0 {
+		options.MaximumLineLength = defaultMaximumLineLength
+	}
+	var scanner reverseLineScannerType
+	scanner.source = source
+	scanner.closer = closer
+	scanner.options = options
+	scanner.position = size
+	if size == 0 {
+		scanner.isFinished = true
+		return &scanner
+	}
+	lastByte := make([]byte, 1)
+	if _, err := source.ReadAt(lastByte, size-1); err != nil && err != io.EOF {
+		scanner.err = err
+		return &scanner
+	}
+	if lastByte[0] == '\n' {
+		scanner.position = size - 1
+	}
+	return &scanner
+}
+
+/*
+GetReverseLineScanner allows you to iterate over the lines of an open file
+starting from the last line and working towards the first. Only the blocks
+at the end of the file which are needed are read, which makes this suitable
+for tailing very large files. Scanning uses positional reads, so the current
+offset of the file instance is not affected.
+*/
+func (shared *fileInstanceType) GetReverseLineScanner(options ReverseLineScannerOptions) (*reverseLineScannerType, error) {
+	if err := shared.checkOpen("scan"); err != nil {
+		return nil, err
+	}
+	fileInfo, err := shared.fileDescriptor.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return getReverseLineScanner(shared.fileDescriptor, nil, fileInfo.Size(), options), nil
+}
+
+/*
+GetReverseLineScannerFromFile allows you to iterate over the lines of a
+file starting from the last line and working towards the first. The
+scanner must be closed once you are finished with it.
+*/
+func (shared *fileSystemType) GetReverseLineScannerFromFile(fileName string, options ReverseLineScannerOptions) (*reverseLineScannerType, error) {
+	file, err := shared.backend.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return getReverseLineScanner(file, file, fileInfo.Size(), options), nil
+}
+
+/*
+Scan allows you to move the scanner to the previous line. False is returned
+once the start of the file has been passed or an error occurs, in which case
+'GetError' reports what went wrong.
+*/
+func (shared *reverseLineScannerType) Scan() bool {
+	if shared.err != nil || shared.isFinished {
+		return false
+	}
+	for {
+		index := bytes.LastIndexByte(shared.pending, '\n')
+		if index >= 0 {
+			shared.setLine(shared.pending[index+1:], shared.position+int64(index)+1)
+			shared.pending = shared.pending[:index]
+			break
+		}
+		if shared.position == 0 {
+			shared.setLine(shared.pending, 0)
+			shared.pending = nil
+			shared.isFinished = true
+			break
+		}
+		if len(shared.pending) > shared.options.MaximumLineLength+1 {
+			shared.err = &OpError{Op: "scan", Err: ErrLineTooLong}
+			return false
+		}
+		if err := shared.readPreviousBlock(); err != nil {
+			shared.err = err
+			return false
+		}
+	}
+	if len(shared.line) > shared.options.MaximumLineLength {
+		shared.err = &OpError{Op: "scan", Err: ErrLineTooLong}
+		return false
+	}
+	return true
+}
+
+/*
+readPreviousBlock allows you to prepend the block of the file which comes
+before the bytes already pending.
+*/
+func (shared *reverseLineScannerType) readPreviousBlock() error {
+	blockSize := int64(reverseBlockSize)
+	if shared.position < blockSize {
+		blockSize = shared.position
+	}
+	block := make([]byte, int(blockSize)+len(shared.pending))
+	readCount, err := shared.source.ReadAt(block[:blockSize], shared.position-blockSize)
+	if int64(readCount) < blockSize {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	copy(block[blockSize:], shared.pending)
+	shared.pending = block
+	shared.position -= blockSize
+	return nil
+}
+
+/*
+setLine allows you to record the line found by a scan along with the offset
+at which it starts.
+*/
+func (shared *reverseLineScannerType) setLine(line []byte, offset int64) {
+	if !shared.options.IsCarriageReturnKept {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+	}
+	shared.line = line
+	shared.offset = offset
+}
+
+/*
+GetLine allows you to obtain the current line without its delimiter. The
+returned slice is only valid until the next call to 'Scan'.
+*/
+func (shared *reverseLineScannerType) GetLine() []byte {
+	return shared.line
+}
+
+/*
+GetText allows you to obtain the current line as a string.
+*/
+func (shared *reverseLineScannerType) GetText() string {
+	return string(shared.line)
+}
+
+/*
+GetOffset allows you to obtain the byte offset within the file at which the
+current line starts.
+*/
+func (shared *reverseLineScannerType) GetOffset() int64 {
+	return shared.offset
+}
+
+/*
+GetError allows you to obtain the error which stopped the scan, if any.
+*/
+func (shared *reverseLineScannerType) GetError() error {
+	return shared.err
+}
+
+/*
+Close allows you to release the file held by a scanner which was obtained
+from a path. Closing a scanner obtained from a file instance leaves the
+file instance open.
+*/
+func (shared *reverseLineScannerType) Close() error {
+	if shared.closer == nil {
+		return nil
+	}
+	err := shared.closer.Close()
+	shared.closer = nil
+	return err
+}
+
+/*
+GetLastNLines allows you to obtain up to the last N lines of an open file,
+in the order they appear in the file. Only the end of the file is read.
+*/
+func (shared *fileInstanceType) GetLastNLines(count int) ([]string, error) {
+	scanner, err := shared.GetReverseLineScanner(ReverseLineScannerOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return getLastNLinesFromScanner(scanner, count)
+}
+
+/*
+GetLastNLinesFromFile allows you to obtain up to the last N lines of a
+file, in the order they appear in the file. Only the end of the file is
+read, so this is suitable for very large files.
+*/
+func (shared *fileSystemType) GetLastNLinesFromFile(fileName string, count int) ([]string, error) {
+	scanner, err := shared.GetReverseLineScannerFromFile(fileName, ReverseLineScannerOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer scanner.Close()
+	return getLastNLinesFromScanner(scanner, count)
+}
+
+/*
+getLastNLinesFromScanner allows you to collect lines from a reverse scanner
+and return them in the order they appear in the file.
+*/
+func getLastNLinesFromScanner(scanner *reverseLineScannerType, count int) ([]string, error) {
+	var lines []string
+	for len(lines) < count && scanner.Scan() {
+		lines = append(lines, scanner.GetText())
+	}
+	if scanner.GetError() != nil {
+		return nil, scanner.GetError()
+	}
+	for left, right := 0, len(lines)-1; left < right; left, right = left+1, right-1 {
+		lines[left], lines[right] = lines[right], lines[left]
+	}
+	return lines, nil
+}
@@ -0,0 +1,79 @@
+package filesystem
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestReverseLineScanner(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	testCases := map[string][]string{
+		"":                          nil,
+		"\n":                        {""},
+		"Only line":                 {"Only line"},
+		"First\nSecond\n":           {"Second", "First"},
+		"First\r\nSecond\r\n":       {"Second", "First"},
+		"First\nSecond\n\nLast":     {"Last", "", "Second", "First"},
+		"\nAfter an empty line\n\n": {"", "After an empty line", ""},
+	}
+	for fileContents, expectedLines := range testCases {
+		fileSystem.WriteBytesToFile("/file.txt", []byte(fileContents), 0)
+		scanner, err := fileSystem.GetReverseLineScannerFromFile("/file.txt", ReverseLineScannerOptions{})
+		assert.NoErrorf(test, err, "An error was not expected when creating a reverse line scanner.")
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.GetText())
+		}
+		scanner.Close()
+		assert.NoErrorf(test, scanner.GetError(), "An error was not expected when scanning %q.", fileContents)
+		assert.Equalf(test, expectedLines, lines, "The lines of %q were not scanned in reverse as expected.", fileContents)
+	}
+}
+
+func TestReverseLineScannerAcrossBlocks(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	longLine := strings.Repeat("x", reverseBlockSize+10)
+	fileSystem.WriteBytesToFile("/file.txt", []byte("First\n"+longLine+"\nLast\n"), 0)
+	scanner, _ := fileSystem.GetReverseLineScannerFromFile("/file.txt", ReverseLineScannerOptions{})
+	defer scanner.Close()
+	var offsets []int64
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.GetText())
+		offsets = append(offsets, scanner.GetOffset())
+	}
+	assert.Equalf(test, []string{"Last", longLine, "First"}, lines, "Lines spanning several blocks were not scanned as expected.")
+	assert.Equalf(test, []int64{int64(len(longLine)) + 7, 6, 0}, offsets, "The line offsets were not as expected.")
+	scanner, _ = fileSystem.GetReverseLineScannerFromFile("/file.txt", ReverseLineScannerOptions{MaximumLineLength: 100})
+	defer scanner.Close()
+	assert.Truef(test, scanner.Scan(), "The last line was expected to fit within the maximum line length.")
+	assert.Falsef(test, scanner.Scan(), "A line longer than the maximum was expected to stop the scan.")
+	assert.ErrorIsf(test, scanner.GetError(), ErrLineTooLong, "A line longer than the maximum was expected to return ErrLineTooLong.")
+}
+
+func TestGetLastNLinesFromFile(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.WriteBytesToFile("/file.txt", []byte("Line 0\r\nLine 1\r\nLine 2\r\nLine 3"), 0)
+	lines, err := fileSystem.GetLastNLinesFromFile("/file.txt", 2)
+	assert.NoErrorf(test, err, "An error was not expected when getting the last lines of a file.")
+	assert.Equalf(test, []string{"Line 2", "Line 3"}, lines, "The last lines were not as expected.")
+	lines, _ = fileSystem.GetLastNLinesFromFile("/file.txt", 10)
+	assert.Equalf(test, []string{"Line 0", "Line 1", "Line 2", "Line 3"}, lines, "Every line was expected when asking for more lines than exist.")
+	lastLine, err := fileSystem.GetLastLineFromFile("/file.txt")
+	assert.NoErrorf(test, err, "An error was not expected when getting the last line of a file.")
+	assert.Equalf(test, "Line 3", lastLine, "The last line was not as expected.")
+	fileSystem.WriteBytesToFile("/empty.txt", nil, 0)
+	lastLine, err = fileSystem.GetLastLineFromFile("/empty.txt")
+	assert.NoErrorf(test, err, "An error was not expected when getting the last line of an empty file.")
+	assert.Equalf(test, "", lastLine, "The last line of an empty file was expected to be empty.")
+	fileSystem.WriteBytesToFile("/trailing.txt", []byte("a\nb\n\r\n\n"), 0)
+	lastLine, err = fileSystem.GetLastLineFromFile("/trailing.txt")
+	assert.NoErrorf(test, err, "An error was not expected when getting the last line of a file ending in newlines.")
+	assert.Equalf(test, "b", lastLine, "Trailing newlines were expected to be ignored.")
+	longLine := strings.Repeat("x", 2*1024*1024)
+	fileSystem.WriteBytesToFile("/long.txt", []byte("a\n"+longLine+"\n"), 0)
+	lastLine, err = fileSystem.GetLastLineFromFile("/long.txt")
+	assert.NoErrorf(test, err, "An error was not expected when getting a last line longer than the default scanner limit.")
+	assert.Equalf(test, len(longLine), len(lastLine), "The whole of a long last line was expected to be returned.")
+}
@@ -0,0 +1,291 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type rootedBackendType struct {
+	root    string
+	backend Backend
+}
+
+type rootedFileType struct {
+	File
+	name string
+}
+
+/*
+GetRootedBackend allows you to obtain a backend which confines every
+operation to a single directory of another backend. In addition, the
+following information should be noted:
+
+- All paths are resolved relative to the root directory provided.
+
+- Absolute paths, '..' components which climb above the root and symbolic
+links which point outside of the root are all rejected with an error
+matching 'ErrPathEscapesRoot'.
+*/
+func GetRootedBackend(backend Backend, root string) Backend {
+	var rootedBackend rootedBackendType
+	rootedBackend.root = filepath.Clean(root)
+	rootedBackend.backend = backend
+	return &rootedBackend
+}
+
+/*
+GetRootedFileSystem allows you to obtain a file system instance which is
+confined to a single directory on the local file system. This is useful
+when working with user supplied relative paths, since nothing outside of
+the root directory can ever be accessed or modified.
+*/
+func GetRootedFileSystem(root string) *fileSystemType {
+	return GetFileSystem(GetRootedBackend(GetOsBackend(), root))
+}
+
+/*
+isAbsoluteUserPath allows you to check if a path supplied by a user is
+absolute on any platform, including paths which begin with a separator or
+a volume name.
+*/
+func isAbsoluteUserPath(name string) bool {
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return true
+	}
+	return strings.HasPrefix(name, "/") || strings.HasPrefix(name, "\\")
+}
+
+/*
+resolve allows you to convert a path relative to the root into a path on
+the underlying backend. Every symbolic link encountered along the way is
+evaluated so that links which lead outside of the root can be rejected.
+When the final link flag is not set, a symbolic link in the last position
+is left in place so that it can be operated on directly.
+*/
+func (shared *rootedBackendType) resolve(operation string, name string, isFinalLinkFollowed bool) (string, error) {
+	escapeError := &os.PathError{Op: operation, Path: name, Err: ErrPathEscapesRoot}
+	if isAbsoluteUserPath(name) {
+		return "", escapeError
+	}
+	components := splitMemoryPath(name)
+	var resolved []string
+	linkCount := 0
+	for index := 0; index < len(components); index++ {
+		component := components[index]
+		if component == ".." {
+			if len(resolved) == 0 {
+				return "", escapeError
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+		candidate := append(append([]string{}, resolved...), component)
+		isLastComponent := index == len(components)-1
+		if isLastComponent && !isFinalLinkFollowed {
+			resolved = candidate
+			continue
+		}
+		hostPath := shared.getHostPath(candidate)
+		fileInfo, err := shared.backend.Lstat(hostPath)
+		if err != nil || fileInfo.Mode()&os.ModeSymlink == 0 {
+			resolved = candidate
+			continue
+		}
+		linkCount++
+		if linkCount > maximumSymlinkDepth {
+			return "", &os.PathError{Op: operation, Path: name, Err: syscall.ELOOP}
+		}
+		linkTarget, err := shared.backend.Readlink(hostPath)
+		if err != nil {
+			return "", &os.PathError{Op: operation, Path: name, Err: err}
+		}
+		if isAbsoluteUserPath(linkTarget) {
+			relativeTarget, err := filepath.Rel(shared.root, filepath.Clean(linkTarget))
+			if err != nil || relativeTarget == ".." || strings.HasPrefix(relativeTarget, ".."+string(filepath.Separator)) {
+				return "", escapeError
+			}
+			resolved = nil
+			linkTarget = relativeTarget
+		}
+		components = append(splitMemoryPath(linkTarget), components[index+1:]...)
+		index = -1
+	}
+	return shared.getHostPath(resolved), nil
+}
+
+/*
+getHostPath allows you to convert a list of resolved components into a
+path on the underlying backend.
+*/
+func (shared *rootedBackendType) getHostPath(components []string) string {
+	return filepath.Join(append([]string{shared.root}, components...)...)
+}
+
+func (shared *rootedBackendType) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	isExclusive := flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0
+	hostPath, err := shared.resolve("open", name, !isExclusive)
+	if err != nil {
+		return nil, err
+	}
+	file, err := shared.backend.OpenFile(hostPath, flag, perm)
+	if err != nil {
+		return nil, shared.translateError(err, name)
+	}
+	return &rootedFileType{File: file, name: name}, nil
+}
+
+func (shared *rootedBackendType) Stat(name string) (os.FileInfo, error) {
+	hostPath, err := shared.resolve("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := shared.backend.Stat(hostPath)
+	return fileInfo, shared.translateError(err, name)
+}
+
+func (shared *rootedBackendType) Lstat(name string) (os.FileInfo, error) {
+	hostPath, err := shared.resolve("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := shared.backend.Lstat(hostPath)
+	return fileInfo, shared.translateError(err, name)
+}
+
+func (shared *rootedBackendType) ReadDir(name string) ([]os.FileInfo, error) {
+	hostPath, err := shared.resolve("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := shared.backend.ReadDir(hostPath)
+	return entries, shared.translateError(err, name)
+}
+
+func (shared *rootedBackendType) Rename(oldName string, newName string) error {
+	oldHostPath, err := shared.resolve("rename", oldName, false)
+	if err != nil {
+		return err
+	}
+	newHostPath, err := shared.resolve("rename", newName, false)
+	if err != nil {
+		return err
+	}
+	err = shared.backend.Rename(oldHostPath, newHostPath)
+	if linkError, isLinkError := err.(*os.LinkError); isLinkError {
+		return &os.LinkError{Op: linkError.Op, Old: oldName, New: newName, Err: linkError.Err}
+	}
+	return shared.translateError(err, oldName)
+}
+
+func (shared *rootedBackendType) Remove(name string) error {
+	hostPath, err := shared.resolve("remove", name, false)
+	if err != nil {
+		return err
+	}
+	return shared.translateError(shared.backend.Remove(hostPath), name)
+}
+
+func (shared *rootedBackendType) RemoveAll(name string) error {
+	hostPath, err := shared.resolve("unlinkat", name, false)
+	if err != nil {
+		return err
+	}
+	return shared.translateError(shared.backend.RemoveAll(hostPath), name)
+}
+
+func (shared *rootedBackendType) Mkdir(name string, perm os.FileMode) error {
+	hostPath, err := shared.resolve("mkdir", name, false)
+	if err != nil {
+		return err
+	}
+	return shared.translateError(shared.backend.Mkdir(hostPath, perm), name)
+}
+
+func (shared *rootedBackendType) MkdirAll(name string, perm os.FileMode) error {
+	hostPath, err := shared.resolve("mkdir", name, true)
+	if err != nil {
+		return err
+	}
+	return shared.translateError(shared.backend.MkdirAll(hostPath, perm), name)
+}
+
+func (shared *rootedBackendType) Chmod(name string, mode os.FileMode) error {
+	hostPath, err := shared.resolve("chmod", name, true)
+	if err != nil {
+		return err
+	}
+	return shared.translateError(shared.backend.Chmod(hostPath, mode), name)
+}
+
+func (shared *rootedBackendType) Chown(name string, uid int, gid int) error {
+	hostPath, err := shared.resolve("chown", name, true)
+	if err != nil {
+		return err
+	}
+	return shared.translateError(shared.backend.Chown(hostPath, uid, gid), name)
+}
+
+func (shared *rootedBackendType) Chtimes(name string, accessTime time.Time, modificationTime time.Time) error {
+	hostPath, err := shared.resolve("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	return shared.translateError(shared.backend.Chtimes(hostPath, accessTime, modificationTime), name)
+}
+
+/*
+Symlink allows you to create a symbolic link inside the root. Links whose
+target is absolute, or whose relative target climbs above the root, are
+rejected.
+*/
+func (shared *rootedBackendType) Symlink(oldName string, newName string) error {
+	escapeError := &os.LinkError{Op: "symlink", Old: oldName, New: newName, Err: ErrPathEscapesRoot}
+	if isAbsoluteUserPath(oldName) {
+		return escapeError
+	}
+	linkDirectory := filepath.Dir(filepath.Clean(newName))
+	depth := len(splitMemoryPath(linkDirectory))
+	for _, component := range splitMemoryPath(oldName) {
+		if component == ".." {
+			depth--
+		} else {
+			depth++
+		}
+		if depth < 0 {
+			return escapeError
+		}
+	}
+	hostPath, err := shared.resolve("symlink", newName, false)
+	if err != nil {
+		return err
+	}
+	return shared.translateError(shared.backend.Symlink(oldName, hostPath), newName)
+}
+
+func (shared *rootedBackendType) Readlink(name string) (string, error) {
+	hostPath, err := shared.resolve("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	linkTarget, err := shared.backend.Readlink(hostPath)
+	return linkTarget, shared.translateError(err, name)
+}
+
+/*
+translateError allows you to replace the host path reported by an error
+from the underlying backend with the path the user originally supplied, so
+that the location of the root is never revealed.
+*/
+func (shared *rootedBackendType) translateError(err error, name string) error {
+	if pathError, isPathError := err.(*os.PathError); isPathError {
+		return &os.PathError{Op: pathError.Op, Path: name, Err: pathError.Err}
+	}
+	return err
+}
+
+func (shared *rootedFileType) Name() string {
+	return shared.name
+}
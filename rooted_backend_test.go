@@ -0,0 +1,66 @@
+package filesystem
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestRootedPathsAreRelativeToRoot(test *testing.T) {
+	memoryFileSystem := GetFileSystem(GetMemoryBackend())
+	err := memoryFileSystem.CreateDirectory("/sandbox/data", 0)
+	assert.NoErrorf(test, err, "An error was not expected when creating a directory.")
+	fileSystem := GetFileSystem(GetRootedBackend(memoryFileSystem.GetBackend(), "/sandbox"))
+	err = fileSystem.WriteBytesToFile("data/file.txt", []byte("sample_string"), 0)
+	assert.NoErrorf(test, err, "An error was not expected when writing inside the root.")
+	err = fileSystem.CopyFile("data/file.txt", "data/../copy.txt")
+	assert.NoErrorf(test, err, "An error was not expected when copying inside the root.")
+	assert.Truef(test, memoryFileSystem.IsFileExists("/sandbox/copy.txt"), "The copied file was expected to be created inside the root.")
+	obtainedValue, err := fileSystem.FindMatchingContent(".", []string{"\\.txt$"}, true, false, true)
+	assert.NoErrorf(test, err, "An error was not expected when searching inside the root.")
+	assert.Equalf(test, []string{"./copy.txt", "data/file.txt"}, obtainedValue, "The matching content was not as expected.")
+}
+
+func TestRootedPathEscapesAreRejected(test *testing.T) {
+	memoryFileSystem := GetFileSystem(GetMemoryBackend())
+	memoryFileSystem.CreateDirectory("/sandbox", 0)
+	memoryFileSystem.WriteBytesToFile("/secret.txt", []byte("secret"), 0)
+	fileSystem := GetFileSystem(GetRootedBackend(memoryFileSystem.GetBackend(), "/sandbox"))
+	err := fileSystem.DeleteDirectory("../../")
+	assert.Truef(test, errors.Is(err, ErrPathEscapesRoot), "Climbing above the root was expected to be rejected.")
+	_, err = fileSystem.GetFileContentsAsBytes("/secret.txt")
+	assert.Truef(test, errors.Is(err, ErrPathEscapesRoot), "Absolute paths were expected to be rejected.")
+	err = memoryFileSystem.GetBackend().Symlink("/secret.txt", "/sandbox/link.txt")
+	assert.NoErrorf(test, err, "An error was not expected when creating a symbolic link.")
+	_, err = fileSystem.GetFileContentsAsBytes("link.txt")
+	assert.Truef(test, errors.Is(err, ErrPathEscapesRoot), "Symbolic links leading outside of the root were expected to be rejected.")
+	err = fileSystem.GetBackend().Symlink("../secret.txt", "other_link.txt")
+	assert.Truef(test, errors.Is(err, ErrPathEscapesRoot), "Creating symbolic links leading outside of the root was expected to be rejected.")
+	assert.Truef(test, memoryFileSystem.IsFileExists("/secret.txt"), "Files outside of the root were not expected to be touched.")
+}
+
+func TestRootedFileSystemOnDisk(test *testing.T) {
+	directory := "/tmp/rooted_test"
+	DeleteDirectory(directory)
+	err := CreateDirectory(directory+"/inner", 0)
+	assert.NoErrorf(test, err, "An error was not expected when creating a directory.")
+	err = WriteBytesToFile("/tmp/rooted_outside.txt", []byte("outside"), 0666)
+	assert.NoErrorf(test, err, "An error was not expected when creating a sample file!")
+	err = os.Symlink("/tmp/rooted_outside.txt", directory+"/escape.txt")
+	assert.NoErrorf(test, err, "An error was not expected when creating a symbolic link.")
+	err = os.Symlink(directory+"/inner", directory+"/inner_link")
+	assert.NoErrorf(test, err, "An error was not expected when creating a symbolic link.")
+	fileSystem := GetRootedFileSystem(directory)
+	err = fileSystem.WriteBytesToFile("inner_link/file.txt", []byte("inside"), 0)
+	assert.NoErrorf(test, err, "Symbolic links which stay inside the root were expected to be followed.")
+	fileContents, _ := GetFileContentsAsBytes(directory + "/inner/file.txt")
+	assert.Equalf(test, "inside", string(fileContents), "The file was expected to be written through the symbolic link.")
+	_, err = fileSystem.GetFileContentsAsBytes("escape.txt")
+	assert.Truef(test, errors.Is(err, ErrPathEscapesRoot), "Symbolic links leading outside of the root were expected to be rejected.")
+	err = fileSystem.DeleteFile("escape.txt")
+	assert.NoErrorf(test, err, "Deleting a symbolic link itself was expected to be allowed.")
+	assert.Truef(test, IsFileExists("/tmp/rooted_outside.txt"), "The target of a deleted symbolic link was not expected to be touched.")
+	DeleteFile("/tmp/rooted_outside.txt")
+	DeleteDirectory(directory)
+}
@@ -0,0 +1,195 @@
+package filesystem
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"sync"
+)
+
+/*
+binaryDetectionSize is the number of bytes at the start of a file which are
+checked for a NUL byte to decide if the file is binary.
+*/
+const binaryDetectionSize = 8000
+
+/*
+SearchOptions allows you to control how the content of a directory tree is
+searched. In addition, the following information should be noted:
+
+- File matchers select which files are searched by name, in the same way as
+'FindMatchingContent'. If none are provided, every file is searched.
+
+- If 'IsRecursive' is set, subdirectories are searched as well.
+
+- If 'IsLiteral' is set, the pattern is treated as plain text. If
+'IsCaseInsensitive' is set, letters match regardless of case.
+
+- If you pass in a context line count greater than '0', up to that many
+lines before and after each matching line are included with the match.
+
+- If you pass in a worker count of '0', one worker per CPU is used.
+
+- Files containing a NUL byte near their start are treated as binary and
+skipped, unless 'IsBinaryIncluded' is set.
+*/
+type SearchOptions struct {
+	FileMatchers      []string
+	IsRecursive       bool
+	IsLiteral         bool
+	IsCaseInsensitive bool
+	ContextLineCount  int
+	WorkerCount       int
+	IsBinaryIncluded  bool
+}
+
+/*
+SearchMatch describes a single match found by a content search. Line and
+column numbers start at '1', with the column counted in bytes. The offset is
+the byte offset of the match within the file. The line is the full text of
+the matching line without its line ending.
+*/
+type SearchMatch struct {
+	Path          string
+	LineNumber    int64
+	ColumnNumber  int
+	Offset        int64
+	Text          string
+	Line          string
+	ContextBefore []string
+	ContextAfter  []string
+}
+
+/*
+SearchContent allows you to search the files of a directory tree for text
+matching a regular expression, in the same way as 'grep'. Files are
+streamed one line at a time by a bounded pool of workers, and every match is
+reported with its location. In addition, the following information should
+be noted:
+
+- Matches are returned ordered by path, and then by their position in the
+file. A line with several matches produces one result for each.
+
+- If a file can not be read, the remaining files are still searched. The
+matches found are returned along with the first error encountered.
+*/
+func (shared *fileSystemType) SearchContent(directoryPath string, regexMatcher string, options SearchOptions) ([]SearchMatch, error) {
+	regex, err := compileTextPattern("search", directoryPath, regexMatcher, options.IsLiteral, options.IsCaseInsensitive)
+	if err != nil {
+		return nil, err
+	}
+	fileMatchers := options.FileMatchers
+	if len(fileMatchers) == 0 {
+		fileMatchers = []string{".*"}
+	}
+	fileNames, err := shared.FindMatchingContent(directoryPath, fileMatchers, true, false, options.IsRecursive)
+	if err != nil {
+		return nil, err
+	}
+	workerCount := options.WorkerCount
+	if workerCount <= 0 {
+		workerCount = runtime.NumCPU()
+	}
+	fileMatches := make([][]SearchMatch, len(fileNames))
+	fileErrors := make([]error, len(fileNames))
+	fileIndexes := make(chan int)
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < workerCount; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for fileIndex := range fileIndexes {
+				fileMatches[fileIndex], fileErrors[fileIndex] = shared.searchFile(fileNames[fileIndex], regex, options)
+			}
+		}()
+	}
+	for fileIndex := range fileNames {
+		fileIndexes <- fileIndex
+	}
+	close(fileIndexes)
+	waitGroup.Wait()
+	var matches []SearchMatch
+	for fileIndex := range fileNames {
+		matches = append(matches, fileMatches[fileIndex]...)
+		if err == nil && fileErrors[fileIndex] != nil {
+			err = fileErrors[fileIndex]
+		}
+	}
+	return matches, err
+}
+
+/*
+searchFile allows you to find every match within a single file, skipping
+files which are not regular files or which appear to be binary.
+*/
+func (shared *fileSystemType) searchFile(fileName string, regex *regexp.Regexp, options SearchOptions) ([]SearchMatch, error) {
+	file, err := shared.backend.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fileInfo.Mode().IsRegular() {
+		return nil, nil
+	}
+	if !options.IsBinaryIncluded {
+		isBinary, err := isBinaryFile(file)
+		if err != nil || isBinary {
+			return nil, err
+		}
+	}
+	var matches []SearchMatch
+	var contextBefore []string
+	awaitingContextIndex := 0
+	scanner := getLineScanner(file, nil, LineScannerOptions{MaximumLineLength: maximumEditedLineLength})
+	for scanner.Scan() {
+		line := scanner.GetText()
+		for ; awaitingContextIndex < len(matches); awaitingContextIndex++ {
+			match := &matches[awaitingContextIndex]
+			if scanner.GetLineNumber()-match.LineNumber <= int64(options.ContextLineCount) {
+				break
+			}
+		}
+		for index := awaitingContextIndex; index < len(matches); index++ {
+			matches[index].ContextAfter = append(matches[index].ContextAfter, line)
+		}
+		for _, location := range regex.FindAllStringIndex(line, -1) {
+			var match SearchMatch
+			match.Path = fileName
+			match.LineNumber = scanner.GetLineNumber()
+			match.ColumnNumber = location[0] + 1
+			match.Offset = scanner.GetOffset() + int64(location[0])
+			match.Text = line[location[0]:location[1]]
+			match.Line = line
+			if options.ContextLineCount > 0 {
+				match.ContextBefore = append([]string(nil), contextBefore...)
+			}
+			matches = append(matches, match)
+		}
+		if options.ContextLineCount > 0 {
+			contextBefore = append(contextBefore, line)
+			if len(contextBefore) > options.ContextLineCount {
+				contextBefore = contextBefore[1:]
+			}
+		}
+	}
+	return matches, scanner.GetError()
+}
+
+/*
+isBinaryFile allows you to check if a file appears to be binary by looking
+for a NUL byte near its start, in the same way as 'grep' and 'git'.
+*/
+func isBinaryFile(file File) (bool, error) {
+	buffer := make([]byte, binaryDetectionSize)
+	bytesRead, err := file.ReadAt(buffer, 0)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return bytes.IndexByte(buffer[:bytesRead], 0) >= 0, nil
+}
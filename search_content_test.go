@@ -0,0 +1,32 @@
+package filesystem
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSearchContent(test *testing.T) {
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.CreateDirectory("/project/nested", 0755)
+	fileSystem.WriteBytesToFile("/project/main.go", []byte("package main\r\n\r\n// TODO: first\r\nfunc main() {} // todo: second\r\n"), 0)
+	fileSystem.WriteBytesToFile("/project/nested/util.go", []byte("package nested\n// TODO: third"), 0)
+	fileSystem.WriteBytesToFile("/project/nested/notes.txt", []byte("TODO: not a go file\n"), 0)
+	fileSystem.WriteBytesToFile("/project/image.go", []byte("TODO:\x00binary"), 0)
+	options := SearchOptions{FileMatchers: []string{`\.go$`}, IsRecursive: true, IsCaseInsensitive: true, ContextLineCount: 1, WorkerCount: 2}
+	matches, err := fileSystem.SearchContent("/project", `todo: \w+`, options)
+	assert.NoErrorf(test, err, "An error was not expected when searching content.")
+	expectedMatches := []SearchMatch{
+		{Path: "/project/main.go", LineNumber: 3, ColumnNumber: 4, Offset: 19, Text: "TODO: first", Line: "// TODO: first", ContextBefore: []string{""}, ContextAfter: []string{"func main() {} // todo: second"}},
+		{Path: "/project/main.go", LineNumber: 4, ColumnNumber: 19, Offset: 50, Text: "todo: second", Line: "func main() {} // todo: second", ContextBefore: []string{"// TODO: first"}},
+		{Path: "/project/nested/util.go", LineNumber: 2, ColumnNumber: 4, Offset: 18, Text: "TODO: third", Line: "// TODO: third", ContextBefore: []string{"package nested"}},
+	}
+	assert.Equalf(test, expectedMatches, matches, "The matches found were not as expected.")
+	options.IsBinaryIncluded = true
+	options.IsRecursive = false
+	options.ContextLineCount = 0
+	matches, _ = fileSystem.SearchContent("/project", `TODO:`, options)
+	assert.Equalf(test, 3, len(matches), "Binary files were expected to be searched when included.")
+	_, err = fileSystem.SearchContent("/project", `(`, options)
+	assert.Truef(test, errors.Is(err, ErrInvalidPattern), "An invalid pattern was expected to be rejected.")
+}
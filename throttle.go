@@ -0,0 +1,108 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+/*
+throttleType is a token bucket which limits the rate at which data may be
+transferred. It is safe to share a single throttle between several
+readers, in which case their combined rate is limited.
+*/
+type throttleType struct {
+	mutex          sync.Mutex
+	bytesPerSecond int64
+	capacity       int64
+	tokens         float64
+	lastRefillTime time.Time
+}
+
+type throttledReaderType struct {
+	ctx      context.Context
+	reader   io.Reader
+	throttle *throttleType
+}
+
+/*
+getThrottle allows you to create a token bucket which refills at the rate
+provided. The bucket holds a tenth of a second of data, which keeps the
+rate smooth while still allowing reads of a reasonable size.
+*/
+func getThrottle(bytesPerSecond int64) *throttleType {
+	var throttle throttleType
+	throttle.bytesPerSecond = bytesPerSecond
+	throttle.capacity = bytesPerSecond / 10
+	if throttle.capacity < 1 {
+		throttle.capacity = 1
+	}
+	throttle.tokens = float64(throttle.capacity)
+	throttle.lastRefillTime = time.Now()
+	return &throttle
+}
+
+/*
+take allows you to remove tokens for data which has been transferred,
+waiting until the bucket is no longer in debt. An error is returned if the
+context is cancelled while waiting.
+*/
+func (shared *throttleType) take(ctx context.Context, byteCount int) error {
+	shared.mutex.Lock()
+	currentTime := time.Now()
+	shared.tokens += currentTime.Sub(shared.lastRefillTime).Seconds() * float64(shared.bytesPerSecond)
+	if shared.tokens > float64(shared.capacity) {
+		shared.tokens = float64(shared.capacity)
+	}
+	shared.lastRefillTime = currentTime
+	shared.tokens -= float64(byteCount)
+	waitDuration := time.Duration(-shared.tokens / float64(shared.bytesPerSecond) * float64(time.Second))
+	shared.mutex.Unlock()
+	if waitDuration <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(waitDuration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+/*
+getReader allows you to wrap a reader so that data is read from it no
+faster than the throttle allows. Waiting stops early with an error if the
+context provided is cancelled.
+*/
+func (shared *throttleType) getReader(ctx context.Context, reader io.Reader) io.Reader {
+	return &throttledReaderType{ctx: ctx, reader: reader, throttle: shared}
+}
+
+/*
+GetThrottledReader allows you to wrap a reader so that data is read from it
+no faster than the number of bytes per second provided. This is useful for
+background transfers which should not saturate a shared link or disk. If
+you pass in a rate of '0' or less, the reader is returned unchanged.
+*/
+func GetThrottledReader(reader io.Reader, bytesPerSecond int64) io.Reader {
+	if bytesPerSecond <= 0 {
+		return reader
+	}
+	return getThrottle(bytesPerSecond).getReader(context.Background(), reader)
+}
+
+func (shared *throttledReaderType) Read(buffer []byte) (int, error) {
+	if int64(len(buffer)) > shared.throttle.capacity {
+		buffer = buffer[:shared.throttle.capacity]
+	}
+	byteCount, err := shared.reader.Read(buffer)
+	if byteCount > 0 {
+		if takeErr := shared.throttle.take(shared.ctx, byteCount); takeErr != nil && err == nil {
+			err = takeErr
+		}
+	}
+	return byteCount, err
+}
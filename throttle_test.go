@@ -0,0 +1,46 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetThrottledReader(test *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 4000))
+	startTime := time.Now()
+	readContent, err := ioutil.ReadAll(GetThrottledReader(bytes.NewReader(content), 200000))
+	elapsedTime := time.Since(startTime)
+	assert.NoErrorf(test, err, "An error was not expected when reading from a throttled reader.")
+	assert.Equalf(test, content, readContent, "The throttled content was not as expected.")
+	assert.Truef(test, elapsedTime >= 80*time.Millisecond, "Reading 40 KB at 200 KB per second took only '%s'.", elapsedTime)
+	assert.Truef(test, elapsedTime < 2*time.Second, "Reading 40 KB at 200 KB per second took '%s'.", elapsedTime)
+	unthrottledReader := bytes.NewReader(content)
+	assert.Equalf(test, unthrottledReader, GetThrottledReader(unthrottledReader, 0), "A rate of zero was expected to leave the reader unchanged.")
+}
+
+func TestThrottleCancel(test *testing.T) {
+	throttle := getThrottle(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	err := throttle.take(ctx, 100)
+	assert.Truef(test, errors.Is(err, context.Canceled), "Waiting for tokens was expected to stop when the context was cancelled.")
+}
+
+func TestCopyFileWithOptions(test *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 2000))
+	fileSystem := GetFileSystem(GetMemoryBackend())
+	fileSystem.writeFile("/source.bin", content, 0644)
+	startTime := time.Now()
+	err := fileSystem.CopyFileWithOptions("/source.bin", "/target.bin", CopyOptions{BytesPerSecond: 100000})
+	elapsedTime := time.Since(startTime)
+	assert.NoErrorf(test, err, "An error was not expected when copying a file with a rate limit.")
+	copiedContent, _ := fileSystem.GetFileContentsAsBytes("/target.bin")
+	assert.Equalf(test, content, copiedContent, "The copied content was not as expected.")
+	assert.Truef(test, elapsedTime >= 80*time.Millisecond, "Copying 20 KB at 100 KB per second took only '%s'.", elapsedTime)
+}
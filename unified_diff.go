@@ -0,0 +1,170 @@
+package filesystem
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+defaultDiffContextLineCount is the number of unchanged lines shown around
+each change in a unified diff.
+*/
+const defaultDiffContextLineCount = 3
+
+type unifiedDiffLineType struct {
+	text        string
+	isDelimited bool
+}
+
+/*
+unifiedDiffType builds a unified diff one line at a time, for edits which
+replace lines in place rather than moving them around. Only the hunk
+currently being built and a few lines of context are held in memory.
+*/
+type unifiedDiffType struct {
+	builder               strings.Builder
+	fileName              string
+	contextLineCount      int
+	contextLines          []unifiedDiffLineType
+	hunkLines             []string
+	oldStartLineNumber    int64
+	newStartLineNumber    int64
+	oldLineCount          int64
+	newLineCount          int64
+	trailingLineCount     int
+	isHunkOpen            bool
+	oldNextLineNumber     int64
+	newNextLineNumber     int64
+	isFileHeaderGenerated bool
+}
+
+/*
+getUnifiedDiff allows you to obtain a diff builder for the file provided.
+*/
+func getUnifiedDiff(fileName string) *unifiedDiffType {
+	var diff unifiedDiffType
+	diff.fileName = fileName
+	diff.contextLineCount = defaultDiffContextLineCount
+	diff.oldNextLineNumber = 1
+	diff.newNextLineNumber = 1
+	return &diff
+}
+
+/*
+addUnchangedLine allows you to record the next line of the file, which was
+left unchanged.
+*/
+func (shared *unifiedDiffType) addUnchangedLine(text string, isDelimited bool) {
+	shared.oldNextLineNumber++
+	shared.newNextLineNumber++
+	line := unifiedDiffLineType{text: text, isDelimited: isDelimited}
+	if shared.isHunkOpen {
+		shared.hunkLines = append(shared.hunkLines, formatDiffLine(" ", line))
+		shared.oldLineCount++
+		shared.newLineCount++
+		shared.trailingLineCount++
+		if shared.trailingLineCount > shared.contextLineCount*2 {
+			shared.closeHunk()
+		}
+	}
+	shared.contextLines = append(shared.contextLines, line)
+	if len(shared.contextLines) > shared.contextLineCount {
+		shared.contextLines = shared.contextLines[1:]
+	}
+}
+
+/*
+addChangedLine allows you to record that the next line of the file was
+replaced. Both the old and new text may contain newlines, in which case
+they are shown as several removed or added lines.
+*/
+func (shared *unifiedDiffType) addChangedLine(oldText string, newText string, isDelimited bool) {
+	if !shared.isHunkOpen {
+		shared.isHunkOpen = true
+		shared.oldStartLineNumber = shared.oldNextLineNumber - int64(len(shared.contextLines))
+		shared.newStartLineNumber = shared.newNextLineNumber - int64(len(shared.contextLines))
+		shared.oldLineCount = int64(len(shared.contextLines))
+		shared.newLineCount = int64(len(shared.contextLines))
+		for _, contextLine := range shared.contextLines {
+			shared.hunkLines = append(shared.hunkLines, formatDiffLine(" ", contextLine))
+		}
+	}
+	shared.contextLines = nil
+	shared.trailingLineCount = 0
+	oldLines := strings.Split(oldText, "\n")
+	for index, oldLine := range oldLines {
+		isOldLineDelimited := isDelimited || index < len(oldLines)-1
+		shared.hunkLines = append(shared.hunkLines, formatDiffLine("-", unifiedDiffLineType{text: oldLine, isDelimited: isOldLineDelimited}))
+		shared.oldLineCount++
+		shared.oldNextLineNumber++
+	}
+	newLines := strings.Split(newText, "\n")
+	for index, newLine := range newLines {
+		isNewLineDelimited := isDelimited || index < len(newLines)-1
+		shared.hunkLines = append(shared.hunkLines, formatDiffLine("+", unifiedDiffLineType{text: newLine, isDelimited: isNewLineDelimited}))
+		shared.newLineCount++
+		shared.newNextLineNumber++
+	}
+}
+
+/*
+closeHunk allows you to write out the hunk being built, keeping no more
+than the configured number of unchanged lines after the last change.
+*/
+func (shared *unifiedDiffType) closeHunk() {
+	if !shared.isHunkOpen {
+		return
+	}
+	excessLineCount := shared.trailingLineCount - shared.contextLineCount
+	if excessLineCount > 0 {
+		shared.hunkLines = shared.hunkLines[:len(shared.hunkLines)-excessLineCount]
+		shared.oldLineCount -= int64(excessLineCount)
+		shared.newLineCount -= int64(excessLineCount)
+	}
+	if !shared.isFileHeaderGenerated {
+		fmt.Fprintf(&shared.builder, "--- %s\n+++ %s\n", shared.fileName, shared.fileName)
+		shared.isFileHeaderGenerated = true
+	}
+	fmt.Fprintf(&shared.builder, "@@ -%d,%d +%d,%d @@\n", shared.oldStartLineNumber, shared.oldLineCount, shared.newStartLineNumber, shared.newLineCount)
+	for _, hunkLine := range shared.hunkLines {
+		shared.builder.WriteString(hunkLine)
+	}
+	shared.hunkLines = nil
+	shared.isHunkOpen = false
+}
+
+/*
+addUnchangedText allows you to record every line of a block of text which
+was left unchanged. Only the last line may be missing its newline.
+*/
+func (shared *unifiedDiffType) addUnchangedText(text string) {
+	for text != "" {
+		index := strings.IndexByte(text, '\n')
+		if index < 0 {
+			shared.addUnchangedLine(text, false)
+			return
+		}
+		shared.addUnchangedLine(text[:index], true)
+		text = text[index+1:]
+	}
+}
+
+/*
+getDiff allows you to finish the diff and obtain it as text. An empty string
+is returned if no lines were changed.
+*/
+func (shared *unifiedDiffType) getDiff() string {
+	shared.closeHunk()
+	return shared.builder.String()
+}
+
+/*
+formatDiffLine allows you to format a line of a hunk, marking a line which
+is not followed by a newline in the way 'patch' expects.
+*/
+func formatDiffLine(prefix string, line unifiedDiffLineType) string {
+	if line.isDelimited {
+		return prefix + line.text + "\n"
+	}
+	return prefix + line.text + "\n\\ No newline at end of file\n"
+}
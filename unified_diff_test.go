@@ -0,0 +1,27 @@
+package filesystem
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestUnifiedDiffHunks(test *testing.T) {
+	diff := getUnifiedDiff("file.txt")
+	for lineNumber := 1; lineNumber <= 19; lineNumber++ {
+		text := strconv.Itoa(lineNumber)
+		switch lineNumber {
+		case 2, 8:
+			diff.addChangedLine(text, text+"!", true)
+		case 16:
+			diff.addChangedLine(text, text+"a\n"+text+"b", true)
+		default:
+			diff.addUnchangedLine(text, lineNumber != 19)
+		}
+	}
+	expectedDiff := "--- file.txt\n+++ file.txt\n" +
+		"@@ -1,11 +1,11 @@\n 1\n-2\n+2!\n 3\n 4\n 5\n 6\n 7\n-8\n+8!\n 9\n 10\n 11\n" +
+		"@@ -13,7 +13,8 @@\n 13\n 14\n 15\n-16\n+16a\n+16b\n 17\n 18\n 19\n\\ No newline at end of file\n"
+	assert.Equalf(test, expectedDiff, diff.getDiff(), "Nearby changes were expected to share a hunk and distant ones to be split.")
+	assert.Equalf(test, "", getUnifiedDiff("file.txt").getDiff(), "A diff without changes was expected to be empty.")
+}